@@ -0,0 +1,349 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type client struct {
+	t     *testing.T
+	token string
+}
+
+func (c *client) do(method, url string, body, out interface{}) int {
+	c.t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+
+	req, err := http.NewRequest(method, url, &buf)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			c.t.Fatalf("%s %s: decoding response: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+type hotel struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	City      string `json:"city"`
+	Available bool   `json:"available"`
+}
+
+type searchResult struct {
+	Hotels []hotel `json:"hotels"`
+	Total  int     `json:"total"`
+}
+
+func (h *Harness) search(c *client, query string) searchResult {
+	c.t.Helper()
+
+	var result searchResult
+	if code := c.do("GET", h.SearchServer.URL+"/search?"+query, nil, &result); code != http.StatusOK {
+		c.t.Fatalf("search %q: expected 200, got %d", query, code)
+	}
+	return result
+}
+
+func (h *Harness) login(t *testing.T, username, password string) *client {
+	t.Helper()
+
+	anonymous := &client{t: t}
+	credentials := map[string]string{"username": username, "email": username + "@example.com", "password": password}
+	anonymous.do("POST", h.UserServer.URL+"/auth/register", credentials, nil)
+
+	var response struct {
+		Token string `json:"token"`
+	}
+	if code := anonymous.do("POST", h.UserServer.URL+"/auth/login", credentials, &response); code != http.StatusOK {
+		t.Fatalf("login as %s: expected 200, got %d", username, code)
+	}
+	return &client{t: t, token: response.Token}
+}
+
+func (h *Harness) createHotel(c *client, name, city string) hotel {
+	c.t.Helper()
+
+	var created hotel
+	code := c.do("POST", h.HotelServer.URL+"/hotels", map[string]interface{}{"name": name, "city": city}, &created)
+	if code != http.StatusCreated {
+		c.t.Fatalf("create hotel: expected 201, got %d", code)
+	}
+	return created
+}
+
+func TestCreateHotelSearchAndBook(t *testing.T) {
+	h := NewHarness()
+	defer h.Close()
+
+	admin := h.login(t, "admin", "admin123")
+	created := h.createHotel(admin, "Hotel Patagonia", "Bariloche")
+
+	// The created event reached search-service and the hotel is indexed
+	result := h.search(admin, "city=Bariloche")
+	if result.Total != 1 || result.Hotels[0].ID != created.ID {
+		t.Fatalf("expected the new hotel in search results, got %+v", result)
+	}
+
+	guest := h.login(t, "juana", "secret")
+	reservation := map[string]interface{}{
+		"hotel_id":  created.ID,
+		"check_in":  "2026-07-10T00:00:00Z",
+		"check_out": "2026-07-14T00:00:00Z",
+		"guests":    2,
+		"rooms":     1,
+	}
+	if code := guest.do("POST", h.UserServer.URL+"/reservations", reservation, nil); code != http.StatusCreated {
+		t.Fatalf("book hotel: expected 201, got %d", code)
+	}
+
+	var availability map[string]interface{}
+	url := fmt.Sprintf("%s/availability?hotel_id=%s&check_in=2026-07-11&check_out=2026-07-12", h.UserServer.URL, created.ID)
+	guest.do("GET", url, nil, &availability)
+	if availability["available"] != false {
+		t.Fatalf("expected hotel to be unavailable after booking, got %v", availability)
+	}
+
+	// Searching the booked dates filters the hotel out through user-service
+	result = h.search(guest, "city=Bariloche&check_in=2026-07-12&check_out=2026-07-13")
+	if result.Total != 0 {
+		t.Fatalf("expected no available hotels for booked dates, got %+v", result)
+	}
+
+	result = h.search(guest, "city=Bariloche&check_in=2026-08-01&check_out=2026-08-03")
+	if result.Total != 1 || !result.Hotels[0].Available {
+		t.Fatalf("expected hotel to be available on other dates, got %+v", result)
+	}
+
+	if code := guest.do("POST", h.UserServer.URL+"/reservations", reservation, nil); code != http.StatusConflict {
+		t.Fatalf("double booking: expected 409, got %d", code)
+	}
+}
+
+func TestHotelUpdatesAndDeletesReachSearch(t *testing.T) {
+	h := NewHarness()
+	defer h.Close()
+
+	admin := h.login(t, "admin", "admin123")
+	created := h.createHotel(admin, "Hotel Norte", "Salta")
+
+	update := map[string]interface{}{"name": "Hotel Norte Boutique", "city": "Jujuy"}
+	if code := admin.do("PUT", h.HotelServer.URL+"/hotels/"+created.ID, update, nil); code != http.StatusOK {
+		t.Fatalf("update hotel: expected 200, got %d", code)
+	}
+
+	if result := h.search(admin, "city=Salta"); result.Total != 0 {
+		t.Fatalf("expected hotel to leave Salta results, got %+v", result)
+	}
+	result := h.search(admin, "city=Jujuy")
+	if result.Total != 1 || result.Hotels[0].Name != "Hotel Norte Boutique" {
+		t.Fatalf("expected updated hotel in Jujuy results, got %+v", result)
+	}
+
+	if code := admin.do("DELETE", h.HotelServer.URL+"/hotels/"+created.ID, nil, nil); code != http.StatusOK {
+		t.Fatalf("delete hotel: expected 200, got %d", code)
+	}
+	if result := h.search(admin, "city=Jujuy"); result.Total != 0 {
+		t.Fatalf("expected deleted hotel to leave search, got %+v", result)
+	}
+}
+
+func TestUserReservationsAndErasure(t *testing.T) {
+	h := NewHarness()
+	defer h.Close()
+
+	admin := h.login(t, "admin", "admin123")
+	created := h.createHotel(admin, "Hotel Sur", "Ushuaia")
+	guest := h.login(t, "pedro", "secret")
+
+	reservation := map[string]interface{}{
+		"hotel_id":  created.ID,
+		"check_in":  "2026-09-01T00:00:00Z",
+		"check_out": "2026-09-03T00:00:00Z",
+	}
+	var booked struct {
+		UserID int `json:"user_id"`
+	}
+	if code := guest.do("POST", h.UserServer.URL+"/reservations", reservation, &booked); code != http.StatusCreated {
+		t.Fatalf("book hotel: expected 201, got %d", code)
+	}
+
+	var export struct {
+		Reservations []map[string]interface{} `json:"reservations"`
+	}
+	if code := guest.do("GET", h.UserServer.URL+"/users/me/export", nil, &export); code != http.StatusOK {
+		t.Fatalf("export: expected 200, got %d", code)
+	}
+	if len(export.Reservations) != 1 || export.Reservations[0]["hotel_id"] != created.ID {
+		t.Fatalf("expected the booking in the export, got %+v", export)
+	}
+
+	erasureURL := fmt.Sprintf("%s/users/%d/erasure", h.UserServer.URL, booked.UserID)
+	if code := admin.do("POST", erasureURL, nil, nil); code != http.StatusAccepted {
+		t.Fatalf("erasure: expected 202, got %d", code)
+	}
+
+	var job struct {
+		Status string `json:"status"`
+	}
+	for i := 0; i < 100 && job.Status != "completed"; i++ {
+		admin.do("GET", erasureURL, nil, &job)
+		time.Sleep(5 * time.Millisecond)
+	}
+	if job.Status != "completed" {
+		t.Fatalf("expected erasure to complete, got %q", job.Status)
+	}
+
+	anonymous := &client{t: t}
+	credentials := map[string]string{"username": "pedro", "password": "secret"}
+	if code := anonymous.do("POST", h.UserServer.URL+"/auth/login", credentials, nil); code != http.StatusUnauthorized {
+		t.Fatalf("expected erased user to be unable to log in, got %d", code)
+	}
+}
+
+func TestHotelAmadeusIDSyncsToMappings(t *testing.T) {
+	h := NewHarness()
+	defer h.Close()
+
+	admin := h.login(t, "admin", "admin123")
+	mapped := h.createHotel(admin, "Hotel Centro", "Mendoza")
+	unmapped := h.createHotel(admin, "Hotel Valle", "Mendoza")
+
+	update := map[string]interface{}{"name": "Hotel Centro", "city": "Mendoza", "amadeus_id": "MZMDZ001"}
+	if code := admin.do("PUT", h.HotelServer.URL+"/hotels/"+mapped.ID, update, nil); code != http.StatusOK {
+		t.Fatalf("update hotel: expected 200, got %d", code)
+	}
+
+	var mapping struct {
+		AmadeusID string `json:"amadeus_id"`
+		Source    string `json:"source"`
+	}
+	if code := admin.do("GET", h.UserServer.URL+"/mappings/"+mapped.ID, nil, &mapping); code != http.StatusOK {
+		t.Fatalf("get mapping: expected 200, got %d", code)
+	}
+	if mapping.AmadeusID != "MZMDZ001" || mapping.Source != "hotel" {
+		t.Fatalf("expected mapping synced from the hotel document, got %+v", mapping)
+	}
+
+	var report struct {
+		Hotels []hotel `json:"hotels"`
+	}
+	admin.do("GET", h.UserServer.URL+"/mappings/unmapped", nil, &report)
+	if len(report.Hotels) != 1 || report.Hotels[0].ID != unmapped.ID {
+		t.Fatalf("expected only the hotel without amadeus_id to be unmapped, got %+v", report)
+	}
+
+	if code := admin.do("DELETE", h.HotelServer.URL+"/hotels/"+mapped.ID, nil, nil); code != http.StatusOK {
+		t.Fatalf("delete hotel: expected 200, got %d", code)
+	}
+	if code := admin.do("GET", h.UserServer.URL+"/mappings/"+mapped.ID, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("expected mapping to be removed with the hotel, got %d", code)
+	}
+}
+
+func TestAmadeusImportReachesSearchAndMappings(t *testing.T) {
+	h := NewHarness()
+	defer h.Close()
+
+	admin := h.login(t, "admin", "admin123")
+
+	var job struct {
+		ID      string `json:"id"`
+		Status  string `json:"status"`
+		Created int    `json:"created"`
+	}
+	if code := admin.do("POST", h.HotelServer.URL+"/hotels/import", map[string]string{"city_code": "PAR"}, &job); code != http.StatusAccepted {
+		t.Fatalf("start import: expected 202, got %d", code)
+	}
+	for i := 0; i < 100 && job.Status != "completed" && job.Status != "failed"; i++ {
+		time.Sleep(5 * time.Millisecond)
+		admin.do("GET", h.HotelServer.URL+"/hotels/import/"+job.ID, nil, &job)
+	}
+	if job.Status != "completed" || job.Created != 2 {
+		t.Fatalf("expected import to create 2 hotels, got %+v", job)
+	}
+
+	result := h.search(admin, "city=PAR")
+	if result.Total != 2 {
+		t.Fatalf("expected imported hotels in search results, got %+v", result)
+	}
+
+	var mapping struct {
+		AmadeusID string `json:"amadeus_id"`
+	}
+	if code := admin.do("GET", h.UserServer.URL+"/mappings/"+result.Hotels[0].ID, nil, &mapping); code != http.StatusOK {
+		t.Fatalf("get mapping: expected 200, got %d", code)
+	}
+	if mapping.AmadeusID == "" {
+		t.Fatal("expected imported hotel to be mapped to its Amadeus ID")
+	}
+}
+
+func TestReservationIsChargedAtHotelPrice(t *testing.T) {
+	h := NewHarness()
+	defer h.Close()
+
+	admin := h.login(t, "admin", "admin123")
+	var created hotel
+	newHotel := map[string]interface{}{"name": "Hotel Lago", "city": "Bariloche", "pricePerNight": 120}
+	if code := admin.do("POST", h.HotelServer.URL+"/hotels", newHotel, &created); code != http.StatusCreated {
+		t.Fatalf("create hotel: expected 201, got %d", code)
+	}
+
+	guest := h.login(t, "lucia", "secret")
+	reservation := map[string]interface{}{
+		"hotel_id":      created.ID,
+		"check_in":      "2026-10-01T00:00:00Z",
+		"check_out":     "2026-10-04T00:00:00Z",
+		"payment_token": "tok_visa",
+	}
+	var booked struct {
+		ID     int    `json:"id"`
+		Status string `json:"status"`
+	}
+	if code := guest.do("POST", h.UserServer.URL+"/reservations", reservation, &booked); code != http.StatusCreated {
+		t.Fatalf("book hotel: expected 201, got %d", code)
+	}
+
+	var payment struct {
+		Amount float64 `json:"amount"`
+		Status string  `json:"status"`
+	}
+	if code := guest.do("GET", fmt.Sprintf("%s/reservations/%d/payment", h.UserServer.URL, booked.ID), nil, &payment); code != http.StatusOK {
+		t.Fatalf("get payment: expected 200, got %d", code)
+	}
+	if booked.Status != "confirmed" || payment.Amount != 360 || payment.Status != "captured" {
+		t.Fatalf("expected 3 nights at 120 captured, got %+v / %+v", booked, payment)
+	}
+
+	reservation["payment_token"] = "tok_declined"
+	reservation["check_in"] = "2026-11-01T00:00:00Z"
+	reservation["check_out"] = "2026-11-02T00:00:00Z"
+	if code := guest.do("POST", h.UserServer.URL+"/reservations", reservation, nil); code != http.StatusPaymentRequired {
+		t.Fatalf("declined card: expected 402, got %d", code)
+	}
+}
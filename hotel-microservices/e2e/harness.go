@@ -0,0 +1,94 @@
+// Package e2e levanta hotel-service, search-service y user-service en el
+// mismo proceso, sobre repositorios en memoria, para probar flujos completos
+// sin Docker ni red.
+package e2e
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+
+	hotelservice "hotel-service"
+	searchservice "search-service"
+	userservice "user-service"
+)
+
+const JWTSecret = "e2e-secret"
+
+type Harness struct {
+	HotelServer  *httptest.Server
+	SearchServer *httptest.Server
+	UserServer   *httptest.Server
+
+	Hotels      hotelservice.HotelRepository
+	HotelEvents *hotelservice.MemoryPublisher
+	SearchIndex searchservice.SearchIndex
+	UserStore   *userservice.MemoryStore
+	UserEvents  *userservice.MemoryPublisher
+
+	// Amadeus atiende la importación de hoteles de hotel-service
+	Amadeus  *userservice.FakeAmadeus
+	Payments *userservice.FakePaymentProvider
+}
+
+func NewHarness() *Harness {
+	gin.SetMode(gin.TestMode)
+
+	h := &Harness{
+		Hotels:      hotelservice.NewMemoryHotelRepository(),
+		HotelEvents: hotelservice.NewMemoryPublisher(),
+		SearchIndex: searchservice.NewMemoryIndex(),
+		UserStore:   userservice.NewMemoryStore(),
+		UserEvents:  userservice.NewMemoryPublisher(),
+		Amadeus:     userservice.NewFakeAmadeus(),
+		Payments:    userservice.NewFakePaymentProvider(),
+	}
+
+	hotelRepos := hotelservice.NewMemoryRepositories()
+	hotelRepos.Hotels = h.Hotels
+	hotels := hotelservice.NewHotelService(hotelRepos, h.HotelEvents,
+		hotelservice.NewAmadeusClient(h.Amadeus.URL(), h.Amadeus.ClientID, h.Amadeus.ClientSecret))
+	// Hace de StartOutboxRelay: al terminar cada pedido publica lo que quedó en
+	// el outbox, para que los tests no dependan de un tick
+	hotelRouter := hotelservice.NewRouter(hotels)
+	h.HotelServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hotelRouter.ServeHTTP(w, r)
+		hotels.RelayOutbox(r.Context())
+	}))
+
+	users := userservice.NewUserService(h.UserStore.Repositories(), userservice.NewMemoryCache(), h.UserEvents, userservice.Config{
+		PaymentProvider: h.Payments,
+		HotelServiceURL: h.HotelServer.URL + "/hotels",
+		JWTSecret:       JWTSecret,
+	})
+	users.CreateAdminUser()
+	h.UserServer = httptest.NewServer(userservice.NewRouter(users))
+
+	search := searchservice.NewSearchService(
+		h.SearchIndex,
+		searchservice.NewHTTPAvailabilityChecker(h.UserServer.URL),
+		searchservice.NewMemoryDeadLetterQueue(),
+		h.HotelServer.URL+"/hotels",
+	)
+	search.SetJWTSecret(JWTSecret)
+	h.SearchServer = httptest.NewServer(searchservice.NewRouter(search))
+
+	// Hace de RabbitMQ: cada evento del fanout de hoteles se entrega en el acto
+	// a search-service y a user-service
+	h.HotelEvents.Subscribe(func(msg hotelservice.PublishedMessage) {
+		if msg.Exchange == hotelservice.HotelEventsExchange {
+			search.ProcessHotelUpdate(msg.Body)
+			users.HandleHotelUpdate(msg.Body)
+		}
+	})
+
+	return h
+}
+
+func (h *Harness) Close() {
+	h.SearchServer.Close()
+	h.HotelServer.Close()
+	h.UserServer.Close()
+	h.Amadeus.Close()
+}
@@ -0,0 +1,182 @@
+// Package amqpx es la conexión con RabbitMQ que comparten los servicios
+package amqpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// ErrUnavailable es lo que devuelven los canales mientras no hay conexión
+var ErrUnavailable = errors.New("rabbitmq is not connected")
+
+const (
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+)
+
+// RabbitMQ mantiene la conexión con el broker: si se cae, vuelve a conectar
+// con backoff y a declarar la topología, y los consumidores que esperan en
+// WaitChannel retoman con un canal nuevo. Los publicadores usan Channel, que
+// no espera: sin conexión devuelve ErrUnavailable.
+type RabbitMQ struct {
+	url      string
+	topology func(*amqp.Channel) error
+
+	mu   sync.Mutex
+	conn *amqp.Connection
+	// ready se cierra cuando hay conexión y se reemplaza cuando se pierde
+	ready   chan struct{}
+	lastErr error
+}
+
+// NewRabbitMQ no conecta: la conexión la abre y la mantiene Start. topology
+// declara exchanges y colas en cada conexión; puede ser nil.
+func NewRabbitMQ(url string, topology func(*amqp.Channel) error) *RabbitMQ {
+	return &RabbitMQ{
+		url:      url,
+		topology: topology,
+		ready:    make(chan struct{}),
+		lastErr:  errors.New("rabbitmq connection not started"),
+	}
+}
+
+// Start conecta en segundo plano y reconecta cada vez que se pierde la
+// conexión, hasta que se cancele ctx
+func (r *RabbitMQ) Start(ctx context.Context) {
+	go func() {
+		backoff := minBackoff
+		for {
+			conn, err := r.connect()
+			if err != nil {
+				r.disconnected(err)
+				log.Printf("Error connecting to RabbitMQ, retrying in %s: %v", backoff, err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+				if backoff *= 2; backoff > maxBackoff {
+					backoff = maxBackoff
+				}
+				continue
+			}
+
+			backoff = minBackoff
+			closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+			r.connected(conn)
+			log.Printf("Connected to RabbitMQ")
+
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				r.disconnected(ctx.Err())
+				return
+			case amqpErr := <-closed:
+				r.disconnected(fmt.Errorf("connection lost: %v", amqpErr))
+				log.Printf("RabbitMQ connection lost, reconnecting: %v", amqpErr)
+			}
+		}
+	}()
+}
+
+func (r *RabbitMQ) connect() (*amqp.Connection, error) {
+	conn, err := amqp.Dial(r.url)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	defer ch.Close()
+
+	if r.topology == nil {
+		return conn, nil
+	}
+	if err := r.topology(ch); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("declaring topology: %w", err)
+	}
+	return conn, nil
+}
+
+func (r *RabbitMQ) connected(conn *amqp.Connection) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.conn = conn
+	r.lastErr = nil
+	close(r.ready)
+}
+
+func (r *RabbitMQ) disconnected(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn != nil {
+		r.conn = nil
+		r.ready = make(chan struct{})
+	}
+	r.lastErr = err
+}
+
+// Channel abre un canal en la conexión actual, o devuelve
+// ErrUnavailable si no hay
+func (r *RabbitMQ) Channel() (*amqp.Channel, error) {
+	r.mu.Lock()
+	conn := r.conn
+	r.mu.Unlock()
+
+	if conn == nil {
+		return nil, ErrUnavailable
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return ch, nil
+}
+
+// WaitChannel espera a que haya conexión y abre un canal en ella
+func (r *RabbitMQ) WaitChannel(ctx context.Context) (*amqp.Channel, error) {
+	for {
+		r.mu.Lock()
+		ready := r.ready
+		r.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ready:
+		}
+
+		// La conexión puede haberse caído entre que avisó y ahora
+		if ch, err := r.Channel(); err == nil {
+			return ch, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(minBackoff):
+		}
+	}
+}
+
+// Ready devuelve nil si hay conexión, o el motivo por el que no la hay
+func (r *RabbitMQ) Ready() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, r.lastErr)
+	}
+	return nil
+}
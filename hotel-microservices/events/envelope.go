@@ -0,0 +1,156 @@
+// Package events define el sobre con el que viajan todos los eventos entre
+// servicios y los payloads de cada tipo.
+//
+// Reglas de compatibilidad:
+//   - Agregar un campo opcional al payload no cambia la versión; los
+//     consumidores ignoran los campos que no conocen.
+//   - Quitar o renombrar un campo, o cambiar su tipo o su significado, sube la
+//     versión del tipo y exige un upcaster de la versión anterior a la nueva.
+//   - Los productores publican siempre la versión actual. Decode lleva los
+//     eventos viejos a la versión actual y rechaza los de versiones que el
+//     consumidor todavía no conoce, así un consumidor se actualiza antes que
+//     el productor.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrInvalidEnvelope es un mensaje que no es un sobre válido
+	ErrInvalidEnvelope = errors.New("invalid event envelope")
+	// ErrUnknownEventType es un tipo que no está registrado en este paquete
+	ErrUnknownEventType = errors.New("unknown event type")
+	// ErrUnsupportedVersion es una versión más nueva que la que conoce el consumidor
+	ErrUnsupportedVersion = errors.New("unsupported event version")
+)
+
+// Envelope es lo que se publica en RabbitMQ; Payload depende de Type y Version
+type Envelope struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Version    int       `json:"version"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Producer   string    `json:"producer"`
+	// CorrelationID agrupa los eventos que salen de una misma operación
+	CorrelationID string          `json:"correlation_id"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// New arma un sobre con la versión actual de eventType. Si ctx no trae
+// correlation ID, el evento abre su propia correlación con su ID.
+func New(ctx context.Context, eventType, producer string, payload interface{}) (Envelope, error) {
+	definition, ok := registry[eventType]
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	envelope := Envelope{
+		ID:            NewID(),
+		Type:          eventType,
+		Version:       definition.version,
+		OccurredAt:    time.Now().UTC(),
+		Producer:      producer,
+		CorrelationID: CorrelationID(ctx),
+		Payload:       body,
+	}
+	if envelope.CorrelationID == "" {
+		envelope.CorrelationID = envelope.ID
+	}
+	return envelope, nil
+}
+
+// Decode lee un sobre y lo lleva a la versión actual de su tipo. También
+// acepta los hotel_updates de antes del sobre ({"action", "hotel"}), como
+// versión 0 de hotel.*.
+func Decode(body []byte) (Envelope, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if _, ok := raw["type"]; !ok {
+		if _, legacy := raw["action"]; legacy {
+			return decodeLegacyHotelUpdate(body)
+		}
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if envelope.Type == "" || envelope.Version < 1 || len(envelope.Payload) == 0 {
+		return Envelope{}, fmt.Errorf("%w: type, version and payload are required", ErrInvalidEnvelope)
+	}
+	return upgrade(envelope)
+}
+
+// upgrade aplica los upcasters desde la versión del sobre hasta la actual
+func upgrade(envelope Envelope) (Envelope, error) {
+	definition, ok := registry[envelope.Type]
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: %s", ErrUnknownEventType, envelope.Type)
+	}
+	if envelope.Version > definition.version {
+		return Envelope{}, fmt.Errorf("%w: %s v%d, latest known is v%d", ErrUnsupportedVersion, envelope.Type, envelope.Version, definition.version)
+	}
+
+	for envelope.Version < definition.version {
+		upcast, ok := definition.upcasters[envelope.Version]
+		if !ok {
+			return Envelope{}, fmt.Errorf("%w: no upgrade from %s v%d", ErrUnsupportedVersion, envelope.Type, envelope.Version)
+		}
+		payload, err := upcast(envelope.Payload)
+		if err != nil {
+			return Envelope{}, fmt.Errorf("%w: upgrading %s v%d: %v", ErrInvalidEnvelope, envelope.Type, envelope.Version, err)
+		}
+		envelope.Payload = payload
+		envelope.Version++
+	}
+	return envelope, nil
+}
+
+// DecodePayload deserializa el payload en v, que debe ser el tipo del payload
+// de la versión actual
+func (e Envelope) DecodePayload(v interface{}) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("%w: decoding %s payload: %v", ErrInvalidEnvelope, e.Type, err)
+	}
+	return nil
+}
+
+// NewID genera un UUID v4
+func NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+type correlationKey struct{}
+
+// WithCorrelationID guarda en ctx el correlation ID que New pone en los eventos
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID devuelve el correlation ID de ctx, o "" si no tiene
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
@@ -0,0 +1,149 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestNewAndDecode(t *testing.T) {
+	ctx := WithCorrelationID(context.Background(), "req-1")
+	envelope, err := New(ctx, HotelUpdated, "hotel-service", HotelPayload{ID: "abc", Name: "Plaza", City: "Mendoza", TotalRooms: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(envelope)
+
+	decoded, err := Decode(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Type != HotelUpdated || decoded.Version != 1 || decoded.CorrelationID != "req-1" || decoded.Producer != "hotel-service" || decoded.ID != envelope.ID {
+		t.Fatalf("unexpected envelope %+v", decoded)
+	}
+
+	var hotel HotelPayload
+	if err := decoded.DecodePayload(&hotel); err != nil {
+		t.Fatal(err)
+	}
+	if hotel.Name != "Plaza" || hotel.TotalRooms != 12 {
+		t.Fatalf("unexpected payload %+v", hotel)
+	}
+}
+
+func TestNewStartsItsOwnCorrelation(t *testing.T) {
+	envelope, err := New(context.Background(), ReservationCreated, "user-service", ReservationPayload{ReservationID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope.CorrelationID != envelope.ID {
+		t.Fatalf("expected correlation ID %s, got %s", envelope.ID, envelope.CorrelationID)
+	}
+
+	if _, err := New(context.Background(), "hotel.archived", "hotel-service", nil); !errors.Is(err, ErrUnknownEventType) {
+		t.Fatalf("expected ErrUnknownEventType, got %v", err)
+	}
+}
+
+func TestDecodeUpgradesLegacyHotelUpdates(t *testing.T) {
+	decoded, err := Decode([]byte(`{"action":"created","hotel":{"id":"abc","name":"Plaza","city":"Mendoza","totalRooms":8,"pricePerNight":120}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hotel HotelPayload
+	decoded.DecodePayload(&hotel)
+	if decoded.Type != HotelCreated || decoded.Version != 1 || hotel.TotalRooms != 8 || hotel.PricePerNight != 120 {
+		t.Fatalf("unexpected upgrade %+v %+v", decoded, hotel)
+	}
+
+	decoded, err = Decode([]byte(`{"action":"deleted","hotel":{"id":"abc","name":"Plaza"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded.Payload) != `{"id":"abc"}` {
+		t.Fatalf("unexpected deleted payload %s", decoded.Payload)
+	}
+}
+
+func TestDecodeRejectsInvalidMessages(t *testing.T) {
+	cases := map[string]error{
+		`not json`: ErrInvalidEnvelope,
+		`{"id":"1","type":"hotel.created","payload":{}}`:              ErrInvalidEnvelope,
+		`{"id":"1","type":"hotel.archived","version":1,"payload":{}}`: ErrUnknownEventType,
+		`{"id":"1","type":"hotel.created","version":2,"payload":{}}`:  ErrUnsupportedVersion,
+		`{"action":"archived","hotel":{"id":"x"}}`:                    ErrUnknownEventType,
+		`{"action":"created"}`:                                        ErrInvalidEnvelope,
+	}
+	for body, expected := range cases {
+		if _, err := Decode([]byte(body)); !errors.Is(err, expected) {
+			t.Errorf("expected %v for %s, got %v", expected, body, err)
+		}
+	}
+}
+
+// jsonFields devuelve los nombres JSON de los campos de v
+func jsonFields(v interface{}) []string {
+	var fields []string
+	typ := reflect.TypeOf(v)
+	for i := 0; i < typ.NumField(); i++ {
+		fields = append(fields, strings.Split(typ.Field(i).Tag.Get("json"), ",")[0])
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func TestSchemasMatchPayloads(t *testing.T) {
+	payloads := map[string]interface{}{
+		HotelCreated:         HotelPayload{},
+		HotelUpdated:         HotelPayload{},
+		HotelDeleted:         HotelDeletedPayload{},
+		ReservationCreated:   ReservationPayload{},
+		ReservationModified:  ReservationPayload{},
+		ReservationCancelled: ReservationPayload{},
+		UserErased:           UserErasedPayload{},
+	}
+	if len(payloads) != len(registry) {
+		t.Fatalf("expected a payload for each of the %d registered types", len(registry))
+	}
+
+	for eventType, payload := range payloads {
+		raw, err := Schema(eventType, CurrentVersion(eventType))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var schema struct {
+			Required   []string                   `json:"required"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			t.Fatalf("invalid schema for %s: %v", eventType, err)
+		}
+
+		var properties []string
+		for name := range schema.Properties {
+			properties = append(properties, name)
+		}
+		sort.Strings(properties)
+		if fields := jsonFields(payload); !reflect.DeepEqual(fields, properties) {
+			t.Errorf("schema of %s describes %v, payload has %v", eventType, properties, fields)
+		}
+		for _, name := range schema.Required {
+			if _, ok := schema.Properties[name]; !ok {
+				t.Errorf("schema of %s requires undeclared %s", eventType, name)
+			}
+		}
+	}
+
+	var envelope struct {
+		Required []string `json:"required"`
+	}
+	json.Unmarshal(EnvelopeSchema(), &envelope)
+	sort.Strings(envelope.Required)
+	if fields := jsonFields(Envelope{}); !reflect.DeepEqual(fields, envelope.Required) {
+		t.Errorf("envelope schema requires %v, envelope has %v", envelope.Required, fields)
+	}
+}
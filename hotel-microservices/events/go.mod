@@ -0,0 +1,5 @@
+module events
+
+go 1.19
+
+require github.com/streadway/amqp v1.0.0
@@ -0,0 +1,51 @@
+// Package readiness arma el GET /ready de los servicios a partir de las
+// dependencias sin las que no pueden recibir tráfico
+package readiness
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+type check struct {
+	name  string
+	check func() error
+}
+
+// Checks es la lista de dependencias; el valor cero no tiene ninguna y
+// siempre está listo
+type Checks struct {
+	mu     sync.Mutex
+	checks []check
+}
+
+// Add suma una dependencia; ServeHTTP la consulta en cada pedido
+func (c *Checks) Add(name string, fn func() error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, check: fn})
+}
+
+// ServeHTTP responde 200 si todas las dependencias responden y 503 si no, con
+// el resultado de cada una
+func (c *Checks) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	checks := append([]check(nil), c.checks...)
+	c.mu.Unlock()
+
+	status := http.StatusOK
+	results := map[string]string{}
+	for _, rc := range checks {
+		if err := rc.check(); err != nil {
+			status = http.StatusServiceUnavailable
+			results[rc.name] = err.Error()
+			continue
+		}
+		results[rc.name] = "ok"
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"ready": status == http.StatusOK, "checks": results})
+}
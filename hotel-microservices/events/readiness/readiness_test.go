@@ -0,0 +1,32 @@
+package readiness
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChecksReportEachDependency(t *testing.T) {
+	var checks Checks
+	w := httptest.NewRecorder()
+	checks.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 without checks, got %d", w.Code)
+	}
+
+	checks.Add("mongodb", func() error { return nil })
+	checks.Add("rabbitmq", func() error { return errors.New("rabbitmq is not connected") })
+	w = httptest.NewRecorder()
+	checks.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+
+	var body struct {
+		Ready  bool              `json:"ready"`
+		Checks map[string]string `json:"checks"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusServiceUnavailable || body.Ready || body.Checks["mongodb"] != "ok" || body.Checks["rabbitmq"] != "rabbitmq is not connected" {
+		t.Fatalf("expected rabbitmq to fail readiness, got %d: %s", w.Code, w.Body.String())
+	}
+}
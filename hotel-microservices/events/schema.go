@@ -0,0 +1,35 @@
+package events
+
+import (
+	"embed"
+	"fmt"
+)
+
+//go:embed schemas/*.json
+var schemas embed.FS
+
+// schemaFiles indica qué JSON Schema describe el payload de cada tipo y versión
+var schemaFiles = map[string]map[int]string{
+	HotelCreated:         {1: "hotel.v1.json"},
+	HotelUpdated:         {1: "hotel.v1.json"},
+	HotelDeleted:         {1: "hotel-deleted.v1.json"},
+	ReservationCreated:   {1: "reservation.v1.json"},
+	ReservationModified:  {1: "reservation.v1.json"},
+	ReservationCancelled: {1: "reservation.v1.json"},
+	UserErased:           {1: "user-erased.v1.json"},
+}
+
+// EnvelopeSchema devuelve el JSON Schema del sobre
+func EnvelopeSchema() []byte {
+	schema, _ := schemas.ReadFile("schemas/envelope.json")
+	return schema
+}
+
+// Schema devuelve el JSON Schema del payload de eventType en version
+func Schema(eventType string, version int) ([]byte, error) {
+	file, ok := schemaFiles[eventType][version]
+	if !ok {
+		return nil, fmt.Errorf("%w: no schema for %s v%d", ErrUnknownEventType, eventType, version)
+	}
+	return schemas.ReadFile("schemas/" + file)
+}
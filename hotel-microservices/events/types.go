@@ -0,0 +1,152 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Tipos de evento. Los de hotel los publica hotel-service y los de reserva y
+// usuario user-service; el tipo sirve también de routing key.
+const (
+	HotelCreated = "hotel.created"
+	HotelUpdated = "hotel.updated"
+	HotelDeleted = "hotel.deleted"
+
+	ReservationCreated   = "reservation.created"
+	ReservationModified  = "reservation.modified"
+	ReservationCancelled = "reservation.cancelled"
+
+	UserErased = "user.erased"
+)
+
+// HotelPayload es el hotel completo, en hotel.created y hotel.updated (v1)
+type HotelPayload struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	City          string    `json:"city"`
+	Address       string    `json:"address"`
+	Amenities     []string  `json:"amenities"`
+	Images        []string  `json:"images"`
+	Thumbnail     string    `json:"thumbnail"`
+	TotalRooms    int       `json:"total_rooms"`
+	PricePerNight float64   `json:"price_per_night"`
+	Latitude      float64   `json:"latitude"`
+	Longitude     float64   `json:"longitude"`
+	AmadeusID     string    `json:"amadeus_id"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// HotelDeletedPayload es el payload de hotel.deleted (v1)
+type HotelDeletedPayload struct {
+	ID string `json:"id"`
+}
+
+// ReservationPayload es el payload de reservation.* (v1)
+type ReservationPayload struct {
+	ReservationID int       `json:"reservation_id"`
+	UserID        int       `json:"user_id"`
+	HotelID       string    `json:"hotel_id"`
+	CheckIn       time.Time `json:"check_in"`
+	CheckOut      time.Time `json:"check_out"`
+	Guests        int       `json:"guests"`
+	Rooms         int       `json:"rooms"`
+	Status        string    `json:"status"`
+	// PreviousStatus está vacío en reservation.created
+	PreviousStatus string `json:"previous_status,omitempty"`
+}
+
+// UserErasedPayload es el payload de user.erased (v1): los datos personales
+// del usuario ya se anonimizaron
+type UserErasedPayload struct {
+	UserID int `json:"user_id"`
+	// JobID es el job de borrado que lo anonimizó
+	JobID int `json:"job_id"`
+}
+
+// upcaster lleva un payload de la versión n a la n+1
+type upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+type definition struct {
+	// version es la versión actual, la que publican los productores
+	version int
+	// upcasters[n] convierte de la versión n a la n+1
+	upcasters map[int]upcaster
+}
+
+var registry = map[string]definition{
+	HotelCreated:         {version: 1, upcasters: map[int]upcaster{0: upcastLegacyHotel}},
+	HotelUpdated:         {version: 1, upcasters: map[int]upcaster{0: upcastLegacyHotel}},
+	HotelDeleted:         {version: 1, upcasters: map[int]upcaster{0: upcastLegacyHotelDeleted}},
+	ReservationCreated:   {version: 1},
+	ReservationModified:  {version: 1},
+	ReservationCancelled: {version: 1},
+	UserErased:           {version: 1},
+}
+
+// CurrentVersion devuelve la versión que se publica de eventType, o 0 si no existe
+func CurrentVersion(eventType string) int {
+	return registry[eventType].version
+}
+
+// legacyHotel es el Hotel de hotel-service tal como viajaba en los
+// hotel_updates sin sobre, con los nombres de campo de su API
+type legacyHotel struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	City          string    `json:"city"`
+	Address       string    `json:"address"`
+	Amenities     []string  `json:"amenities"`
+	Images        []string  `json:"images"`
+	Thumbnail     string    `json:"thumbnail"`
+	TotalRooms    int       `json:"totalRooms"`
+	PricePerNight float64   `json:"pricePerNight"`
+	Latitude      float64   `json:"latitude"`
+	Longitude     float64   `json:"longitude"`
+	AmadeusID     string    `json:"amadeus_id"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func upcastLegacyHotel(payload json.RawMessage) (json.RawMessage, error) {
+	var hotel legacyHotel
+	if err := json.Unmarshal(payload, &hotel); err != nil {
+		return nil, err
+	}
+	return json.Marshal(HotelPayload(hotel))
+}
+
+func upcastLegacyHotelDeleted(payload json.RawMessage) (json.RawMessage, error) {
+	var hotel legacyHotel
+	if err := json.Unmarshal(payload, &hotel); err != nil {
+		return nil, err
+	}
+	return json.Marshal(HotelDeletedPayload{ID: hotel.ID})
+}
+
+// decodeLegacyHotelUpdate convierte un {"action", "hotel"} en un sobre v0 de
+// hotel.<action> y lo lleva a la versión actual
+func decodeLegacyHotelUpdate(body []byte) (Envelope, error) {
+	var update struct {
+		Action string          `json:"action"`
+		Hotel  json.RawMessage `json:"hotel"`
+	}
+	if err := json.Unmarshal(body, &update); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if len(update.Hotel) == 0 {
+		return Envelope{}, fmt.Errorf("%w: legacy hotel update without hotel", ErrInvalidEnvelope)
+	}
+
+	envelope := Envelope{
+		ID:         NewID(),
+		Type:       "hotel." + update.Action,
+		Version:    0,
+		OccurredAt: time.Now().UTC(),
+		Producer:   "hotel-service",
+		Payload:    update.Hotel,
+	}
+	envelope.CorrelationID = envelope.ID
+	return upgrade(envelope)
+}
@@ -42,6 +42,8 @@ export const hotelService = {
   createHotel: (hotel) => api.post('/hotels', hotel),
   updateHotel: (id, hotel) => api.put(`/hotels/${id}`, hotel),
   deleteHotel: (id) => api.delete(`/hotels/${id}`),
+  importHotels: (cityCode) => api.post('/hotels/import', { city_code: cityCode }),
+  getImportJob: (id) => api.get(`/hotels/import/${id}`),
 };
 
 export const searchService = {
@@ -52,11 +54,20 @@ export const userService = {
   getUsers: () => api.get('/users'),
   getUser: (id) => api.get(`/users/${id}`),
   getUserReservations: (id) => api.get(`/users/${id}/reservations`),
+  exportMyData: () => api.get('/users/me/export'),
+  requestErasure: (id) => api.post(`/users/${id}/erasure`),
+  getErasureJob: (id) => api.get(`/users/${id}/erasure`),
 };
 
 export const reservationService = {
   createReservation: (reservation) => api.post('/reservations', reservation),
   getReservations: () => api.get('/reservations'),
+  cancelReservation: (id) => api.post(`/reservations/${id}/cancel`),
+  getReservationPayment: (id) => api.get(`/reservations/${id}/payment`),
+  checkInReservation: (id) => api.post(`/reservations/${id}/check-in`),
+  createHold: (hold) => api.post('/holds', hold),
+  getHold: (id) => api.get(`/holds/${id}`),
+  releaseHold: (id) => api.delete(`/holds/${id}`),
   checkAvailability: (params) => api.get('/availability', { params }),
   getHotelAvailability: (hotelId, checkIn, checkOut) => 
     api.get(`/hotels/${hotelId}/availability`, { 
@@ -64,4 +75,16 @@ export const reservationService = {
     }),
 };
 
-export default api;
\ No newline at end of file
+export const mappingService = {
+  getMappings: () => api.get('/mappings'),
+  getUnmappedHotels: () => api.get('/mappings/unmapped'),
+  lookupAmadeusHotels: (params) => api.get('/mappings/lookup', { params }),
+  setMapping: (hotelId, amadeusId) => api.put(`/mappings/${hotelId}`, { amadeus_id: amadeusId }),
+  deleteMapping: (hotelId) => api.delete(`/mappings/${hotelId}`),
+};
+
+export const jobService = {
+  getJobRuns: (params) => api.get('/jobs/runs', { params }),
+};
+
+export default api;
@@ -0,0 +1,152 @@
+package hotelservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultAmadeusBaseURL = "https://test.api.amadeus.com"
+
+var ErrAmadeusNotConfigured = errors.New("Amadeus credentials not configured")
+
+type AmadeusHotel struct {
+	HotelID  string `json:"hotelId"`
+	Name     string `json:"name"`
+	IATACode string `json:"iataCode"`
+	GeoCode  struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"geoCode"`
+	Address struct {
+		Lines       []string `json:"lines"`
+		PostalCode  string   `json:"postalCode"`
+		CityName    string   `json:"cityName"`
+		CountryCode string   `json:"countryCode"`
+	} `json:"address"`
+}
+
+// AmadeusClient es el cliente mínimo para importar hoteles: token y hotel list API
+type AmadeusClient struct {
+	baseURL      string
+	clientID     string
+	clientSecret string
+	HTTPClient   *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func NewAmadeusClient(baseURL, clientID, clientSecret string) *AmadeusClient {
+	if baseURL == "" {
+		baseURL = DefaultAmadeusBaseURL
+	}
+
+	return &AmadeusClient{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		HTTPClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (a *AmadeusClient) Configured() bool {
+	return a != nil && a.clientID != "" && a.clientSecret != ""
+}
+
+func (a *AmadeusClient) accessToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Now().Add(time.Minute).Before(a.tokenExpiry) {
+		return a.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", a.clientID)
+	form.Set("client_secret", a.clientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.baseURL+"/v1/security/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Amadeus token request returned status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+
+	a.token = token.AccessToken
+	a.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return a.token, nil
+}
+
+// HotelsByCity lista los hoteles de Amadeus para un código IATA de ciudad (p.ej. PAR)
+func (a *AmadeusClient) HotelsByCity(ctx context.Context, cityCode string) ([]AmadeusHotel, error) {
+	if !a.Configured() {
+		return nil, ErrAmadeusNotConfigured
+	}
+
+	token, err := a.accessToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting Amadeus token: %v", err)
+	}
+
+	query := url.Values{}
+	query.Set("cityCode", strings.ToUpper(cityCode))
+	req, err := http.NewRequestWithContext(ctx, "GET", a.baseURL+"/v1/reference-data/locations/hotels/by-city?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusBadRequest, http.StatusNotFound:
+		// Amadeus responde 400 cuando no encuentra hoteles para la ciudad
+		return []AmadeusHotel{}, nil
+	case http.StatusUnauthorized:
+		a.mu.Lock()
+		a.token = ""
+		a.mu.Unlock()
+		return nil, errors.New("Amadeus rejected the access token")
+	default:
+		return nil, fmt.Errorf("Amadeus hotel list returned status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Data []AmadeusHotel `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
@@ -3,6 +3,7 @@ module hotel-service
 go 1.19
 
 require (
+	events v0.0.0
 	github.com/gin-gonic/gin v1.9.1
 	github.com/streadway/amqp v1.0.0
 	go.mongodb.org/mongo-driver v1.12.1
@@ -42,3 +43,5 @@ require (
 	google.golang.org/protobuf v1.30.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace events => ../events
@@ -0,0 +1,177 @@
+package hotelservice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"events"
+)
+
+// ImportJob es una importación de hoteles de una ciudad desde Amadeus
+type ImportJob struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CityCode    string             `bson:"city_code" json:"city_code"`
+	Status      string             `bson:"status" json:"status"`
+	Created     int                `bson:"created" json:"created"`
+	Updated     int                `bson:"updated" json:"updated"`
+	Error       string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	CompletedAt *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+func (s *HotelService) startImport(c *gin.Context) {
+	var request struct {
+		CityCode string `json:"city_code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !s.amadeus.Configured() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Amadeus not configured"})
+		return
+	}
+
+	job := ImportJob{
+		ID:        primitive.NewObjectID(),
+		CityCode:  strings.ToUpper(request.CityCode),
+		Status:    "pending",
+		CreatedAt: time.Now(),
+	}
+	if err := s.importJobs.Create(c.Request.Context(), job); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	go s.runImport(events.CorrelationID(c.Request.Context()), job)
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (s *HotelService) getImportJob(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	job, err := s.importJobs.FindByID(c.Request.Context(), objectID)
+	if err == ErrImportJobNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// runImport corre en segundo plano; los eventos de todos los hoteles importados
+// llevan el correlation ID del pedido que la inició
+func (s *HotelService) runImport(correlationID string, job ImportJob) {
+	ctx := events.WithCorrelationID(context.Background(), correlationID)
+
+	job.Status = "running"
+	s.importJobs.Update(ctx, job)
+
+	err := s.importCity(ctx, &job)
+
+	now := time.Now()
+	job.CompletedAt = &now
+	job.Status = "completed"
+	if err != nil {
+		log.Printf("Import of %s failed: %v", job.CityCode, err)
+		job.Status = "failed"
+		job.Error = err.Error()
+	}
+	if err := s.importJobs.Update(ctx, job); err != nil {
+		log.Printf("Error saving import job %s: %v", job.ID.Hex(), err)
+	}
+}
+
+// importCity hace upsert por amadeus_id de los hoteles de la ciudad y publica
+// los mismos eventos que el alta y la edición manual
+func (s *HotelService) importCity(ctx context.Context, job *ImportJob) error {
+	amadeusHotels, err := s.amadeus.HotelsByCity(ctx, job.CityCode)
+	if err != nil {
+		return err
+	}
+
+	for _, amadeusHotel := range amadeusHotels {
+		if amadeusHotel.HotelID == "" {
+			continue
+		}
+
+		hotel, err := s.hotels.FindByAmadeusID(ctx, amadeusHotel.HotelID)
+		switch err {
+		case nil:
+			applyAmadeusHotel(&hotel, amadeusHotel, job.CityCode)
+			hotel.UpdatedAt = time.Now()
+			err := s.writeWithEvent(ctx, events.HotelUpdated, hotel, func(ctx context.Context) error {
+				return s.hotels.Update(ctx, hotel.ID, hotel)
+			})
+			if err != nil {
+				return fmt.Errorf("updating hotel %s: %v", amadeusHotel.HotelID, err)
+			}
+			job.Updated++
+		case ErrHotelNotFound:
+			hotel = Hotel{ID: primitive.NewObjectID(), AmadeusID: amadeusHotel.HotelID, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+			applyAmadeusHotel(&hotel, amadeusHotel, job.CityCode)
+			applyHotelDefaults(&hotel)
+			err := s.writeWithEvent(ctx, events.HotelCreated, hotel, func(ctx context.Context) error {
+				return s.hotels.Create(ctx, hotel)
+			})
+			if err != nil {
+				return fmt.Errorf("creating hotel %s: %v", amadeusHotel.HotelID, err)
+			}
+			job.Created++
+		default:
+			return err
+		}
+	}
+
+	log.Printf("Imported %d hotels from Amadeus for %s (%d created, %d updated)", job.Created+job.Updated, job.CityCode, job.Created, job.Updated)
+	return nil
+}
+
+// applyAmadeusHotel pisa solo los datos que vienen de Amadeus; descripción,
+// precios e imágenes cargados a mano se conservan
+func applyAmadeusHotel(hotel *Hotel, amadeusHotel AmadeusHotel, cityCode string) {
+	hotel.Name = titleCase(amadeusHotel.Name)
+	hotel.Latitude = amadeusHotel.GeoCode.Latitude
+	hotel.Longitude = amadeusHotel.GeoCode.Longitude
+
+	if amadeusHotel.Address.CityName != "" {
+		hotel.City = titleCase(amadeusHotel.Address.CityName)
+	} else if hotel.City == "" {
+		hotel.City = cityCode
+	}
+
+	address := strings.Join(amadeusHotel.Address.Lines, ", ")
+	if amadeusHotel.Address.PostalCode != "" {
+		address = strings.TrimPrefix(address+", "+amadeusHotel.Address.PostalCode, ", ")
+	}
+	if address != "" {
+		hotel.Address = titleCase(address)
+	}
+}
+
+// titleCase pasa "HOTEL PARIS OPERA" a "Hotel Paris Opera"; Amadeus devuelve todo en mayúsculas
+func titleCase(value string) string {
+	words := strings.Fields(strings.ToLower(value))
+	for i, word := range words {
+		runes := []rune(word)
+		words[i] = strings.ToUpper(string(runes[0])) + string(runes[1:])
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,154 @@
+package hotelservice
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"events"
+)
+
+// newFakeAmadeusHotelList responde token y hotel list; solo conoce PAR
+func newFakeAmadeusHotelList(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/security/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token", "expires_in": 1799})
+	})
+	mux.HandleFunc("/v1/reference-data/locations/hotels/by-city", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("cityCode") != "PAR" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"data": [
+			{"hotelId": "ADPAR001", "name": "HOTEL PARIS OPERA", "iataCode": "PAR",
+			 "geoCode": {"latitude": 48.8719, "longitude": 2.3316},
+			 "address": {"lines": ["4 RUE DE CAUMARTIN"], "postalCode": "75009", "cityName": "PARIS", "countryCode": "FR"}},
+			{"hotelId": "YXPARKPR", "name": "HOTEL PARK PARIS", "iataCode": "PAR",
+			 "geoCode": {"latitude": 48.8606, "longitude": 2.3376}}
+		]}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newImportTestService(t *testing.T) (*HotelService, *MemoryPublisher) {
+	service, publisher := newTestService()
+	service.amadeus = NewAmadeusClient(newFakeAmadeusHotelList(t).URL, "id", "secret")
+	return service, publisher
+}
+
+func TestImportCityUpsertsByAmadeusID(t *testing.T) {
+	service, publisher := newImportTestService(t)
+	ctx := context.Background()
+
+	existing := Hotel{ID: primitive.NewObjectID(), Name: "Opera", Description: "Cargado a mano", PricePerNight: 180, AmadeusID: "ADPAR001"}
+	service.hotels.Create(ctx, existing)
+
+	job := ImportJob{CityCode: "PAR"}
+	if err := service.importCity(ctx, &job); err != nil {
+		t.Fatal(err)
+	}
+	if job.Created != 1 || job.Updated != 1 {
+		t.Fatalf("expected 1 created and 1 updated, got %+v", job)
+	}
+
+	updated, _ := service.hotels.FindByAmadeusID(ctx, "ADPAR001")
+	if updated.ID != existing.ID || updated.Name != "Hotel Paris Opera" || updated.City != "Paris" {
+		t.Errorf("expected existing hotel to be refreshed from Amadeus, got %+v", updated)
+	}
+	if updated.Address != "4 Rue De Caumartin, 75009" || updated.Latitude != 48.8719 || updated.Longitude != 2.3316 {
+		t.Errorf("expected address and coordinates from Amadeus, got %+v", updated)
+	}
+	if updated.Description != "Cargado a mano" || updated.PricePerNight != 180 {
+		t.Errorf("expected manual fields to be kept, got %+v", updated)
+	}
+
+	created, err := service.hotels.FindByAmadeusID(ctx, "YXPARKPR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.City != "PAR" || len(created.Images) == 0 {
+		t.Errorf("expected new hotel with city code and defaults, got %+v", created)
+	}
+
+	relayOutbox(t, service)
+	eventTypes := map[string]int{}
+	for _, msg := range publisher.Messages() {
+		var envelope events.Envelope
+		json.Unmarshal(msg.Body, &envelope)
+		eventTypes[envelope.Type]++
+	}
+	if eventTypes[events.HotelCreated] != 1 || eventTypes[events.HotelUpdated] != 1 {
+		t.Errorf("expected one created and one updated event, got %v", eventTypes)
+	}
+
+	// Reimportar no duplica hoteles
+	job = ImportJob{CityCode: "PAR"}
+	service.importCity(ctx, &job)
+	hotels, _ := service.hotels.FindAll(ctx)
+	if len(hotels) != 2 || job.Created != 0 || job.Updated != 2 {
+		t.Errorf("expected re-import to only update, got %d hotels and %+v", len(hotels), job)
+	}
+}
+
+func TestStartImportRunsInBackground(t *testing.T) {
+	service, _ := newImportTestService(t)
+	router := NewRouter(service)
+
+	w := doRequest(router, "POST", "/hotels/import", map[string]interface{}{"city_code": "par"})
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var job ImportJob
+	json.Unmarshal(w.Body.Bytes(), &job)
+	if job.CityCode != "PAR" {
+		t.Errorf("expected city code to be normalized, got %q", job.CityCode)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for job.Status != "completed" && job.Status != "failed" {
+		if time.Now().After(deadline) {
+			t.Fatalf("import did not finish, last status %q", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		w := doRequest(router, "GET", "/hotels/import/"+job.ID.Hex(), nil)
+		json.Unmarshal(w.Body.Bytes(), &job)
+	}
+	if job.Status != "completed" || job.Created != 2 || job.CompletedAt == nil {
+		t.Errorf("expected completed import with 2 hotels, got %+v", job)
+	}
+
+	// "import" no debe confundirse con un ID de hotel
+	if w := doRequest(router, "GET", "/hotels", nil); w.Code != http.StatusOK {
+		t.Errorf("expected hotel list to keep working, got %d", w.Code)
+	}
+}
+
+func TestStartImportRequiresAmadeusAndCityCode(t *testing.T) {
+	service, _ := newTestService()
+	router := NewRouter(service)
+
+	if w := doRequest(router, "POST", "/hotels/import", map[string]interface{}{"city_code": "PAR"}); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without Amadeus credentials, got %d", w.Code)
+	}
+
+	service, _ = newImportTestService(t)
+	router = NewRouter(service)
+	if w := doRequest(router, "POST", "/hotels/import", map[string]interface{}{}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without city_code, got %d", w.Code)
+	}
+	if w := doRequest(router, "GET", "/hotels/import/bad", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid ID, got %d", w.Code)
+	}
+}
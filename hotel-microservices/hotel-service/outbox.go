@@ -0,0 +1,222 @@
+package hotelservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"events"
+	"events/amqpx"
+)
+
+// Estados de OutboxEvent.Status
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+)
+
+const (
+	outboxBatchSize  = 100
+	outboxMaxBackoff = 5 * time.Minute
+	// outboxRetention es cuánto se guardan los eventos ya publicados, para
+	// poder revisarlos; después PurgeOutbox los borra
+	outboxRetention     = 24 * time.Hour
+	outboxPurgeInterval = time.Hour
+	// outboxRelayLease es cuánto dura el turno de relay de una réplica; si se
+	// cae, otra lo toma cuando vence
+	outboxRelayLease = 30 * time.Second
+)
+
+// OutboxEvent es un evento guardado junto con el cambio que lo originó, a la
+// espera de que el relay lo publique en RabbitMQ
+type OutboxEvent struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	// EventID es el id del sobre que va en Body
+	EventID    string `bson:"event_id" json:"event_id"`
+	Exchange   string `bson:"exchange" json:"exchange"`
+	RoutingKey string `bson:"routing_key" json:"routing_key"`
+	// Body es el sobre ya serializado a JSON
+	Body          string     `bson:"body" json:"body"`
+	Status        string     `bson:"status" json:"status"`
+	Attempts      int        `bson:"attempts" json:"attempts"`
+	LastError     string     `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `bson:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time  `bson:"created_at" json:"created_at"`
+	SentAt        *time.Time `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+}
+
+// writeWithEvent corre write y guarda el evento eventType del hotel en el
+// outbox en la misma transacción; después despierta al relay para que lo
+// publique sin demorar el pedido
+func (s *HotelService) writeWithEvent(ctx context.Context, eventType string, hotel Hotel, write func(ctx context.Context) error) error {
+	err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := write(ctx); err != nil {
+			return err
+		}
+		return s.recordHotelEvent(ctx, eventType, hotel)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.wakeRelay()
+	return nil
+}
+
+// wakeRelay avisa a StartOutboxRelay que hay eventos nuevos. No bloquea: si
+// ya hay un aviso sin atender, ese relay también publica este evento.
+func (s *HotelService) wakeRelay() {
+	select {
+	case s.relayWake <- struct{}{}:
+	default:
+	}
+}
+
+// hotelEventPayload es el payload de eventType para hotel
+func hotelEventPayload(eventType string, hotel Hotel) interface{} {
+	if eventType == events.HotelDeleted {
+		return events.HotelDeletedPayload{ID: hotel.ID.Hex()}
+	}
+	return events.HotelPayload{
+		ID:            hotel.ID.Hex(),
+		Name:          hotel.Name,
+		Description:   hotel.Description,
+		City:          hotel.City,
+		Address:       hotel.Address,
+		Amenities:     hotel.Amenities,
+		Images:        hotel.Images,
+		Thumbnail:     hotel.Thumbnail,
+		TotalRooms:    hotel.TotalRooms,
+		PricePerNight: hotel.PricePerNight,
+		Latitude:      hotel.Latitude,
+		Longitude:     hotel.Longitude,
+		AmadeusID:     hotel.AmadeusID,
+		UpdatedAt:     hotel.UpdatedAt,
+	}
+}
+
+func (s *HotelService) recordHotelEvent(ctx context.Context, eventType string, hotel Hotel) error {
+	envelope, err := events.New(ctx, eventType, EventProducer, hotelEventPayload(eventType, hotel))
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.outbox.Create(ctx, OutboxEvent{
+		ID:            primitive.NewObjectID(),
+		EventID:       envelope.ID,
+		Exchange:      HotelEventsExchange,
+		RoutingKey:    eventType,
+		Body:          string(body),
+		Status:        OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+}
+
+// RelayOutbox publica los eventos pendientes en orden. Ante el primer fallo
+// agenda el reintento con backoff y corta, para no adelantar eventos
+// posteriores del mismo hotel. Devuelve cuántos publicó.
+//
+// Con varias réplicas publica sólo la que tiene el turno de relay, que se
+// renueva en cada pasada; las demás devuelven 0 sin tocar el outbox.
+//
+// La entrega es at-least-once: si el proceso se cae entre el publish y
+// MarkSent, o una pasada dura más que el turno, el evento se vuelve a publicar.
+func (s *HotelService) RelayOutbox(ctx context.Context) (int, error) {
+	s.relayMu.Lock()
+	defer s.relayMu.Unlock()
+
+	now := time.Now()
+	claimed, err := s.outbox.ClaimRelay(ctx, s.relayOwner, now, now.Add(outboxRelayLease))
+	if err != nil || !claimed {
+		return 0, err
+	}
+
+	pending, err := s.outbox.ListPending(ctx, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, event := range pending {
+		if event.NextAttemptAt.After(now) {
+			return i, nil
+		}
+
+		if err := s.publisher.Publish(event.Exchange, event.RoutingKey, json.RawMessage(event.Body)); err != nil {
+			// Sin conexión el evento espera sin gastar intentos; el próximo tick lo vuelve a probar
+			if errors.Is(err, amqpx.ErrUnavailable) {
+				return i, err
+			}
+			attempts := event.Attempts + 1
+			if markErr := s.outbox.MarkFailed(ctx, event.ID, attempts, err.Error(), now.Add(outboxBackoff(attempts))); markErr != nil {
+				log.Printf("Error recording failed outbox event %s: %v", event.ID.Hex(), markErr)
+			}
+			return i, fmt.Errorf("publishing outbox event %s (attempt %d): %w", event.ID.Hex(), attempts, err)
+		}
+
+		if err := s.outbox.MarkSent(ctx, event.ID, time.Now()); err != nil {
+			return i, err
+		}
+	}
+
+	return len(pending), nil
+}
+
+// PurgeOutbox borra los eventos publicados hace más de outboxRetention y
+// devuelve cuántos borró. Los pendientes no se tocan.
+func (s *HotelService) PurgeOutbox(ctx context.Context) (int64, error) {
+	return s.outbox.DeleteSentBefore(ctx, time.Now().Add(-outboxRetention))
+}
+
+// outboxBackoff duplica la espera en cada intento, hasta outboxMaxBackoff
+func outboxBackoff(attempts int) time.Duration {
+	backoff := time.Second
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return backoff
+}
+
+// StartOutboxRelay publica el outbox en segundo plano hasta que se cancele
+// ctx: apenas writeWithEvent avisa de un evento nuevo y, para los reintentos
+// con backoff, cada interval. Una vez por outboxPurgeInterval purga los
+// eventos ya publicados.
+func (s *HotelService) StartOutboxRelay(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var lastPurge time.Time
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.relayWake:
+			case <-ticker.C:
+			}
+
+			if _, err := s.RelayOutbox(ctx); err != nil {
+				log.Printf("Error relaying outbox: %v", err)
+			}
+			if time.Since(lastPurge) >= outboxPurgeInterval {
+				if _, err := s.PurgeOutbox(ctx); err != nil {
+					log.Printf("Error purging outbox: %v", err)
+				}
+				lastPurge = time.Now()
+			}
+		}
+	}()
+}
@@ -0,0 +1,194 @@
+package hotelservice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"events"
+	"events/amqpx"
+)
+
+// disconnectedPublisher es el publisher de confirms sin conexión al broker
+type disconnectedPublisher struct{}
+
+func (disconnectedPublisher) Publish(exchange, routingKey string, message interface{}) error {
+	return amqpx.ErrUnavailable
+}
+
+// flakyPublisher falla los primeros failures publish, como un RabbitMQ caído
+type flakyPublisher struct {
+	*MemoryPublisher
+	failures int
+}
+
+func (p *flakyPublisher) Publish(exchange, routingKey string, message interface{}) error {
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	return p.MemoryPublisher.Publish(exchange, routingKey, message)
+}
+
+func TestOutboxRetriesFailedPublish(t *testing.T) {
+	repos := NewMemoryRepositories()
+	publisher := &flakyPublisher{MemoryPublisher: NewMemoryPublisher(), failures: 1}
+	service := NewHotelService(repos, publisher, NewAmadeusClient("", "", ""))
+	router := NewRouter(service)
+	ctx := context.Background()
+
+	// El alta no espera a RabbitMQ: el evento queda en el outbox para el relay
+	if w := doRequest(router, "POST", "/hotels", map[string]interface{}{"name": "Hotel Sol", "city": "Córdoba"}); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	doRequest(router, "POST", "/hotels", map[string]interface{}{"name": "Hotel Luna", "city": "Córdoba"})
+	if len(publisher.Messages()) != 0 || publisher.failures != 1 {
+		t.Fatal("expected the requests not to publish")
+	}
+
+	if _, err := service.RelayOutbox(ctx); err == nil {
+		t.Fatal("expected the first publish to fail")
+	}
+	pending, _ := repos.Outbox.ListPending(ctx, 10)
+	if len(pending) != 2 || pending[0].Attempts != 1 || pending[0].LastError == "" || !pending[0].NextAttemptAt.After(time.Now()) {
+		t.Fatalf("expected both events pending with the first one backing off, got %+v", pending)
+	}
+	if len(publisher.Messages()) != 0 {
+		t.Fatal("expected the second event to wait for the first one")
+	}
+
+	// Todavía en backoff
+	if sent, err := service.RelayOutbox(ctx); sent != 0 || err != nil {
+		t.Fatalf("expected nothing to be relayed during the backoff, got %d, %v", sent, err)
+	}
+
+	repos.Outbox.MarkFailed(ctx, pending[0].ID, pending[0].Attempts, pending[0].LastError, time.Now())
+	if sent, err := service.RelayOutbox(ctx); sent != 2 || err != nil {
+		t.Fatalf("expected both events to be relayed, got %d, %v", sent, err)
+	}
+
+	messages := publisher.Messages()
+	if len(messages) != 2 || messages[0].Exchange != HotelEventsExchange || messages[0].RoutingKey != events.HotelCreated {
+		t.Fatalf("expected two hotel.created events, got %+v", messages)
+	}
+	if _, hotel := lastHotelEvent(t, publisher.MemoryPublisher); hotel["name"] != "Hotel Luna" {
+		t.Fatalf("expected events in order, got %v last", hotel["name"])
+	}
+	if pending, _ := repos.Outbox.ListPending(ctx, 10); len(pending) != 0 {
+		t.Fatalf("expected the outbox to be drained, got %+v", pending)
+	}
+}
+
+func TestStartOutboxRelayPublishesAfterWrite(t *testing.T) {
+	service, publisher := newTestService()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// El tick no llega durante el test: publica el aviso de writeWithEvent
+	service.StartOutboxRelay(ctx, time.Hour)
+
+	if w := doRequest(NewRouter(service), "POST", "/hotels", map[string]interface{}{"name": "Hotel Sol", "city": "Córdoba"}); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(publisher.Messages()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the relay to publish the event")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPurgeOutboxKeepsPendingAndRecentEvents(t *testing.T) {
+	repos := NewMemoryRepositories()
+	service := NewHotelService(repos, NewMemoryPublisher(), NewAmadeusClient("", "", ""))
+	ctx := context.Background()
+
+	old := time.Now().Add(-outboxRetention - time.Hour)
+	recent := time.Now()
+	for _, event := range []OutboxEvent{
+		{ID: primitive.NewObjectID(), Status: OutboxSent, SentAt: &old},
+		{ID: primitive.NewObjectID(), Status: OutboxSent, SentAt: &recent},
+		{ID: primitive.NewObjectID(), Status: OutboxPending, CreatedAt: old},
+	} {
+		repos.Outbox.Create(ctx, event)
+	}
+
+	if deleted, err := service.PurgeOutbox(ctx); deleted != 1 || err != nil {
+		t.Fatalf("expected only the old sent event to be purged, got %d, %v", deleted, err)
+	}
+	if pending, _ := repos.Outbox.ListPending(ctx, 10); len(pending) != 1 {
+		t.Fatalf("expected the pending event to be kept, got %+v", pending)
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		4:  8 * time.Second,
+		20: outboxMaxBackoff,
+	}
+	for attempts, expected := range cases {
+		if got := outboxBackoff(attempts); got != expected {
+			t.Fatalf("expected %s for attempt %d, got %s", expected, attempts, got)
+		}
+	}
+}
+
+func TestOutboxWaitsWithoutSpendingAttemptsWhileDisconnected(t *testing.T) {
+	repos := NewMemoryRepositories()
+	service := NewHotelService(repos, disconnectedPublisher{}, NewAmadeusClient("", "", ""))
+	ctx := context.Background()
+
+	if w := doRequest(NewRouter(service), "POST", "/hotels", map[string]interface{}{"name": "Hotel Sol", "city": "Córdoba"}); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := service.RelayOutbox(ctx); !errors.Is(err, amqpx.ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+
+	pending, _ := repos.Outbox.ListPending(ctx, 10)
+	if len(pending) != 1 || pending[0].Attempts != 0 || pending[0].NextAttemptAt.After(time.Now()) {
+		t.Fatalf("expected the event to stay due with no attempts, got %+v", pending)
+	}
+}
+
+func TestOutboxRelaysFromOneReplicaAtATime(t *testing.T) {
+	repos := NewMemoryRepositories()
+	first, second := NewMemoryPublisher(), NewMemoryPublisher()
+	replica1 := NewHotelService(repos, first, NewAmadeusClient("", "", ""))
+	replica2 := NewHotelService(repos, second, NewAmadeusClient("", "", ""))
+	ctx := context.Background()
+
+	if w := doRequest(NewRouter(replica1), "POST", "/hotels", map[string]interface{}{"name": "Hotel Sol", "city": "Córdoba"}); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(NewRouter(replica2), "POST", "/hotels", map[string]interface{}{"name": "Hotel Luna", "city": "Salta"}); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Las dos réplicas ven los mismos eventos, pero sólo la del turno los publica
+	if sent, err := replica1.RelayOutbox(ctx); sent != 2 || err != nil {
+		t.Fatalf("expected the first replica to relay both events, got %d, %v", sent, err)
+	}
+	if sent, err := replica2.RelayOutbox(ctx); sent != 0 || err != nil {
+		t.Fatalf("expected the second replica to wait for its turn, got %d, %v", sent, err)
+	}
+	if len(first.Messages()) != 2 || len(second.Messages()) != 0 {
+		t.Fatalf("expected each event published once, got %d and %d", len(first.Messages()), len(second.Messages()))
+	}
+
+	// Si la réplica del turno se cae, otra lo toma cuando vence
+	later := time.Now().Add(outboxRelayLease + time.Second)
+	if claimed, _ := repos.Outbox.ClaimRelay(ctx, replica2.relayOwner, time.Now(), later); claimed {
+		t.Fatal("expected the turn to stay with the first replica while it lasts")
+	}
+	if claimed, _ := repos.Outbox.ClaimRelay(ctx, replica2.relayOwner, later, later.Add(outboxRelayLease)); !claimed {
+		t.Fatal("expected an expired turn to be taken over")
+	}
+}
@@ -0,0 +1,195 @@
+package hotelservice
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"events/amqpx"
+)
+
+type EventPublisher interface {
+	Publish(exchange, routingKey string, message interface{}) error
+}
+
+type amqpPublisher struct {
+	channel *amqp.Channel
+}
+
+func NewAMQPPublisher(channel *amqp.Channel) EventPublisher {
+	return &amqpPublisher{channel: channel}
+}
+
+func (p *amqpPublisher) Publish(exchange, routingKey string, message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	return p.channel.Publish(
+		exchange,
+		routingKey,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+		},
+	)
+}
+
+type amqpConfirmPublisher struct {
+	mu       sync.Mutex
+	rabbit   *amqpx.RabbitMQ
+	channel  *amqp.Channel
+	confirms chan amqp.Confirmation
+	sequence uint64
+	timeout  time.Duration
+}
+
+// NewAMQPConfirmPublisher publica en modo confirm: Publish espera a que
+// RabbitMQ confirme el mensaje y devuelve error si lo rechaza o no contesta en
+// timeout. Sin conexión devuelve amqpx.ErrUnavailable y el evento queda en el outbox.
+func NewAMQPConfirmPublisher(rabbit *amqpx.RabbitMQ, timeout time.Duration) EventPublisher {
+	return &amqpConfirmPublisher{rabbit: rabbit, timeout: timeout}
+}
+
+// openChannel abre el canal la primera vez y después de cada error, porque
+// un canal de AMQP no sobrevive a la conexión que lo creó
+func (p *amqpConfirmPublisher) openChannel() error {
+	if p.channel != nil {
+		return nil
+	}
+
+	channel, err := p.rabbit.Channel()
+	if err != nil {
+		return err
+	}
+	if err := channel.Confirm(false); err != nil {
+		channel.Close()
+		return err
+	}
+
+	p.channel = channel
+	p.confirms = channel.NotifyPublish(make(chan amqp.Confirmation, 16))
+	p.sequence = 0
+	return nil
+}
+
+func (p *amqpConfirmPublisher) resetChannel() {
+	if p.channel != nil {
+		p.channel.Close()
+		p.channel = nil
+	}
+}
+
+func (p *amqpConfirmPublisher) Publish(exchange, routingKey string, message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	// Un publish a la vez, así la confirmación que llega es la de este mensaje
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.openChannel(); err != nil {
+		return err
+	}
+
+	err = p.channel.Publish(
+		exchange,
+		routingKey,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Body:         body,
+		},
+	)
+	if err != nil {
+		p.resetChannel()
+		return err
+	}
+	p.sequence++
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case confirmation, ok := <-p.confirms:
+			if !ok {
+				p.resetChannel()
+				return errors.New("channel closed before the message was confirmed")
+			}
+			// Confirmación tardía de un publish anterior que ya dio timeout
+			if confirmation.DeliveryTag < p.sequence {
+				continue
+			}
+			if !confirmation.Ack {
+				return fmt.Errorf("message %d was nacked by the broker", confirmation.DeliveryTag)
+			}
+			return nil
+		case <-timer.C:
+			return fmt.Errorf("message %d not confirmed after %s", p.sequence, p.timeout)
+		}
+	}
+}
+
+type PublishedMessage struct {
+	Exchange   string
+	RoutingKey string
+	Body       []byte
+}
+
+// MemoryPublisher guarda los mensajes publicados y se los entrega a los
+// suscriptores en el mismo proceso, en lugar de pasar por RabbitMQ
+type MemoryPublisher struct {
+	mu          sync.Mutex
+	messages    []PublishedMessage
+	subscribers []func(PublishedMessage)
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(exchange, routingKey string, message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	msg := PublishedMessage{Exchange: exchange, RoutingKey: routingKey, Body: body}
+
+	p.mu.Lock()
+	p.messages = append(p.messages, msg)
+	subscribers := append([]func(PublishedMessage){}, p.subscribers...)
+	p.mu.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber(msg)
+	}
+
+	return nil
+}
+
+func (p *MemoryPublisher) Subscribe(fn func(PublishedMessage)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.subscribers = append(p.subscribers, fn)
+}
+
+func (p *MemoryPublisher) Messages() []PublishedMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]PublishedMessage{}, p.messages...)
+}
@@ -0,0 +1,257 @@
+package hotelservice
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrHotelNotFound     = errors.New("hotel not found")
+	ErrImportJobNotFound = errors.New("import job not found")
+)
+
+// Transactor corre fn en una transacción. Los repositorios tienen que usar el
+// ctx que recibe fn para quedar dentro de ella.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type mongoTransactor struct {
+	client *mongo.Client
+}
+
+// NewMongoTransactor necesita que MongoDB corra como replica set
+func NewMongoTransactor(client *mongo.Client) Transactor {
+	return &mongoTransactor{client: client}
+}
+
+func (t *mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
+
+type HotelRepository interface {
+	FindAll(ctx context.Context) ([]Hotel, error)
+	// FindPage devuelve hasta limit hoteles con ID mayor que after, ordenados
+	// por ID; con after en cero arranca desde el primero
+	FindPage(ctx context.Context, after primitive.ObjectID, limit int) ([]Hotel, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (Hotel, error)
+	FindByAmadeusID(ctx context.Context, amadeusID string) (Hotel, error)
+	Create(ctx context.Context, hotel Hotel) error
+	Update(ctx context.Context, id primitive.ObjectID, hotel Hotel) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type mongoHotelRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoHotelRepository(collection *mongo.Collection) HotelRepository {
+	return &mongoHotelRepository{collection: collection}
+}
+
+func (r *mongoHotelRepository) FindAll(ctx context.Context) ([]Hotel, error) {
+	var hotels []Hotel
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &hotels); err != nil {
+		return nil, err
+	}
+
+	return hotels, nil
+}
+
+func (r *mongoHotelRepository) FindPage(ctx context.Context, after primitive.ObjectID, limit int) ([]Hotel, error) {
+	filter := bson.M{}
+	if !after.IsZero() {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	hotels := []Hotel{}
+	if err := cursor.All(ctx, &hotels); err != nil {
+		return nil, err
+	}
+	return hotels, nil
+}
+
+func (r *mongoHotelRepository) FindByID(ctx context.Context, id primitive.ObjectID) (Hotel, error) {
+	var hotel Hotel
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&hotel)
+	if err == mongo.ErrNoDocuments {
+		return hotel, ErrHotelNotFound
+	}
+	return hotel, err
+}
+
+func (r *mongoHotelRepository) FindByAmadeusID(ctx context.Context, amadeusID string) (Hotel, error) {
+	var hotel Hotel
+	err := r.collection.FindOne(ctx, bson.M{"amadeus_id": amadeusID}).Decode(&hotel)
+	if err == mongo.ErrNoDocuments {
+		return hotel, ErrHotelNotFound
+	}
+	return hotel, err
+}
+
+func (r *mongoHotelRepository) Create(ctx context.Context, hotel Hotel) error {
+	_, err := r.collection.InsertOne(ctx, hotel)
+	return err
+}
+
+func (r *mongoHotelRepository) Update(ctx context.Context, id primitive.ObjectID, hotel Hotel) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": hotel},
+	)
+	return err
+}
+
+func (r *mongoHotelRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+type ImportJobRepository interface {
+	FindByID(ctx context.Context, id primitive.ObjectID) (ImportJob, error)
+	Create(ctx context.Context, job ImportJob) error
+	Update(ctx context.Context, job ImportJob) error
+}
+
+type mongoImportJobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoImportJobRepository(collection *mongo.Collection) ImportJobRepository {
+	return &mongoImportJobRepository{collection: collection}
+}
+
+func (r *mongoImportJobRepository) FindByID(ctx context.Context, id primitive.ObjectID) (ImportJob, error) {
+	var job ImportJob
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return job, ErrImportJobNotFound
+	}
+	return job, err
+}
+
+func (r *mongoImportJobRepository) Create(ctx context.Context, job ImportJob) error {
+	_, err := r.collection.InsertOne(ctx, job)
+	return err
+}
+
+func (r *mongoImportJobRepository) Update(ctx context.Context, job ImportJob) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": job.ID}, job)
+	return err
+}
+
+type OutboxRepository interface {
+	Create(ctx context.Context, event OutboxEvent) error
+	// ListPending devuelve los eventos sin publicar, del más viejo al más nuevo
+	ListPending(ctx context.Context, limit int) ([]OutboxEvent, error)
+	MarkSent(ctx context.Context, id primitive.ObjectID, sentAt time.Time) error
+	MarkFailed(ctx context.Context, id primitive.ObjectID, attempts int, lastError string, nextAttemptAt time.Time) error
+	// DeleteSentBefore borra los eventos publicados antes de before
+	DeleteSentBefore(ctx context.Context, before time.Time) (int64, error)
+	// ClaimRelay le da a owner el turno de relay hasta until si está libre,
+	// vencido antes de now o ya era suyo. Devuelve false si lo tiene otro.
+	ClaimRelay(ctx context.Context, owner string, now, until time.Time) (bool, error)
+}
+
+// outboxRelayLeaseID es el documento del outbox que guarda quién tiene el turno de relay
+const outboxRelayLeaseID = "relay_lease"
+
+type mongoOutboxRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoOutboxRepository(collection *mongo.Collection) OutboxRepository {
+	return &mongoOutboxRepository{collection: collection}
+}
+
+func (r *mongoOutboxRepository) Create(ctx context.Context, event OutboxEvent) error {
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+func (r *mongoOutboxRepository) ListPending(ctx context.Context, limit int) ([]OutboxEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, bson.M{"status": OutboxPending}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []OutboxEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *mongoOutboxRepository) MarkSent(ctx context.Context, id primitive.ObjectID, sentAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"status": OutboxSent, "sent_at": sentAt},
+		"$unset": bson.M{"last_error": ""},
+	})
+	return err
+}
+
+func (r *mongoOutboxRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, attempts int, lastError string, nextAttemptAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"attempts":        attempts,
+		"last_error":      lastError,
+		"next_attempt_at": nextAttemptAt,
+	}})
+	return err
+}
+
+func (r *mongoOutboxRepository) ClaimRelay(ctx context.Context, owner string, now, until time.Time) (bool, error) {
+	filter := bson.M{
+		"_id": outboxRelayLeaseID,
+		"$or": bson.A{bson.M{"claimed_by": owner}, bson.M{"claimed_until": bson.M{"$lt": now}}},
+	}
+	update := bson.M{"$set": bson.M{"claimed_by": owner, "claimed_until": until}}
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetUpsert(true)).Err()
+	if mongo.IsDuplicateKeyError(err) {
+		// El turno vigente es de otro: el filtro no lo encontró y el upsert chocó con su documento
+		return false, nil
+	}
+	if err == mongo.ErrNoDocuments {
+		// Sin ReturnDocument el upsert que crea el documento no devuelve nada
+		return true, nil
+	}
+	return err == nil, err
+}
+
+func (r *mongoOutboxRepository) DeleteSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"status": OutboxSent, "sent_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
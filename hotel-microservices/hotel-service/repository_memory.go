@@ -0,0 +1,240 @@
+package hotelservice
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryHotelRepository guarda los hoteles en memoria, para tests y
+// para correr el servicio sin MongoDB
+type memoryHotelRepository struct {
+	mu     sync.RWMutex
+	hotels map[primitive.ObjectID]Hotel
+}
+
+func NewMemoryHotelRepository() HotelRepository {
+	return &memoryHotelRepository{hotels: map[primitive.ObjectID]Hotel{}}
+}
+
+func (r *memoryHotelRepository) FindAll(ctx context.Context) ([]Hotel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hotels := make([]Hotel, 0, len(r.hotels))
+	for _, hotel := range r.hotels {
+		hotels = append(hotels, hotel)
+	}
+
+	// ObjectIDs start with a timestamp, so this keeps insertion order like Mongo
+	sort.Slice(hotels, func(i, j int) bool {
+		return hotels[i].ID.Hex() < hotels[j].ID.Hex()
+	})
+
+	return hotels, nil
+}
+
+func (r *memoryHotelRepository) FindPage(ctx context.Context, after primitive.ObjectID, limit int) ([]Hotel, error) {
+	hotels, _ := r.FindAll(ctx)
+
+	page := []Hotel{}
+	for _, hotel := range hotels {
+		if hotel.ID.Hex() > after.Hex() && len(page) < limit {
+			page = append(page, hotel)
+		}
+	}
+	return page, nil
+}
+
+func (r *memoryHotelRepository) FindByID(ctx context.Context, id primitive.ObjectID) (Hotel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hotel, ok := r.hotels[id]
+	if !ok {
+		return Hotel{}, ErrHotelNotFound
+	}
+	return hotel, nil
+}
+
+func (r *memoryHotelRepository) FindByAmadeusID(ctx context.Context, amadeusID string) (Hotel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, hotel := range r.hotels {
+		if hotel.AmadeusID == amadeusID {
+			return hotel, nil
+		}
+	}
+	return Hotel{}, ErrHotelNotFound
+}
+
+func (r *memoryHotelRepository) Create(ctx context.Context, hotel Hotel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hotels[hotel.ID] = hotel
+	return nil
+}
+
+// Update replica el $set de Mongo: reemplaza los campos pero conserva el ID
+func (r *memoryHotelRepository) Update(ctx context.Context, id primitive.ObjectID, hotel Hotel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.hotels[id]; !ok {
+		return nil
+	}
+
+	hotel.ID = id
+	r.hotels[id] = hotel
+	return nil
+}
+
+func (r *memoryHotelRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.hotels, id)
+	return nil
+}
+
+type memoryImportJobRepository struct {
+	mu   sync.RWMutex
+	jobs map[primitive.ObjectID]ImportJob
+}
+
+func NewMemoryImportJobRepository() ImportJobRepository {
+	return &memoryImportJobRepository{jobs: map[primitive.ObjectID]ImportJob{}}
+}
+
+func (r *memoryImportJobRepository) FindByID(ctx context.Context, id primitive.ObjectID) (ImportJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return ImportJob{}, ErrImportJobNotFound
+	}
+	return job, nil
+}
+
+func (r *memoryImportJobRepository) Create(ctx context.Context, job ImportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[job.ID] = job
+	return nil
+}
+
+func (r *memoryImportJobRepository) Update(ctx context.Context, job ImportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[job.ID] = job
+	return nil
+}
+
+// memoryTransactor corre fn sin transacción: si falla a mitad de camino no
+// deshace lo escrito. Alcanza para tests y para correr sin MongoDB.
+type memoryTransactor struct{}
+
+func NewMemoryTransactor() Transactor {
+	return memoryTransactor{}
+}
+
+func (memoryTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type memoryOutboxRepository struct {
+	mu     sync.RWMutex
+	events []OutboxEvent
+	// relayOwner tiene el turno de relay hasta relayUntil
+	relayOwner string
+	relayUntil time.Time
+}
+
+func NewMemoryOutboxRepository() OutboxRepository {
+	return &memoryOutboxRepository{}
+}
+
+func (r *memoryOutboxRepository) Create(ctx context.Context, event OutboxEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *memoryOutboxRepository) ListPending(ctx context.Context, limit int) ([]OutboxEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []OutboxEvent
+	for _, event := range r.events {
+		if event.Status == OutboxPending && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *memoryOutboxRepository) MarkSent(ctx context.Context, id primitive.ObjectID, sentAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.events {
+		if r.events[i].ID == id {
+			r.events[i].Status = OutboxSent
+			r.events[i].SentAt = &sentAt
+			r.events[i].LastError = ""
+		}
+	}
+	return nil
+}
+
+func (r *memoryOutboxRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, attempts int, lastError string, nextAttemptAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.events {
+		if r.events[i].ID == id {
+			r.events[i].Attempts = attempts
+			r.events[i].LastError = lastError
+			r.events[i].NextAttemptAt = nextAttemptAt
+		}
+	}
+	return nil
+}
+
+func (r *memoryOutboxRepository) ClaimRelay(ctx context.Context, owner string, now, until time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.relayOwner != "" && r.relayOwner != owner && !r.relayUntil.Before(now) {
+		return false, nil
+	}
+	r.relayOwner = owner
+	r.relayUntil = until
+	return true, nil
+}
+
+func (r *memoryOutboxRepository) DeleteSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.events[:0]
+	for _, event := range r.events {
+		if event.Status == OutboxSent && event.SentAt != nil && event.SentAt.Before(before) {
+			continue
+		}
+		kept = append(kept, event)
+	}
+	deleted := int64(len(r.events) - len(kept))
+	r.events = kept
+	return deleted, nil
+}
@@ -0,0 +1,309 @@
+package hotelservice
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"events"
+	"events/readiness"
+)
+
+type Hotel struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name          string             `bson:"name" json:"name"`
+	Description   string             `bson:"description" json:"description"`
+	City          string             `bson:"city" json:"city"`
+	Address       string             `bson:"address" json:"address"`
+	Amenities     []string           `bson:"amenities" json:"amenities"`
+	Images        []string           `bson:"images" json:"images"`
+	Thumbnail     string             `bson:"thumbnail" json:"thumbnail"`
+	TotalRooms    int                `bson:"total_rooms" json:"totalRooms"`
+	PricePerNight float64            `bson:"price_per_night" json:"pricePerNight"`
+	Latitude      float64            `bson:"latitude" json:"latitude"`
+	Longitude     float64            `bson:"longitude" json:"longitude"`
+	AmadeusID     string             `bson:"amadeus_id" json:"amadeus_id"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// HotelEventsExchange es un exchange topic; la routing key es el tipo del
+// evento (hotel.created, hotel.updated, hotel.deleted). Cada grupo de
+// consumidores bindea su propia cola durable, y las instancias que necesitan
+// ver todos los eventos, una cola exclusiva.
+//
+// Reemplaza al fanout hotel_events, porque un exchange no puede cambiar de
+// tipo. Los eventos que quedaron en el outbox apuntando al fanout siguen
+// llegando: las colas de los consumidores conservan su binding a él.
+const HotelEventsExchange = "hotel_events.topic"
+
+// EventProducer identifica a hotel-service en los sobres que publica
+const EventProducer = "hotel-service"
+
+// CorrelationHeader trae el correlation ID del pedido; si falta se genera uno
+const CorrelationHeader = "X-Correlation-ID"
+
+type HotelService struct {
+	hotels     HotelRepository
+	importJobs ImportJobRepository
+	outbox     OutboxRepository
+	transactor Transactor
+	publisher  EventPublisher
+	amadeus    *AmadeusClient
+	// relayMu evita que dos RelayOutbox del mismo proceso publiquen el mismo
+	// evento; entre réplicas lo evita el turno de relay, que se toma como relayOwner
+	relayMu    sync.Mutex
+	relayOwner string
+	// relayWake despierta a StartOutboxRelay después de cada escritura
+	relayWake chan struct{}
+	readiness readiness.Checks
+}
+
+type Repositories struct {
+	Hotels     HotelRepository
+	ImportJobs ImportJobRepository
+	Outbox     OutboxRepository
+	// Transactor agrupa la escritura del hotel y la de su evento en el outbox
+	Transactor Transactor
+}
+
+// NewMemoryRepositories arma los repositorios en memoria, para tests y para
+// correr el servicio sin MongoDB
+func NewMemoryRepositories() Repositories {
+	return Repositories{
+		Hotels:     NewMemoryHotelRepository(),
+		ImportJobs: NewMemoryImportJobRepository(),
+		Outbox:     NewMemoryOutboxRepository(),
+		Transactor: NewMemoryTransactor(),
+	}
+}
+
+func NewHotelService(repos Repositories, publisher EventPublisher, amadeus *AmadeusClient) *HotelService {
+	return &HotelService{
+		hotels:     repos.Hotels,
+		importJobs: repos.ImportJobs,
+		outbox:     repos.Outbox,
+		transactor: repos.Transactor,
+		publisher:  publisher,
+		amadeus:    amadeus,
+		relayWake:  make(chan struct{}, 1),
+		relayOwner: events.NewID(),
+	}
+}
+
+// AddReadinessCheck suma una dependencia sin la que el servicio no está listo
+// para recibir tráfico; GET /ready la consulta en cada pedido
+func (s *HotelService) AddReadinessCheck(name string, check func() error) {
+	s.readiness.Add(name, check)
+}
+
+func NewRouter(service *HotelService) *gin.Engine {
+	router := gin.Default()
+
+	// CORS middleware
+	router.Use(func(c *gin.Context) {
+		c.Header("Access-Control-Allow-Origin", "*")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Correlation-ID")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	})
+
+	// Los eventos que produce el pedido llevan su correlation ID
+	router.Use(func(c *gin.Context) {
+		correlationID := c.GetHeader(CorrelationHeader)
+		if correlationID == "" {
+			correlationID = events.NewID()
+		}
+		c.Header(CorrelationHeader, correlationID)
+		c.Request = c.Request.WithContext(events.WithCorrelationID(c.Request.Context(), correlationID))
+		c.Next()
+	})
+
+	router.GET("/ready", gin.WrapH(&service.readiness))
+
+	// Hotel routes (accessible via /api/hotels from nginx)
+	router.GET("/hotels", service.getHotels)
+	router.POST("/hotels/import", service.startImport)
+	router.GET("/hotels/import/:id", service.getImportJob)
+	router.GET("/hotels/:id", service.getHotel)
+	router.POST("/hotels", service.createHotel)
+	router.PUT("/hotels/:id", service.updateHotel)
+	router.DELETE("/hotels/:id", service.deleteHotel)
+
+	return router
+}
+
+const (
+	defaultHotelPageSize = 100
+	maxHotelPageSize     = 1000
+)
+
+// getHotels devuelve todos los hoteles, o una página si viene after o limit:
+// la siguiente se pide con after igual al último ID recibido
+func (s *HotelService) getHotels(c *gin.Context) {
+	if c.Query("after") != "" || c.Query("limit") != "" {
+		s.getHotelPage(c)
+		return
+	}
+
+	hotels, err := s.hotels.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hotels)
+}
+
+func (s *HotelService) getHotelPage(c *gin.Context) {
+	var after primitive.ObjectID
+	if raw := c.Query("after"); raw != "" {
+		objectID, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid after ID"})
+			return
+		}
+		after = objectID
+	}
+
+	limit := defaultHotelPageSize
+	if raw := c.Query("limit"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 || value > maxHotelPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxHotelPageSize)})
+			return
+		}
+		limit = value
+	}
+
+	hotels, err := s.hotels.FindPage(c.Request.Context(), after, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hotels)
+}
+
+func (s *HotelService) getHotel(c *gin.Context) {
+	id := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	hotel, err := s.hotels.FindByID(c.Request.Context(), objectID)
+	if err != nil {
+		if err == ErrHotelNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Hotel not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hotel)
+}
+
+func (s *HotelService) createHotel(c *gin.Context) {
+	var hotel Hotel
+	if err := c.ShouldBindJSON(&hotel); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hotel.ID = primitive.NewObjectID()
+	hotel.CreatedAt = time.Now()
+	hotel.UpdatedAt = time.Now()
+	applyHotelDefaults(&hotel)
+
+	err := s.writeWithEvent(c.Request.Context(), events.HotelCreated, hotel, func(ctx context.Context) error {
+		return s.hotels.Create(ctx, hotel)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, hotel)
+}
+
+// applyHotelDefaults completa imágenes, miniatura y amenities si no vinieron
+func applyHotelDefaults(hotel *Hotel) {
+	// Set default images if not provided
+	if len(hotel.Images) == 0 {
+		hotel.Images = []string{
+			"https://images.unsplash.com/photo-1566073771259-6a8506099945?w=800",
+			"https://images.unsplash.com/photo-1582719478250-c89cae4dc85b?w=800",
+			"https://images.unsplash.com/photo-1560347876-aeef00ee58a1?w=800",
+		}
+	}
+
+	if hotel.Thumbnail == "" {
+		hotel.Thumbnail = "https://images.unsplash.com/photo-1566073771259-6a8506099945?w=400"
+	}
+
+	if len(hotel.Amenities) == 0 {
+		hotel.Amenities = []string{"WiFi", "Pool", "Spa", "Gym", "Restaurant"}
+	}
+}
+
+func (s *HotelService) updateHotel(c *gin.Context) {
+	id := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var hotel Hotel
+	if err := c.ShouldBindJSON(&hotel); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hotel.UpdatedAt = time.Now()
+	hotel.ID = objectID
+
+	err = s.writeWithEvent(c.Request.Context(), events.HotelUpdated, hotel, func(ctx context.Context) error {
+		return s.hotels.Update(ctx, objectID, hotel)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hotel)
+}
+
+func (s *HotelService) deleteHotel(c *gin.Context) {
+	id := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	err = s.writeWithEvent(c.Request.Context(), events.HotelDeleted, Hotel{ID: objectID}, func(ctx context.Context) error {
+		return s.hotels.Delete(ctx, objectID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Hotel deleted successfully"})
+}
@@ -0,0 +1,298 @@
+package hotelservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"events"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newTestService() (*HotelService, *MemoryPublisher) {
+	publisher := NewMemoryPublisher()
+	return NewHotelService(NewMemoryRepositories(), publisher, NewAmadeusClient("", "", "")), publisher
+}
+
+func doRequest(router http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// relayOutbox publica lo que dejaron en el outbox los pedidos del test, como
+// haría StartOutboxRelay
+func relayOutbox(t *testing.T, service *HotelService) {
+	t.Helper()
+
+	if _, err := service.RelayOutbox(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func lastHotelEvent(t *testing.T, publisher *MemoryPublisher) (string, map[string]interface{}) {
+	t.Helper()
+
+	messages := publisher.Messages()
+	if len(messages) == 0 {
+		t.Fatal("expected a published hotel update")
+	}
+
+	msg := messages[len(messages)-1]
+
+	envelope, err := events.Decode(msg.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Exchange != HotelEventsExchange || msg.RoutingKey != envelope.Type {
+		t.Fatalf("expected %s on %s, got %q on %q", envelope.Type, HotelEventsExchange, msg.RoutingKey, msg.Exchange)
+	}
+	if envelope.Producer != EventProducer || envelope.CorrelationID == "" {
+		t.Fatalf("unexpected envelope %+v", envelope)
+	}
+	var payload map[string]interface{}
+	if err := envelope.DecodePayload(&payload); err != nil {
+		t.Fatal(err)
+	}
+	return envelope.Type, payload
+}
+
+func TestCreateHotelAppliesDefaultsAndPublishes(t *testing.T) {
+	service, publisher := newTestService()
+	router := NewRouter(service)
+
+	w := doRequest(router, "POST", "/hotels", map[string]interface{}{
+		"name": "Hotel Colón",
+		"city": "Córdoba",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var hotel Hotel
+	if err := json.Unmarshal(w.Body.Bytes(), &hotel); err != nil {
+		t.Fatal(err)
+	}
+	if hotel.ID.IsZero() {
+		t.Error("expected an ID to be assigned")
+	}
+	if len(hotel.Images) == 0 || hotel.Thumbnail == "" || len(hotel.Amenities) == 0 {
+		t.Errorf("expected default images, thumbnail and amenities, got %+v", hotel)
+	}
+
+	relayOutbox(t, service)
+	eventType, payload := lastHotelEvent(t, publisher)
+	if eventType != events.HotelCreated || payload["id"] != hotel.ID.Hex() {
+		t.Errorf("unexpected event %s %v", eventType, payload["id"])
+	}
+}
+
+func TestHotelEventsCarryCorrelationID(t *testing.T) {
+	service, publisher := newTestService()
+	router := NewRouter(service)
+
+	data, _ := json.Marshal(map[string]interface{}{"name": "Hotel Sol", "city": "Córdoba"})
+	req := httptest.NewRequest("POST", "/hotels", bytes.NewReader(data))
+	req.Header.Set(CorrelationHeader, "req-42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated || w.Header().Get(CorrelationHeader) != "req-42" {
+		t.Fatalf("expected 201 echoing the correlation ID, got %d %q", w.Code, w.Header().Get(CorrelationHeader))
+	}
+
+	relayOutbox(t, service)
+	messages := publisher.Messages()
+	envelope, err := events.Decode(messages[len(messages)-1].Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope.CorrelationID != "req-42" || envelope.Version != events.CurrentVersion(events.HotelCreated) {
+		t.Fatalf("unexpected envelope %+v", envelope)
+	}
+}
+
+func TestCreateHotelRejectsInvalidJSON(t *testing.T) {
+	service, publisher := newTestService()
+	router := NewRouter(service)
+
+	req := httptest.NewRequest("POST", "/hotels", bytes.NewBufferString("{not json"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if len(publisher.Messages()) != 0 {
+		t.Error("expected no events for a rejected request")
+	}
+}
+
+func TestGetHotels(t *testing.T) {
+	service, _ := newTestService()
+	router := NewRouter(service)
+
+	doRequest(router, "POST", "/hotels", map[string]interface{}{"name": "A", "city": "Rosario"})
+	doRequest(router, "POST", "/hotels", map[string]interface{}{"name": "B", "city": "Salta"})
+
+	w := doRequest(router, "GET", "/hotels", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var hotels []Hotel
+	if err := json.Unmarshal(w.Body.Bytes(), &hotels); err != nil {
+		t.Fatal(err)
+	}
+	if len(hotels) != 2 {
+		t.Fatalf("expected 2 hotels, got %d", len(hotels))
+	}
+}
+
+func TestGetHotelsPaged(t *testing.T) {
+	service, _ := newTestService()
+	router := NewRouter(service)
+
+	for _, name := range []string{"A", "B", "C"} {
+		doRequest(router, "POST", "/hotels", map[string]interface{}{"name": name, "city": "Rosario"})
+	}
+
+	var names []string
+	after := ""
+	for {
+		w := doRequest(router, "GET", "/hotels?limit=2&after="+after, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		var page []Hotel
+		json.Unmarshal(w.Body.Bytes(), &page)
+		if len(page) == 0 {
+			break
+		}
+		for _, hotel := range page {
+			names = append(names, hotel.Name)
+		}
+		after = page[len(page)-1].ID.Hex()
+	}
+	if len(names) != 3 || names[0] != "A" || names[2] != "C" {
+		t.Fatalf("unexpected pages %v", names)
+	}
+
+	if w := doRequest(router, "GET", "/hotels?limit=5000", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for limit over the maximum, got %d", w.Code)
+	}
+	if w := doRequest(router, "GET", "/hotels?after=bad", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid after, got %d", w.Code)
+	}
+}
+
+func TestGetHotel(t *testing.T) {
+	service, _ := newTestService()
+	router := NewRouter(service)
+
+	created := doRequest(router, "POST", "/hotels", map[string]interface{}{"name": "A", "city": "Rosario"})
+	var hotel Hotel
+	json.Unmarshal(created.Body.Bytes(), &hotel)
+
+	w := doRequest(router, "GET", "/hotels/"+hotel.ID.Hex(), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	if w := doRequest(router, "GET", "/hotels/not-an-id", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid ID, got %d", w.Code)
+	}
+
+	if w := doRequest(router, "GET", "/hotels/"+primitive.NewObjectID().Hex(), nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown hotel, got %d", w.Code)
+	}
+}
+
+func TestUpdateHotel(t *testing.T) {
+	service, publisher := newTestService()
+	router := NewRouter(service)
+
+	created := doRequest(router, "POST", "/hotels", map[string]interface{}{"name": "A", "city": "Rosario"})
+	var hotel Hotel
+	json.Unmarshal(created.Body.Bytes(), &hotel)
+
+	w := doRequest(router, "PUT", "/hotels/"+hotel.ID.Hex(), map[string]interface{}{"name": "A renovado", "city": "Rosario"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	stored, _ := service.hotels.FindByID(context.Background(), hotel.ID)
+	if stored.Name != "A renovado" {
+		t.Errorf("expected stored name to be updated, got %q", stored.Name)
+	}
+
+	relayOutbox(t, service)
+	eventType, payload := lastHotelEvent(t, publisher)
+	if eventType != events.HotelUpdated || payload["id"] != hotel.ID.Hex() {
+		t.Errorf("unexpected event %s %v", eventType, payload["id"])
+	}
+
+	if w := doRequest(router, "PUT", "/hotels/bad", map[string]interface{}{"name": "x"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid ID, got %d", w.Code)
+	}
+}
+
+func TestDeleteHotel(t *testing.T) {
+	service, publisher := newTestService()
+	router := NewRouter(service)
+
+	created := doRequest(router, "POST", "/hotels", map[string]interface{}{"name": "A", "city": "Rosario"})
+	var hotel Hotel
+	json.Unmarshal(created.Body.Bytes(), &hotel)
+
+	w := doRequest(router, "DELETE", "/hotels/"+hotel.ID.Hex(), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	if _, err := service.hotels.FindByID(context.Background(), hotel.ID); err != ErrHotelNotFound {
+		t.Errorf("expected hotel to be deleted, got %v", err)
+	}
+
+	relayOutbox(t, service)
+	eventType, payload := lastHotelEvent(t, publisher)
+	if eventType != events.HotelDeleted || payload["id"] != hotel.ID.Hex() {
+		t.Errorf("unexpected event %s %v", eventType, payload["id"])
+	}
+
+	if w := doRequest(router, "DELETE", "/hotels/bad", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid ID, got %d", w.Code)
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	service, _ := newTestService()
+	router := NewRouter(service)
+
+	w := doRequest(router, "OPTIONS", "/hotels", nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("expected CORS headers on preflight")
+	}
+}
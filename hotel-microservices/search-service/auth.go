@@ -0,0 +1,54 @@
+package searchservice
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// AdminClaims son los claims de los tokens que firma user-service; solo
+// interesa si el usuario es admin
+type AdminClaims struct {
+	UserID  int  `json:"user_id"`
+	IsAdmin bool `json:"is_admin"`
+	jwt.RegisteredClaims
+}
+
+// SetJWTSecret configura el secreto con el que user-service firma los
+// tokens. Sin secreto los endpoints /admin rechazan todos los pedidos.
+func (s *SearchService) SetJWTSecret(secret string) {
+	s.jwtSecret = secret
+}
+
+// adminMiddleware deja pasar solo pedidos con un token de admin de user-service
+func (s *SearchService) adminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.jwtSecret == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Admin API is disabled"})
+			return
+		}
+
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "No token provided"})
+			return
+		}
+
+		claims := &AdminClaims{}
+		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			return []byte(s.jwtSecret), nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+		if err != nil || !token.Valid {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+		if !claims.IsAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			return
+		}
+
+		c.Next()
+	}
+}
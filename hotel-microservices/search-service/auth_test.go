@@ -0,0 +1,43 @@
+package searchservice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminEndpointsRequireAdminToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		token  string
+		status int
+	}{
+		{"admin token", testJWTSecret, signToken(t, testJWTSecret, true), http.StatusOK},
+		{"no token", testJWTSecret, "", http.StatusUnauthorized},
+		{"token signed with another secret", testJWTSecret, signToken(t, "other-secret", true), http.StatusUnauthorized},
+		{"malformed token", testJWTSecret, "not-a-token", http.StatusUnauthorized},
+		{"guest token", testJWTSecret, signToken(t, testJWTSecret, false), http.StatusForbidden},
+		{"no secret configured", "", signToken(t, "", true), http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestService(stubAvailability{})
+			service.SetJWTSecret(tt.secret)
+			router := NewRouter(service)
+
+			for _, path := range []string{"/admin/dead-letters", "/admin/reindex", "/admin/reconcile"} {
+				req := httptest.NewRequest("GET", path, nil)
+				if tt.token != "" {
+					req.Header.Set("Authorization", "Bearer "+tt.token)
+				}
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != tt.status {
+					t.Fatalf("GET %s: expected %d, got %d: %s", path, tt.status, w.Code, w.Body.String())
+				}
+			}
+		})
+	}
+}
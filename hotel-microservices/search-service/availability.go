@@ -0,0 +1,41 @@
+package searchservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+type AvailabilityChecker interface {
+	CheckAvailability(hotelID, checkIn, checkOut string) bool
+}
+
+type httpAvailabilityChecker struct {
+	userServiceURL string
+}
+
+func NewHTTPAvailabilityChecker(userServiceURL string) AvailabilityChecker {
+	return &httpAvailabilityChecker{userServiceURL: userServiceURL}
+}
+
+func (a *httpAvailabilityChecker) CheckAvailability(hotelID, checkIn, checkOut string) bool {
+	// Call user service to check availability
+	url := fmt.Sprintf("%s/availability?hotel_id=%s&check_in=%s&check_out=%s",
+		a.userServiceURL, hotelID, checkIn, checkOut)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		log.Printf("Error checking availability: %v", err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false
+	}
+
+	available, ok := result["available"].(bool)
+	return ok && available
+}
@@ -0,0 +1,102 @@
+package searchservice
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"events"
+)
+
+// DefaultAvailabilityCacheTTL acota lo que puede quedar desactualizado un
+// resultado por cambios que no llegan como eventos, como las retenciones
+const DefaultAvailabilityCacheTTL = 30 * time.Second
+
+type availabilityEntry struct {
+	available bool
+	expiresAt time.Time
+}
+
+// CachedAvailabilityChecker guarda en memoria de la instancia las consultas a
+// user-service. Cada instancia tiene su caché, así que las invalidaciones le
+// tienen que llegar a todas: ver ConsumeBroadcast.
+type CachedAvailabilityChecker struct {
+	checker AvailabilityChecker
+	ttl     time.Duration
+
+	mu sync.Mutex
+	// entries por hotel y después por fechas, para invalidar un hotel entero
+	entries map[string]map[string]availabilityEntry
+}
+
+func NewCachedAvailabilityChecker(checker AvailabilityChecker, ttl time.Duration) *CachedAvailabilityChecker {
+	return &CachedAvailabilityChecker{
+		checker: checker,
+		ttl:     ttl,
+		entries: map[string]map[string]availabilityEntry{},
+	}
+}
+
+func (c *CachedAvailabilityChecker) CheckAvailability(hotelID, checkIn, checkOut string) bool {
+	dates := checkIn + "/" + checkOut
+
+	c.mu.Lock()
+	entry, ok := c.entries[hotelID][dates]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.available
+	}
+
+	available := c.checker.CheckAvailability(hotelID, checkIn, checkOut)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries[hotelID] == nil {
+		c.entries[hotelID] = map[string]availabilityEntry{}
+	}
+	c.entries[hotelID][dates] = availabilityEntry{available: available, expiresAt: time.Now().Add(c.ttl)}
+	return available
+}
+
+// Invalidate descarta todo lo guardado de hotelID
+func (c *CachedAvailabilityChecker) Invalidate(hotelID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, hotelID)
+}
+
+// HandleEvent invalida el hotel afectado por un evento hotel.* o reservation.*
+func (c *CachedAvailabilityChecker) HandleEvent(body []byte) error {
+	envelope, err := events.Decode(body)
+	if err != nil {
+		return err
+	}
+
+	var hotelID string
+	switch envelope.Type {
+	case events.HotelCreated, events.HotelUpdated:
+		var hotel events.HotelPayload
+		if err := envelope.DecodePayload(&hotel); err != nil {
+			return err
+		}
+		hotelID = hotel.ID
+	case events.HotelDeleted:
+		var hotel events.HotelDeletedPayload
+		if err := envelope.DecodePayload(&hotel); err != nil {
+			return err
+		}
+		hotelID = hotel.ID
+	case events.ReservationCreated, events.ReservationModified, events.ReservationCancelled:
+		var reservation events.ReservationPayload
+		if err := envelope.DecodePayload(&reservation); err != nil {
+			return err
+		}
+		hotelID = reservation.HotelID
+	default:
+		return fmt.Errorf("unexpected event type %q", envelope.Type)
+	}
+
+	c.Invalidate(hotelID)
+	return nil
+}
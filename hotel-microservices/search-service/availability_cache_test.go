@@ -0,0 +1,76 @@
+package searchservice
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"events"
+)
+
+// countingAvailability cuenta las consultas que llegan a user-service
+type countingAvailability struct {
+	available map[string]bool
+	calls     int
+}
+
+func (c *countingAvailability) CheckAvailability(hotelID, checkIn, checkOut string) bool {
+	c.calls++
+	return c.available[hotelID]
+}
+
+func publishedEvent(t *testing.T, eventType string, payload interface{}) []byte {
+	t.Helper()
+
+	envelope, err := events.New(context.Background(), eventType, "test", payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(envelope)
+	return body
+}
+
+func TestCachedAvailabilityChecker(t *testing.T) {
+	upstream := &countingAvailability{available: map[string]bool{"1": true}}
+	cache := NewCachedAvailabilityChecker(upstream, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if !cache.CheckAvailability("1", "2026-01-10", "2026-01-12") {
+			t.Fatal("expected hotel 1 to be available")
+		}
+	}
+	cache.CheckAvailability("1", "2026-02-10", "2026-02-12")
+	if upstream.calls != 2 {
+		t.Fatalf("expected one call per date range, got %d", upstream.calls)
+	}
+
+	// Una reserva en el hotel invalida todas sus fechas
+	upstream.available["1"] = false
+	if err := cache.HandleEvent(publishedEvent(t, events.ReservationCreated, events.ReservationPayload{ReservationID: 7, HotelID: "1"})); err != nil {
+		t.Fatal(err)
+	}
+	if cache.CheckAvailability("1", "2026-01-10", "2026-01-12") || upstream.calls != 3 {
+		t.Fatalf("expected a fresh check after the reservation, got %d calls", upstream.calls)
+	}
+
+	if err := cache.HandleEvent(publishedEvent(t, events.HotelDeleted, events.HotelDeletedPayload{ID: "1"})); err != nil {
+		t.Fatal(err)
+	}
+	cache.CheckAvailability("1", "2026-01-10", "2026-01-12")
+	if upstream.calls != 4 {
+		t.Fatalf("expected a fresh check after the hotel changed, got %d calls", upstream.calls)
+	}
+}
+
+func TestCachedAvailabilityCheckerExpires(t *testing.T) {
+	upstream := &countingAvailability{available: map[string]bool{}}
+	cache := NewCachedAvailabilityChecker(upstream, time.Nanosecond)
+
+	cache.CheckAvailability("1", "2026-01-10", "2026-01-12")
+	time.Sleep(time.Millisecond)
+	cache.CheckAvailability("1", "2026-01-10", "2026-01-12")
+	if upstream.calls != 2 {
+		t.Fatalf("expected expired entries to be checked again, got %d calls", upstream.calls)
+	}
+}
@@ -0,0 +1,114 @@
+package searchservice
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBatchSize es cuántos hoteles distintos junta un batch antes de mandarlo
+	DefaultBatchSize = 100
+	// DefaultBatchWindow es cuánto espera un batch a que lleguen más cambios
+	DefaultBatchWindow = 200 * time.Millisecond
+	// DefaultCommitWithin es el plazo que se le da a Solr para hacer visibles
+	// los cambios con un soft commit, en lugar de un commit por request
+	DefaultCommitWithin = time.Second
+
+	batchFlushTimeout = 30 * time.Second
+)
+
+// updateBatch junta los cambios pendientes por hotel; un documento nil es un borrado
+type updateBatch struct {
+	changes map[string]*HotelDocument
+	timer   *time.Timer
+	// done se cierra cuando el batch se aplicó, con el resultado en err
+	done chan struct{}
+	err  error
+}
+
+// updateBatcher agrupa los Index y Delete que llegan dentro de una ventana de
+// tiempo o hasta completar un tamaño y los manda a Solr en un solo update.
+// Quien llama espera a que se aplique su batch, así el ack de RabbitMQ sigue
+// siendo posterior al indexado.
+type updateBatcher struct {
+	apply  func(ctx context.Context, docs []HotelDocument, deletes []string) error
+	size   int
+	window time.Duration
+
+	mu      sync.Mutex
+	current *updateBatch
+	// flushMu aplica los batches de a uno y en el orden en que se abrieron
+	flushMu sync.Mutex
+}
+
+func newUpdateBatcher(size int, window time.Duration, apply func(ctx context.Context, docs []HotelDocument, deletes []string) error) *updateBatcher {
+	return &updateBatcher{apply: apply, size: size, window: window}
+}
+
+// submit agrega el cambio de hotelID al batch abierto y espera a que se
+// aplique. Los cambios del mismo hotel en un batch se combinan: un borrado
+// gana siempre, y entre dos versiones queda la de updated_at más nuevo o, si
+// empatan, la última que llegó.
+func (b *updateBatcher) submit(ctx context.Context, hotelID string, doc *HotelDocument) error {
+	b.mu.Lock()
+	batch := b.current
+	if batch == nil {
+		batch = &updateBatch{changes: map[string]*HotelDocument{}, done: make(chan struct{})}
+		batch.timer = time.AfterFunc(b.window, func() { b.flush(batch) })
+		b.current = batch
+	}
+
+	previous, seen := batch.changes[hotelID]
+	switch {
+	case !seen, doc == nil:
+		batch.changes[hotelID] = doc
+	case previous != nil && !doc.UpdatedAt.Before(previous.UpdatedAt):
+		batch.changes[hotelID] = doc
+	}
+
+	full := len(batch.changes) >= b.size
+	b.mu.Unlock()
+
+	if full {
+		batch.timer.Stop()
+		go b.flush(batch)
+	}
+
+	select {
+	case <-batch.done:
+		return batch.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flush aplica batch si sigue abierto; si ya lo cerró el timer o el tamaño no
+// hace nada. Mientras se aplica un batch el siguiente sigue juntando cambios.
+func (b *updateBatcher) flush(batch *updateBatch) {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	if b.current != batch {
+		b.mu.Unlock()
+		return
+	}
+	b.current = nil
+	b.mu.Unlock()
+
+	var docs []HotelDocument
+	var deletes []string
+	for id, doc := range batch.changes {
+		if doc == nil {
+			deletes = append(deletes, id)
+		} else {
+			docs = append(docs, *doc)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), batchFlushTimeout)
+	defer cancel()
+	batch.err = b.apply(ctx, docs, deletes)
+	close(batch.done)
+}
@@ -0,0 +1,115 @@
+package searchservice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordedBatches guarda lo que recibe apply en cada batch
+type recordedBatches struct {
+	mu      sync.Mutex
+	docs    [][]HotelDocument
+	deletes [][]string
+	err     error
+}
+
+func (r *recordedBatches) apply(ctx context.Context, docs []HotelDocument, deletes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.docs = append(r.docs, docs)
+	r.deletes = append(r.deletes, deletes)
+	return r.err
+}
+
+// submitAll manda los cambios a la vez y espera a que todos vuelvan
+func submitAll(batcher *updateBatcher, changes []HotelDocument, deletes []string) []error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(changes)+len(deletes))
+	for i, doc := range changes {
+		doc := doc
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = batcher.submit(context.Background(), doc.ID, &doc)
+		}(i)
+	}
+	for i, id := range deletes {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			errs[i] = batcher.submit(context.Background(), id, nil)
+		}(len(changes)+i, id)
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestUpdateBatcherCoalescesChangesToTheSameHotel(t *testing.T) {
+	recorded := &recordedBatches{}
+	batcher := newUpdateBatcher(100, 20*time.Millisecond, recorded.apply)
+
+	updated := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	errs := submitAll(batcher, []HotelDocument{
+		{ID: "1", Name: "v1", UpdatedAt: updated},
+		{ID: "1", Name: "v2", UpdatedAt: updated.Add(time.Minute)},
+		// Llegue cuando llegue, un reintento atrasado no pisa la versión más nueva
+		{ID: "1", Name: "v0", UpdatedAt: updated.Add(-time.Minute)},
+		{ID: "2", Name: "borrado", UpdatedAt: updated},
+	}, []string{"2"})
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(recorded.docs) != 1 {
+		t.Fatalf("expected a single batch, got %d", len(recorded.docs))
+	}
+	docs, deletes := recorded.docs[0], recorded.deletes[0]
+	if len(docs) != 1 || docs[0].Name != "v2" {
+		t.Fatalf("expected only v2 of hotel 1, got %+v", docs)
+	}
+	if len(deletes) != 1 || deletes[0] != "2" {
+		t.Fatalf("expected hotel 2 to be deleted, got %v", deletes)
+	}
+}
+
+func TestUpdateBatcherFlushesFullBatches(t *testing.T) {
+	recorded := &recordedBatches{err: errors.New("solr down")}
+	batcher := newUpdateBatcher(2, time.Hour, recorded.apply)
+
+	errs := submitAll(batcher, []HotelDocument{{ID: "1"}, {ID: "2"}}, nil)
+	for _, err := range errs {
+		if err == nil || err.Error() != "solr down" {
+			t.Fatalf("expected the batch error for every change, got %v", err)
+		}
+	}
+
+	ids := []string{recorded.docs[0][0].ID, recorded.docs[0][1].ID}
+	sort.Strings(ids)
+	if len(recorded.docs) != 1 || ids[0] != "1" || ids[1] != "2" {
+		t.Fatalf("unexpected batches %+v", recorded.docs)
+	}
+}
+
+func TestSolrIndexUsesCommitWithin(t *testing.T) {
+	var queries []string
+	solr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+	}))
+	defer solr.Close()
+
+	if err := NewSolrIndex(solr.URL).Index(context.Background(), HotelDocument{ID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 1 || queries[0] != "commitWithin=1000" {
+		t.Fatalf("expected commitWithin instead of commit=true, got %v", queries)
+	}
+}
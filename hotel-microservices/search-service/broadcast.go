@@ -0,0 +1,88 @@
+package searchservice
+
+import (
+	"context"
+	"log"
+
+	"github.com/streadway/amqp"
+
+	"events/amqpx"
+)
+
+// reservationEventsExchange es el exchange topic donde user-service publica
+// los cambios de reservas
+const reservationEventsExchange = "reservation_events"
+
+// Binding es una routing key de un exchange topic
+type Binding struct {
+	Exchange   string
+	RoutingKey string
+}
+
+// AvailabilityInvalidations son los eventos que cambian la disponibilidad de un hotel
+var AvailabilityInvalidations = []Binding{
+	{Exchange: hotelEventsExchange, RoutingKey: hotelEventsBindingKey},
+	{Exchange: reservationEventsExchange, RoutingKey: "reservation.*"},
+}
+
+// ConsumeBroadcast entrega a handle todos los eventos de bindings que
+// lleguen a esta instancia, a diferencia de hotel_updates donde cada evento va
+// a una sola. Usa una cola exclusiva con nombre generado por el broker, que
+// desaparece con la conexión; los eventos publicados mientras no hay conexión
+// se pierden, así que sirve para estado que se puede reconstruir, como un caché.
+func ConsumeBroadcast(ctx context.Context, rabbit *amqpx.RabbitMQ, bindings []Binding, handle func(body []byte) error) {
+	for {
+		channel, err := rabbit.WaitChannel(ctx)
+		if err != nil {
+			return
+		}
+
+		if err := consumeBroadcast(channel, bindings, handle); err != nil {
+			log.Printf("Error consuming broadcast events: %v", err)
+		}
+		channel.Close()
+	}
+}
+
+func consumeBroadcast(channel *amqp.Channel, bindings []Binding, handle func(body []byte) error) error {
+	q, err := channel.QueueDeclare(
+		"",
+		false,
+		true,
+		true,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, binding := range bindings {
+		if err := channel.ExchangeDeclare(binding.Exchange, "topic", true, false, false, false, nil); err != nil {
+			return err
+		}
+		if err := channel.QueueBind(q.Name, binding.RoutingKey, binding.Exchange, false, nil); err != nil {
+			return err
+		}
+	}
+
+	msgs, err := channel.Consume(
+		q.Name,
+		"",
+		true,
+		true,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	for msg := range msgs {
+		if err := handle(msg.Body); err != nil {
+			log.Printf("Error handling broadcast event %s: %v", msg.RoutingKey, err)
+		}
+	}
+	return nil
+}
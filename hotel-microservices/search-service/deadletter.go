@@ -0,0 +1,276 @@
+package searchservice
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/streadway/amqp"
+
+	"events/amqpx"
+)
+
+const (
+	// DefaultMaxAttempts es cuántas veces se intenta aplicar un hotel_updates
+	// antes de mandarlo a la dead-letter queue
+	DefaultMaxAttempts = 5
+	// DefaultRetryBackoff es la espera antes del segundo intento; se duplica en cada uno
+	DefaultRetryBackoff = time.Second
+
+	defaultDeadLetterLimit = 50
+	maxDeadLetterLimit     = 500
+)
+
+// hotelUpdatesDeadLetterQueue guarda los hotel_updates que no se pudieron indexar
+const hotelUpdatesDeadLetterQueue = "hotel_updates.dlq"
+
+// DeadLetter es un hotel_updates descartado junto con el motivo
+type DeadLetter struct {
+	// Body es el mensaje tal como llegó, que puede no ser JSON válido
+	Body     string    `json:"body"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// DeadLetterQueue guarda los mensajes que agotaron los reintentos
+type DeadLetterQueue interface {
+	Add(letter DeadLetter) error
+	// Peek devuelve hasta limit mensajes sin sacarlos de la cola
+	Peek(limit int) ([]DeadLetter, error)
+	// Take pasa hasta limit mensajes por fn; los que fn procesa sin error
+	// salen de la cola y el resto queda. Devuelve cuántos salieron.
+	Take(limit int, fn func(DeadLetter) error) (int, error)
+}
+
+// ProcessHotelUpdate aplica un hotel_updates reintentando con backoff los
+// errores del índice. Los mensajes malformados o que agotan maxAttempts van a
+// la dead-letter queue; sólo devuelve error si tampoco se pudieron guardar ahí.
+func (s *SearchService) ProcessHotelUpdate(body []byte) error {
+	backoff := s.retryBackoff
+	var err error
+	attempts := 0
+	for attempts < s.maxAttempts {
+		attempts++
+		if err = s.HandleHotelUpdate(body); err == nil {
+			return nil
+		}
+		if errors.Is(err, ErrMalformedHotelUpdate) || attempts == s.maxAttempts {
+			break
+		}
+
+		log.Printf("Error handling hotel update (attempt %d/%d), retrying in %s: %v", attempts, s.maxAttempts, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	log.Printf("Dead-lettering hotel update after %d attempts: %v", attempts, err)
+	return s.deadLetters.Add(DeadLetter{
+		Body:     string(body),
+		Error:    err.Error(),
+		Attempts: attempts,
+		FailedAt: time.Now().UTC(),
+	})
+}
+
+// ReplayDeadLetters vuelve a aplicar hasta limit mensajes de la dead-letter
+// queue, una sola vez cada uno. Devuelve cuántos se aplicaron.
+func (s *SearchService) ReplayDeadLetters(limit int) (int, error) {
+	return s.deadLetters.Take(limit, func(letter DeadLetter) error {
+		if err := s.HandleHotelUpdate([]byte(letter.Body)); err != nil {
+			log.Printf("Error replaying dead-lettered hotel update: %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+func deadLetterLimit(c *gin.Context) (int, error) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return defaultDeadLetterLimit, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 || limit > maxDeadLetterLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxDeadLetterLimit)
+	}
+	return limit, nil
+}
+
+func (s *SearchService) listDeadLetters(c *gin.Context) {
+	limit, err := deadLetterLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	letters, err := s.deadLetters.Peek(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if letters == nil {
+		letters = []DeadLetter{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"dead_letters": letters, "total": len(letters)})
+}
+
+func (s *SearchService) replayDeadLetters(c *gin.Context) {
+	limit, err := deadLetterLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	replayed, err := s.ReplayDeadLetters(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+}
+
+// amqpDeadLetterQueue guarda los mensajes en una cola durable de RabbitMQ, con
+// el motivo en los headers. La cola la declara HotelUpdatesTopology.
+type amqpDeadLetterQueue struct {
+	mu      sync.Mutex
+	rabbit  *amqpx.RabbitMQ
+	channel *amqp.Channel
+}
+
+// NewAMQPDeadLetterQueue usa un canal propio, aparte del del consumidor, que
+// vuelve a abrir si se pierde la conexión
+func NewAMQPDeadLetterQueue(rabbit *amqpx.RabbitMQ) DeadLetterQueue {
+	return &amqpDeadLetterQueue{rabbit: rabbit}
+}
+
+func (q *amqpDeadLetterQueue) openChannel() error {
+	if q.channel != nil {
+		return nil
+	}
+	channel, err := q.rabbit.Channel()
+	if err != nil {
+		return err
+	}
+	q.channel = channel
+	return nil
+}
+
+// fail descarta el canal si err lo dejó inservible
+func (q *amqpDeadLetterQueue) fail(err error) error {
+	if err != nil && q.channel != nil {
+		q.channel.Close()
+		q.channel = nil
+	}
+	return err
+}
+
+func (q *amqpDeadLetterQueue) Add(letter DeadLetter) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.openChannel(); err != nil {
+		return err
+	}
+
+	return q.fail(q.channel.Publish("", hotelUpdatesDeadLetterQueue, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    letter.FailedAt,
+		Headers: amqp.Table{
+			"x-error":    letter.Error,
+			"x-attempts": int32(letter.Attempts),
+		},
+		Body: []byte(letter.Body),
+	}))
+}
+
+func (q *amqpDeadLetterQueue) Peek(limit int) ([]DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	deliveries, err := q.get(limit)
+	// Devolverlos a la cola en su lugar original
+	for _, delivery := range deliveries {
+		delivery.Nack(false, true)
+	}
+	if err != nil {
+		return nil, q.fail(err)
+	}
+
+	letters := make([]DeadLetter, len(deliveries))
+	for i, delivery := range deliveries {
+		letters[i] = deadLetterFromDelivery(delivery)
+	}
+	return letters, nil
+}
+
+func (q *amqpDeadLetterQueue) Take(limit int, fn func(DeadLetter) error) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Se sacan todos antes de procesar para no volver a leer los que fallan
+	deliveries, err := q.get(limit)
+	if err != nil {
+		for _, delivery := range deliveries {
+			delivery.Nack(false, true)
+		}
+		return 0, q.fail(err)
+	}
+
+	taken := 0
+	for _, delivery := range deliveries {
+		if err := fn(deadLetterFromDelivery(delivery)); err != nil {
+			delivery.Nack(false, true)
+			continue
+		}
+		if err := delivery.Ack(false); err != nil {
+			return taken, q.fail(err)
+		}
+		taken++
+	}
+	return taken, nil
+}
+
+func (q *amqpDeadLetterQueue) get(limit int) ([]amqp.Delivery, error) {
+	if err := q.openChannel(); err != nil {
+		return nil, err
+	}
+
+	var deliveries []amqp.Delivery
+	for len(deliveries) < limit {
+		delivery, ok, err := q.channel.Get(hotelUpdatesDeadLetterQueue, false)
+		if err != nil {
+			return deliveries, err
+		}
+		if !ok {
+			break
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+func deadLetterFromDelivery(delivery amqp.Delivery) DeadLetter {
+	letter := DeadLetter{
+		Body:     string(delivery.Body),
+		FailedAt: delivery.Timestamp,
+	}
+	if reason, ok := delivery.Headers["x-error"].(string); ok {
+		letter.Error = reason
+	}
+	switch attempts := delivery.Headers["x-attempts"].(type) {
+	case int32:
+		letter.Attempts = int(attempts)
+	case int64:
+		letter.Attempts = int(attempts)
+	}
+	return letter
+}
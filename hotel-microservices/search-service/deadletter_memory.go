@@ -0,0 +1,48 @@
+package searchservice
+
+import "sync"
+
+// memoryDeadLetterQueue es una DeadLetterQueue en memoria para tests
+type memoryDeadLetterQueue struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+}
+
+func NewMemoryDeadLetterQueue() DeadLetterQueue {
+	return &memoryDeadLetterQueue{}
+}
+
+func (m *memoryDeadLetterQueue) Add(letter DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.letters = append(m.letters, letter)
+	return nil
+}
+
+func (m *memoryDeadLetterQueue) Peek(limit int) ([]DeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if limit > len(m.letters) {
+		limit = len(m.letters)
+	}
+	return append([]DeadLetter(nil), m.letters[:limit]...), nil
+}
+
+func (m *memoryDeadLetterQueue) Take(limit int, fn func(DeadLetter) error) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var remaining []DeadLetter
+	taken := 0
+	for i, letter := range m.letters {
+		if i < limit && fn(letter) == nil {
+			taken++
+			continue
+		}
+		remaining = append(remaining, letter)
+	}
+	m.letters = remaining
+	return taken, nil
+}
@@ -0,0 +1,126 @@
+package searchservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// flakyIndex falla los primeros failures Index, como un Solr caído
+type flakyIndex struct {
+	SearchIndex
+	failures int
+	calls    int
+}
+
+func (f *flakyIndex) Index(ctx context.Context, doc HotelDocument) error {
+	f.calls++
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("solr unavailable")
+	}
+	return f.SearchIndex.Index(ctx, doc)
+}
+
+func newFlakyTestService(failures int) (*SearchService, *flakyIndex) {
+	index := &flakyIndex{SearchIndex: NewMemoryIndex(), failures: failures}
+	service := newTestService(stubAvailability{})
+	service.index = index
+	service.maxAttempts = 3
+	return service, index
+}
+
+const plazaCreated = `{"action":"created","hotel":{"id":"abc","name":"Plaza","city":"Mendoza"}}`
+
+func TestProcessHotelUpdateRetriesIndexErrors(t *testing.T) {
+	service, index := newFlakyTestService(2)
+
+	if err := service.ProcessHotelUpdate([]byte(plazaCreated)); err != nil {
+		t.Fatal(err)
+	}
+	if index.calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", index.calls)
+	}
+	if letters, _ := service.deadLetters.Peek(10); len(letters) != 0 {
+		t.Fatalf("expected nothing dead-lettered, got %+v", letters)
+	}
+	if docs, _ := index.Search(context.Background(), "Mendoza"); len(docs) != 1 {
+		t.Fatalf("expected hotel to be indexed, got %+v", docs)
+	}
+}
+
+func TestProcessHotelUpdateDeadLettersAfterMaxAttempts(t *testing.T) {
+	service, index := newFlakyTestService(5)
+
+	if err := service.ProcessHotelUpdate([]byte(plazaCreated)); err != nil {
+		t.Fatal(err)
+	}
+	// Los malformados no se reintentan
+	if err := service.ProcessHotelUpdate([]byte(`{"action":"archived","hotel":{"id":"x"}}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	letters, _ := service.deadLetters.Peek(10)
+	if len(letters) != 2 || index.calls != 3 {
+		t.Fatalf("expected two dead letters after 3 index calls, got %+v after %d", letters, index.calls)
+	}
+	if letters[0].Attempts != 3 || letters[0].Error == "" || letters[0].Body != plazaCreated {
+		t.Fatalf("unexpected dead letter %+v", letters[0])
+	}
+	if letters[1].Attempts != 1 || !errors.Is(service.HandleHotelUpdate([]byte(letters[1].Body)), ErrMalformedHotelUpdate) {
+		t.Fatalf("expected the malformed update to be dead-lettered on the first attempt, got %+v", letters[1])
+	}
+}
+
+func TestDeadLetterEndpoints(t *testing.T) {
+	service, _ := newFlakyTestService(3)
+	service.ProcessHotelUpdate([]byte(plazaCreated))
+	service.ProcessHotelUpdate([]byte(`not json`))
+	router := NewRouter(service)
+
+	req := newAdminRequest(t, "GET", "/admin/dead-letters")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var listed struct {
+		DeadLetters []DeadLetter `json:"dead_letters"`
+		Total       int          `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil || w.Code != http.StatusOK || listed.Total != 2 {
+		t.Fatalf("expected two dead letters, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Solr ya responde: se aplica el primero y el malformado queda en la cola
+	req = newAdminRequest(t, "POST", "/admin/dead-letters/replay")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != `{"replayed":1}` {
+		t.Fatalf("expected one replayed message, got %d: %s", w.Code, w.Body.String())
+	}
+	if docs, _ := service.index.Search(context.Background(), "Mendoza"); len(docs) != 1 {
+		t.Fatalf("expected replayed hotel to be indexed, got %+v", docs)
+	}
+	if letters, _ := service.deadLetters.Peek(10); len(letters) != 1 || letters[0].Body != "not json" {
+		t.Fatalf("expected only the malformed message to remain, got %+v", letters)
+	}
+
+	req = newAdminRequest(t, "GET", "/admin/dead-letters?limit=0")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid limit, got %d", w.Code)
+	}
+}
+
+func TestSolrIndexReportsUpdateErrors(t *testing.T) {
+	solr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"msg":"undefined field"}}`, http.StatusBadRequest)
+	}))
+	defer solr.Close()
+
+	if err := NewSolrIndex(solr.URL).Index(context.Background(), HotelDocument{ID: "1"}); err == nil {
+		t.Fatal("expected an error when Solr rejects the update")
+	}
+}
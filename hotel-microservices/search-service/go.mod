@@ -3,7 +3,9 @@ module search-service
 go 1.19
 
 require (
+	events v0.0.0
 	github.com/gin-gonic/gin v1.9.1
+	github.com/golang-jwt/jwt/v4 v4.5.0
 	github.com/streadway/amqp v1.0.0
 )
 
@@ -33,3 +35,5 @@ require (
 	google.golang.org/protobuf v1.30.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace events => ../events
@@ -0,0 +1,79 @@
+package searchservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ErrHotelNotFound es un hotel que ya no existe en hotel-service
+var ErrHotelNotFound = errors.New("hotel not found in hotel-service")
+
+// HotelSource recorre los hoteles de hotel-service en páginas ordenadas por ID
+type HotelSource interface {
+	// HotelPage devuelve hasta limit hoteles con ID mayor que after; una
+	// página vacía es el final
+	HotelPage(ctx context.Context, after string, limit int) ([]HotelDocument, error)
+	// Hotel devuelve el estado actual de un hotel, o ErrHotelNotFound
+	Hotel(ctx context.Context, hotelID string) (HotelDocument, error)
+}
+
+type httpHotelSource struct {
+	hotelsURL string
+	client    *http.Client
+}
+
+// NewHTTPHotelSource usa GET hotelsURL?after=&limit=, donde hotelsURL es la
+// colección de hoteles, p.ej. http://nginx/api/hotels
+func NewHTTPHotelSource(hotelsURL string) HotelSource {
+	return &httpHotelSource{hotelsURL: hotelsURL, client: http.DefaultClient}
+}
+
+func (h *httpHotelSource) Hotel(ctx context.Context, hotelID string) (HotelDocument, error) {
+	var hotel HotelDocument
+	err := h.get(ctx, h.hotelsURL+"/"+url.PathEscape(hotelID), &hotel)
+	return hotel, err
+}
+
+func (h *httpHotelSource) HotelPage(ctx context.Context, after string, limit int) ([]HotelDocument, error) {
+	params := url.Values{"limit": {strconv.Itoa(limit)}}
+	if after != "" {
+		params.Set("after", after)
+	}
+
+	var hotels []HotelDocument
+	if err := h.get(ctx, h.hotelsURL+"?"+params.Encode(), &hotels); err != nil {
+		return nil, err
+	}
+	return hotels, nil
+}
+
+// get decodifica en out la respuesta de hotel-service; un 404 es ErrHotelNotFound
+func (h *httpHotelSource) get(ctx context.Context, hotelURL string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", hotelURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrHotelNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("hotel-service returned %d: %s", resp.StatusCode, body)
+	}
+
+	// Los campos que se indexan tienen el mismo nombre en la API de hotel-service
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,307 @@
+package searchservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type SearchIndex interface {
+	Search(ctx context.Context, city string) ([]HotelDocument, error)
+	Index(ctx context.Context, doc HotelDocument) error
+	Delete(ctx context.Context, hotelID string) error
+}
+
+// RebuildableIndex es un índice que se puede reconstruir en una colección
+// aparte y publicar de una vez: las búsquedas, Index y Delete van siempre a la
+// colección a la que apunta el alias.
+type RebuildableIndex interface {
+	SearchIndex
+	// EnsureAlias crea una primera colección y el alias si todavía no existen;
+	// devuelve true si los creó, es decir, si la colección está vacía
+	EnsureAlias(ctx context.Context) (bool, error)
+	CreateCollection(ctx context.Context, collection string) error
+	// IndexInto y DeleteFrom escriben en collection sin hacer commit
+	IndexInto(ctx context.Context, collection string, docs []HotelDocument) error
+	DeleteFrom(ctx context.Context, collection, hotelID string) error
+	Commit(ctx context.Context, collection string) error
+	// SwapAlias apunta el alias a collection y devuelve la colección anterior
+	SwapAlias(ctx context.Context, collection string) (string, error)
+	DropCollection(ctx context.Context, collection string) error
+}
+
+// ListableIndex es un índice que se puede recorrer entero, para reconciliarlo
+// con hotel-service
+type ListableIndex interface {
+	SearchIndex
+	// Documents devuelve hasta limit documentos con ID mayor que after,
+	// ordenados por ID y sólo con id y updated_at; una página vacía es el final
+	Documents(ctx context.Context, after string, limit int) ([]HotelDocument, error)
+	// Document devuelve el id y updated_at del documento indexado ahora,
+	// incluyendo lo escrito y todavía no visible en las búsquedas
+	Document(ctx context.Context, hotelID string) (HotelDocument, bool, error)
+}
+
+// searchAlias es el alias de SolrCloud que reciben las búsquedas
+const searchAlias = "hotels"
+
+// searchRows es el máximo de hoteles que devuelve Search
+const searchRows = 100
+
+type solrIndex struct {
+	baseURL string
+	client  *http.Client
+	// batcher agrupa los Index y Delete sobre el alias
+	batcher *updateBatcher
+}
+
+// NewSolrIndex necesita Solr en modo cloud: el índice es un alias sobre
+// colecciones que crea y borra Reindex
+func NewSolrIndex(baseURL string) SearchIndex {
+	index := &solrIndex{baseURL: baseURL, client: http.DefaultClient}
+	index.batcher = newUpdateBatcher(DefaultBatchSize, DefaultBatchWindow, index.applyBatch)
+	return index
+}
+
+func (s *solrIndex) Search(ctx context.Context, city string) ([]HotelDocument, error) {
+	return s.selectDocuments(ctx, newSolrQuery("*:*").FilterPhrase("city", city).Rows(searchRows))
+}
+
+func (s *solrIndex) Documents(ctx context.Context, after string, limit int) ([]HotelDocument, error) {
+	query := newSolrQuery("*:*").Fields("id", "updated_at").Sort("id asc").Rows(limit)
+	if after != "" {
+		query.FilterAfter("id", after)
+	}
+	return s.selectDocuments(ctx, query)
+}
+
+func (s *solrIndex) Document(ctx context.Context, hotelID string) (HotelDocument, bool, error) {
+	// El real-time get ve también lo que todavía no alcanzó el commitWithin
+	params := url.Values{"id": {hotelID}, "fl": {"id,updated_at"}, "wt": {"json"}}
+	solrURL := fmt.Sprintf("%s/solr/%s/get?%s", s.baseURL, searchAlias, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", solrURL, nil)
+	if err != nil {
+		return HotelDocument{}, false, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return HotelDocument{}, false, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return HotelDocument{}, false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return HotelDocument{}, false, fmt.Errorf("solr get returned %d: %s", resp.StatusCode, body)
+	}
+
+	var response struct {
+		Doc *HotelDocument `json:"doc"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return HotelDocument{}, false, err
+	}
+	if response.Doc == nil {
+		return HotelDocument{}, false, nil
+	}
+	return *response.Doc, true, nil
+}
+
+func (s *solrIndex) selectDocuments(ctx context.Context, query *solrQuery) ([]HotelDocument, error) {
+	solrURL := fmt.Sprintf("%s/solr/%s/select?%s", s.baseURL, searchAlias, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", solrURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("solr select returned %d: %s", resp.StatusCode, body)
+	}
+
+	var solrResponse SolrResponse
+	if err := json.Unmarshal(body, &solrResponse); err != nil {
+		return nil, err
+	}
+
+	return solrResponse.Response.Docs, nil
+}
+
+func (s *solrIndex) Index(ctx context.Context, doc HotelDocument) error {
+	return s.batcher.submit(ctx, doc.ID, &doc)
+}
+
+func (s *solrIndex) Delete(ctx context.Context, hotelID string) error {
+	return s.batcher.submit(ctx, hotelID, nil)
+}
+
+// applyBatch manda un batch al alias con commitWithin; cada hotel aparece una
+// sola vez, así que no importa el orden entre altas y borrados
+func (s *solrIndex) applyBatch(ctx context.Context, docs []HotelDocument, deletes []string) error {
+	commitWithin := url.Values{"commitWithin": {strconv.FormatInt(DefaultCommitWithin.Milliseconds(), 10)}}
+	if len(docs) > 0 {
+		if err := s.update(ctx, searchAlias, commitWithin, docs); err != nil {
+			return err
+		}
+	}
+	if len(deletes) > 0 {
+		return s.update(ctx, searchAlias, commitWithin, map[string]interface{}{"delete": deletes})
+	}
+	return nil
+}
+
+func (s *solrIndex) IndexInto(ctx context.Context, collection string, docs []HotelDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	return s.update(ctx, collection, nil, docs)
+}
+
+func (s *solrIndex) DeleteFrom(ctx context.Context, collection, hotelID string) error {
+	return s.update(ctx, collection, nil, map[string]interface{}{
+		"delete": map[string]string{"id": hotelID},
+	})
+}
+
+func (s *solrIndex) Commit(ctx context.Context, collection string) error {
+	return s.update(ctx, collection, nil, map[string]interface{}{
+		"commit": map[string]interface{}{},
+	})
+}
+
+func (s *solrIndex) update(ctx context.Context, collection string, params url.Values, payload interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	solrURL := fmt.Sprintf("%s/solr/%s/update", s.baseURL, collection)
+	if len(params) > 0 {
+		solrURL += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", solrURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("solr update returned %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+// newCollectionName nombra las colecciones que apunta el alias
+func newCollectionName() string {
+	return fmt.Sprintf("%s_%d", searchAlias, time.Now().UnixMilli())
+}
+
+func (s *solrIndex) EnsureAlias(ctx context.Context) (bool, error) {
+	current, err := s.aliasedCollection(ctx)
+	if err != nil || current != "" {
+		return false, err
+	}
+
+	collection := newCollectionName()
+	if err := s.CreateCollection(ctx, collection); err != nil {
+		return false, err
+	}
+	if _, err := s.SwapAlias(ctx, collection); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *solrIndex) CreateCollection(ctx context.Context, collection string) error {
+	return s.collections(ctx, url.Values{
+		"action":                {"CREATE"},
+		"name":                  {collection},
+		"numShards":             {"1"},
+		"collection.configName": {"_default"},
+	}, nil)
+}
+
+// SwapAlias usa CREATEALIAS, que reemplaza el alias en un solo paso
+func (s *solrIndex) SwapAlias(ctx context.Context, collection string) (string, error) {
+	previous, err := s.aliasedCollection(ctx)
+	if err != nil {
+		return "", err
+	}
+	err = s.collections(ctx, url.Values{
+		"action":      {"CREATEALIAS"},
+		"name":        {searchAlias},
+		"collections": {collection},
+	}, nil)
+	return previous, err
+}
+
+func (s *solrIndex) DropCollection(ctx context.Context, collection string) error {
+	return s.collections(ctx, url.Values{
+		"action": {"DELETE"},
+		"name":   {collection},
+	}, nil)
+}
+
+// aliasedCollection devuelve la colección del alias, o "" si no existe
+func (s *solrIndex) aliasedCollection(ctx context.Context) (string, error) {
+	var response struct {
+		Aliases map[string]string `json:"aliases"`
+	}
+	if err := s.collections(ctx, url.Values{"action": {"LISTALIASES"}}, &response); err != nil {
+		return "", err
+	}
+	return response.Aliases[searchAlias], nil
+}
+
+// collections llama a la Collections API y, si out no es nil, decodifica la respuesta
+func (s *solrIndex) collections(ctx context.Context, params url.Values, out interface{}) error {
+	params.Set("wt", "json")
+	solrURL := fmt.Sprintf("%s/solr/admin/collections?%s", s.baseURL, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", solrURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("solr %s returned %d: %s", params.Get("action"), resp.StatusCode, body)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
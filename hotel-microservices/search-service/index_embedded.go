@@ -0,0 +1,216 @@
+package searchservice
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Backends de búsqueda que se eligen con SEARCH_BACKEND
+const (
+	SolrBackend     = "solr"
+	EmbeddedBackend = "embedded"
+)
+
+// embeddedBatchWindow es la ventana del batcher del índice embebido: escribir
+// el archivo es más barato que un update a Solr, así que espera menos
+const embeddedBatchWindow = 50 * time.Millisecond
+
+// embeddedMinCompaction es el tamaño mínimo del log antes de compactarlo; por
+// encima, se compacta cuando el log pesa más que el snapshot, así cada batch
+// cuesta en promedio lo que pesa y no lo que pesa el índice entero
+const embeddedMinCompaction = 1 << 20
+
+// embeddedSnapshot es el formato del archivo del índice embebido
+type embeddedSnapshot struct {
+	Generation  int64                      `json:"generation"`
+	Alias       string                     `json:"alias"`
+	Collections map[string][]HotelDocument `json:"collections"`
+}
+
+// embeddedLogEntry es un batch aplicado al alias, una línea JSON por batch en
+// el log que acompaña al snapshot
+type embeddedLogEntry struct {
+	Generation int64           `json:"generation"`
+	Index      []HotelDocument `json:"index,omitempty"`
+	Delete     []string        `json:"delete,omitempty"`
+}
+
+// NewEmbeddedIndex abre el índice embebido guardado en path, o lo crea vacío.
+// Corre dentro del proceso, así search-service funciona sin Solr. Cada
+// instancia tiene el suyo, así que tiene que consumir los eventos de hoteles
+// con su propia cola (ver HotelUpdatesQueue).
+//
+// path guarda un snapshot completo y path + ".log" los batches posteriores;
+// al abrir se carga el snapshot y se repite el log encima.
+func NewEmbeddedIndex(path string) (SearchIndex, error) {
+	index := newMemoryIndex()
+	index.path = path
+	index.batcher = newUpdateBatcher(DefaultBatchSize, embeddedBatchWindow, index.applyBatch)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, index.persist()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot embeddedSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("reading embedded index %s: %v", path, err)
+	}
+	if _, ok := snapshot.Collections[snapshot.Alias]; !ok {
+		return nil, fmt.Errorf("reading embedded index %s: alias points to missing collection %q", path, snapshot.Alias)
+	}
+
+	index.alias = snapshot.Alias
+	index.collections = map[string]*memoryCollection{}
+	for name, docs := range snapshot.Collections {
+		collection := newMemoryCollection()
+		for _, doc := range docs {
+			collection.put(doc)
+		}
+		index.collections[name] = collection
+	}
+	index.generation = snapshot.Generation
+	index.snapshotSize = int64(len(data))
+	if err := index.replayLog(); err != nil {
+		return nil, fmt.Errorf("reading embedded index log %s: %v", index.logPath(), err)
+	}
+	return index, nil
+}
+
+func (m *memoryIndex) logPath() string {
+	return m.path + ".log"
+}
+
+// replayLog aplica al alias los batches del log de la generación del
+// snapshot. Los de otra generación quedaron de antes de una compactación que
+// no llegó a borrar el log, y ya están en el snapshot. Una última línea
+// cortada es un batch que no terminó de escribirse y se descarta.
+func (m *memoryIndex) replayLog() error {
+	file, err := os.Open(m.logPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	collection := m.collections[m.alias]
+	decoder := json.NewDecoder(file)
+	for {
+		var entry embeddedLogEntry
+		err := decoder.Decode(&entry)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("Ignoring truncated embedded index log %s: %v", m.logPath(), err)
+			break
+		}
+		if entry.Generation != m.generation {
+			continue
+		}
+		for _, doc := range entry.Index {
+			collection.put(doc)
+		}
+		for _, hotelID := range entry.Delete {
+			collection.remove(hotelID)
+		}
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	m.logSize = info.Size()
+	return nil
+}
+
+// appendLog agrega un batch al log y lo compacta cuando crece más que el
+// snapshot. Se llama con m.mu tomado.
+func (m *memoryIndex) appendLog(docs []HotelDocument, deletes []string) error {
+	if m.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(embeddedLogEntry{Generation: m.generation, Index: docs, Delete: deletes})
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(m.logPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	m.logSize += int64(len(data) + 1)
+	if m.logSize > embeddedMinCompaction && m.logSize > m.snapshotSize {
+		return m.persist()
+	}
+	return nil
+}
+
+// persist escribe el índice completo en m.path, reemplazando el archivo de una
+// vez para no dejarlo a medias, y empieza un log vacío. Los cambios de
+// colección y alias persisten así; los batches van al log. Se llama con m.mu tomado.
+func (m *memoryIndex) persist() error {
+	if m.path == "" {
+		return nil
+	}
+
+	snapshot := embeddedSnapshot{Generation: m.generation + 1, Alias: m.alias, Collections: map[string][]HotelDocument{}}
+	for name, collection := range m.collections {
+		docs := make([]HotelDocument, 0, len(collection.docs))
+		for _, doc := range collection.docs {
+			docs = append(docs, doc)
+		}
+		snapshot.Collections[name] = docs
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), filepath.Base(m.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		return err
+	}
+
+	// Desde acá el log viejo es de otra generación: si no se llega a borrar,
+	// replayLog lo ignora
+	m.generation = snapshot.Generation
+	m.snapshotSize = int64(len(data))
+	m.logSize = 0
+	if err := os.Remove(m.logPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package searchservice
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// memoryCollection es una colección con un índice invertido por palabra de la ciudad
+type memoryCollection struct {
+	docs   map[string]HotelDocument
+	byWord map[string]map[string]bool
+}
+
+func newMemoryCollection() *memoryCollection {
+	return &memoryCollection{docs: map[string]HotelDocument{}, byWord: map[string]map[string]bool{}}
+}
+
+// cityWords separa la ciudad en palabras como el campo de texto de Solr: sin
+// distinguir mayúsculas y cortando en todo lo que no sea letra o número
+func cityWords(city string) []string {
+	return strings.FieldsFunc(strings.ToLower(city), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// containsPhrase dice si words tiene a phrase como palabras seguidas, igual
+// que la phrase query de Solr
+func containsPhrase(words, phrase []string) bool {
+	for start := 0; start+len(phrase) <= len(words); start++ {
+		match := true
+		for i, word := range phrase {
+			if words[start+i] != word {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *memoryCollection) put(doc HotelDocument) {
+	c.remove(doc.ID)
+	c.docs[doc.ID] = doc
+
+	for _, word := range cityWords(doc.City) {
+		if c.byWord[word] == nil {
+			c.byWord[word] = map[string]bool{}
+		}
+		c.byWord[word][doc.ID] = true
+	}
+}
+
+func (c *memoryCollection) remove(hotelID string) {
+	previous, ok := c.docs[hotelID]
+	if !ok {
+		return
+	}
+	delete(c.docs, hotelID)
+
+	for _, word := range cityWords(previous.City) {
+		delete(c.byWord[word], hotelID)
+		if len(c.byWord[word]) == 0 {
+			delete(c.byWord, word)
+		}
+	}
+}
+
+// search devuelve los documentos cuya ciudad contiene city como frase
+func (c *memoryCollection) search(city string) []HotelDocument {
+	phrase := cityWords(city)
+	if len(phrase) == 0 {
+		return nil
+	}
+
+	var results []HotelDocument
+	for id := range c.byWord[phrase[0]] {
+		doc := c.docs[id]
+		if containsPhrase(cityWords(doc.City), phrase) {
+			results = append(results, doc)
+		}
+	}
+	return results
+}
+
+// memoryIndex es un SearchIndex en Go puro. Guarda colecciones por nombre como
+// Solr, así también sirve para Reindex y Reconcile. Sin path vive sólo en
+// memoria, para tests; con path es el backend embebido (ver NewEmbeddedIndex).
+type memoryIndex struct {
+	mu          sync.RWMutex
+	collections map[string]*memoryCollection
+	// alias es la colección que reciben las búsquedas
+	alias string
+	// path es el archivo donde se persiste, o "" para no persistir
+	path string
+	// batcher agrupa los Index y Delete para persistir una vez por batch; es
+	// nil sin path, así los tests en memoria aplican cada cambio en el acto
+	batcher *updateBatcher
+	// generation numera los snapshots; el log sólo vale para el snapshot de su generación
+	generation int64
+	// snapshotSize y logSize deciden cuándo compactar el log en un snapshot
+	snapshotSize int64
+	logSize      int64
+}
+
+func NewMemoryIndex() SearchIndex {
+	return newMemoryIndex()
+}
+
+func newMemoryIndex() *memoryIndex {
+	return &memoryIndex{
+		collections: map[string]*memoryCollection{searchAlias: newMemoryCollection()},
+		alias:       searchAlias,
+	}
+}
+
+func (m *memoryIndex) Search(ctx context.Context, city string) ([]HotelDocument, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := m.collections[m.alias].search(city)
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > searchRows {
+		results = results[:searchRows]
+	}
+
+	return results, nil
+}
+
+func (m *memoryIndex) Index(ctx context.Context, doc HotelDocument) error {
+	if m.batcher != nil {
+		return m.batcher.submit(ctx, doc.ID, &doc)
+	}
+	return m.applyBatch(ctx, []HotelDocument{doc}, nil)
+}
+
+func (m *memoryIndex) Delete(ctx context.Context, hotelID string) error {
+	if m.batcher != nil {
+		return m.batcher.submit(ctx, hotelID, nil)
+	}
+	return m.applyBatch(ctx, nil, []string{hotelID})
+}
+
+// applyBatch aplica los cambios al alias y los agrega al log en una sola escritura
+func (m *memoryIndex) applyBatch(ctx context.Context, docs []HotelDocument, deletes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	collection := m.collections[m.alias]
+	for _, doc := range docs {
+		collection.put(doc)
+	}
+	for _, hotelID := range deletes {
+		collection.remove(hotelID)
+	}
+	return m.appendLog(docs, deletes)
+}
+
+func (m *memoryIndex) Documents(ctx context.Context, after string, limit int) ([]HotelDocument, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var docs []HotelDocument
+	for id, doc := range m.collections[m.alias].docs {
+		if id > after {
+			docs = append(docs, HotelDocument{ID: doc.ID, UpdatedAt: doc.UpdatedAt})
+		}
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].ID < docs[j].ID
+	})
+	if len(docs) > limit {
+		docs = docs[:limit]
+	}
+	return docs, nil
+}
+
+func (m *memoryIndex) Document(ctx context.Context, hotelID string) (HotelDocument, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	doc, ok := m.collections[m.alias].docs[hotelID]
+	if !ok {
+		return HotelDocument{}, false, nil
+	}
+	return HotelDocument{ID: doc.ID, UpdatedAt: doc.UpdatedAt}, true, nil
+}
+
+func (m *memoryIndex) EnsureAlias(ctx context.Context) (bool, error) {
+	return false, nil
+}
+
+func (m *memoryIndex) CreateCollection(ctx context.Context, collection string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.collections[collection]; ok {
+		return fmt.Errorf("collection %s already exists", collection)
+	}
+	m.collections[collection] = newMemoryCollection()
+	return m.persist()
+}
+
+// IndexInto y DeleteFrom no persisten: como en Solr, quedan para el Commit
+func (m *memoryIndex) IndexInto(ctx context.Context, collection string, docs []HotelDocument) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	target, ok := m.collections[collection]
+	if !ok {
+		return fmt.Errorf("collection %s not found", collection)
+	}
+	for _, doc := range docs {
+		target.put(doc)
+	}
+	return nil
+}
+
+func (m *memoryIndex) DeleteFrom(ctx context.Context, collection, hotelID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	target, ok := m.collections[collection]
+	if !ok {
+		return fmt.Errorf("collection %s not found", collection)
+	}
+	target.remove(hotelID)
+	return nil
+}
+
+func (m *memoryIndex) Commit(ctx context.Context, collection string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.persist()
+}
+
+func (m *memoryIndex) SwapAlias(ctx context.Context, collection string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.collections[collection]; !ok {
+		return "", fmt.Errorf("collection %s not found", collection)
+	}
+	previous := m.alias
+	m.alias = collection
+	return previous, m.persist()
+}
+
+func (m *memoryIndex) DropCollection(ctx context.Context, collection string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if collection == m.alias {
+		return fmt.Errorf("collection %s is aliased", collection)
+	}
+	delete(m.collections, collection)
+	return m.persist()
+}
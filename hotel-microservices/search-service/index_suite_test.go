@@ -0,0 +1,330 @@
+package searchservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventually reintenta check hasta que dé true; Solr hace visibles los cambios
+// recién con el commitWithin
+func eventually(t *testing.T, what string, check func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !check() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func searchIDs(t *testing.T, index SearchIndex, city string) []string {
+	t.Helper()
+
+	docs, err := index.Search(context.Background(), city)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// testSearchIndex es el comportamiento que tiene que cumplir cada backend. Los
+// IDs y ciudades llevan un prefijo por corrida para poder usar un Solr compartido.
+func testSearchIndex(t *testing.T, newIndex func(t *testing.T) SearchIndex) {
+	ctx := context.Background()
+	run := fmt.Sprintf("t%d", time.Now().UnixNano())
+	city := func(name string) string { return run + name }
+	id := func(n int) string { return fmt.Sprintf("%s-%d", run, n) }
+
+	t.Run("SearchByCity", func(t *testing.T) {
+		index := newIndex(t)
+		index.Index(ctx, HotelDocument{ID: id(1), Name: "Plaza", City: city("Rosario")})
+		index.Index(ctx, HotelDocument{ID: id(2), Name: "Ribera", City: city("Rosario")})
+		index.Index(ctx, HotelDocument{ID: id(3), Name: "Cumbre", City: city("Salta")})
+
+		eventually(t, "both Rosario hotels", func() bool {
+			return strings.Join(searchIDs(t, index, city("Rosario")), ",") == id(1)+","+id(2)
+		})
+		// Sin distinguir mayúsculas
+		if ids := searchIDs(t, index, strings.ToUpper(city("salta"))); len(ids) != 1 || ids[0] != id(3) {
+			t.Fatalf("expected case-insensitive match, got %v", ids)
+		}
+		if ids := searchIDs(t, index, city("Mendoza")); len(ids) != 0 {
+			t.Fatalf("expected no results, got %v", ids)
+		}
+	})
+
+	// Como la phrase query de Solr: palabras enteras y seguidas de la ciudad
+	t.Run("SearchByCityPhrase", func(t *testing.T) {
+		index := newIndex(t)
+		index.Index(ctx, HotelDocument{ID: id(11), Name: "Lago", City: city("Villa") + " Carlos Paz"})
+		index.Index(ctx, HotelDocument{ID: id(12), Name: "Sierras", City: city("Villa") + " General Belgrano"})
+
+		eventually(t, "both Villa hotels", func() bool {
+			return strings.Join(searchIDs(t, index, city("Villa")), ",") == id(11)+","+id(12)
+		})
+		if ids := searchIDs(t, index, city("villa")+" carlos"); len(ids) != 1 || ids[0] != id(11) {
+			t.Fatalf("expected the partial phrase to match, got %v", ids)
+		}
+		if ids := searchIDs(t, index, "Carlos "+city("Villa")); len(ids) != 0 {
+			t.Fatalf("expected words out of order not to match, got %v", ids)
+		}
+		if ids := searchIDs(t, index, city("Vill")); len(ids) != 0 {
+			t.Fatalf("expected part of a word not to match, got %v", ids)
+		}
+	})
+
+	t.Run("UpdateReplacesDocument", func(t *testing.T) {
+		index := newIndex(t)
+		index.Index(ctx, HotelDocument{ID: id(4), Name: "Plaza", City: city("Rosario")})
+		index.Index(ctx, HotelDocument{ID: id(4), Name: "Plaza", City: city("Salta")})
+
+		eventually(t, "the hotel to move to Salta", func() bool {
+			return len(searchIDs(t, index, city("Salta"))) == 1
+		})
+		if ids := searchIDs(t, index, city("Rosario")); len(ids) != 0 {
+			t.Fatalf("expected the old city to be gone, got %v", ids)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		index := newIndex(t)
+		index.Index(ctx, HotelDocument{ID: id(5), Name: "Plaza", City: city("Jujuy")})
+		eventually(t, "the hotel to be indexed", func() bool {
+			return len(searchIDs(t, index, city("Jujuy"))) == 1
+		})
+
+		if err := index.Delete(ctx, id(5)); err != nil {
+			t.Fatal(err)
+		}
+		eventually(t, "the hotel to be deleted", func() bool {
+			return len(searchIDs(t, index, city("Jujuy"))) == 0
+		})
+	})
+
+	t.Run("Documents", func(t *testing.T) {
+		index, ok := newIndex(t).(ListableIndex)
+		if !ok {
+			t.Skip("backend does not list documents")
+		}
+		updated := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+		for n := 6; n <= 8; n++ {
+			index.Index(ctx, HotelDocument{ID: id(n), City: city("Tandil"), UpdatedAt: updated})
+		}
+		eventually(t, "the hotels to be indexed", func() bool {
+			return len(searchIDs(t, index, city("Tandil"))) == 3
+		})
+
+		var listed []HotelDocument
+		after := id(5)
+		for {
+			docs, err := index.Documents(ctx, after, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(docs) == 0 || !strings.HasPrefix(docs[0].ID, run) {
+				break
+			}
+			listed = append(listed, docs...)
+			after = docs[len(docs)-1].ID
+		}
+		if len(listed) < 3 || listed[0].ID != id(6) || listed[2].ID != id(8) || !listed[0].UpdatedAt.Equal(updated) {
+			t.Fatalf("unexpected listing %+v", listed)
+		}
+
+		if doc, ok, err := index.Document(ctx, id(7)); err != nil || !ok || doc.ID != id(7) || !doc.UpdatedAt.Equal(updated) {
+			t.Fatalf("expected document %s, got %+v, %v, %v", id(7), doc, ok, err)
+		}
+		if _, ok, err := index.Document(ctx, id(99)); err != nil || ok {
+			t.Fatalf("expected no document %s, got %v, %v", id(99), ok, err)
+		}
+	})
+
+	t.Run("RebuildAndSwap", func(t *testing.T) {
+		index, ok := newIndex(t).(RebuildableIndex)
+		if !ok {
+			t.Skip("backend cannot be rebuilt")
+		}
+		if _, err := index.EnsureAlias(ctx); err != nil {
+			t.Fatal(err)
+		}
+		index.Index(ctx, HotelDocument{ID: id(9), City: city("Azul")})
+
+		collection := newCollectionName()
+		if err := index.CreateCollection(ctx, collection); err != nil {
+			t.Fatal(err)
+		}
+		index.IndexInto(ctx, collection, []HotelDocument{{ID: id(10), City: city("Azul")}})
+		if err := index.Commit(ctx, collection); err != nil {
+			t.Fatal(err)
+		}
+
+		// Hasta el cambio de alias las búsquedas siguen en la colección actual
+		eventually(t, "the current collection", func() bool {
+			ids := searchIDs(t, index, city("Azul"))
+			return len(ids) == 1 && ids[0] == id(9)
+		})
+
+		previous, err := index.SwapAlias(ctx, collection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := searchIDs(t, index, city("Azul")); len(ids) != 1 || ids[0] != id(10) {
+			t.Fatalf("expected the rebuilt collection after the swap, got %v", ids)
+		}
+		if err := index.DropCollection(ctx, previous); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestMemoryIndexBehavior(t *testing.T) {
+	testSearchIndex(t, func(t *testing.T) SearchIndex {
+		return NewMemoryIndex()
+	})
+}
+
+func TestEmbeddedIndexBehavior(t *testing.T) {
+	testSearchIndex(t, func(t *testing.T) SearchIndex {
+		index, err := NewEmbeddedIndex(filepath.Join(t.TempDir(), "hotels.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return index
+	})
+}
+
+// TestSolrIndexBehavior corre contra un Solr en modo cloud si se define
+// SOLR_TEST_URL, p.ej. SOLR_TEST_URL=http://localhost:8983
+func TestSolrIndexBehavior(t *testing.T) {
+	solrURL := os.Getenv("SOLR_TEST_URL")
+	if solrURL == "" {
+		t.Skip("SOLR_TEST_URL not set")
+	}
+
+	index := NewSolrIndex(solrURL)
+	if _, err := index.(RebuildableIndex).EnsureAlias(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	testSearchIndex(t, func(t *testing.T) SearchIndex {
+		return index
+	})
+}
+
+func TestEmbeddedIndexPersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "hotels.json")
+
+	index, err := NewEmbeddedIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index.Index(ctx, HotelDocument{ID: "1", Name: "Plaza", City: "Rosario"})
+	index.Index(ctx, HotelDocument{ID: "2", Name: "Cumbre", City: "Salta"})
+	index.Delete(ctx, "2")
+
+	// Los cambios que llegan juntos se persisten en un mismo batch
+	var wg sync.WaitGroup
+	for n := 0; n < 20; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			if err := index.Index(ctx, HotelDocument{ID: fmt.Sprintf("tandil-%02d", n), City: "Tandil"}); err != nil {
+				t.Error(err)
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	reopened, err := NewEmbeddedIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, reopened, "rosario"); len(ids) != 1 || ids[0] != "1" {
+		t.Fatalf("expected the persisted hotel, got %v", ids)
+	}
+	if ids := searchIDs(t, reopened, "Salta"); len(ids) != 0 {
+		t.Fatalf("expected the deleted hotel to stay deleted, got %v", ids)
+	}
+	if ids := searchIDs(t, reopened, "Tandil"); len(ids) != 20 {
+		t.Fatalf("expected the batched hotels to be persisted, got %v", ids)
+	}
+
+	os.WriteFile(path, []byte("not json"), 0o644)
+	if _, err := NewEmbeddedIndex(path); err == nil {
+		t.Fatal("expected an error for a corrupt index file")
+	}
+}
+
+func TestEmbeddedIndexAppendsBatchesAndCompacts(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "hotels.json")
+
+	index, err := NewEmbeddedIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, _ := os.ReadFile(path)
+
+	// Un batch se agrega al log sin reescribir el snapshot
+	index.Index(ctx, HotelDocument{ID: "1", City: "Rosario"})
+	index.Delete(ctx, "1")
+	index.Index(ctx, HotelDocument{ID: "2", City: "Rosario"})
+	if current, _ := os.ReadFile(path); string(current) != string(snapshot) {
+		t.Fatal("expected the batches not to rewrite the snapshot")
+	}
+
+	// Un batch cortado por una caída y uno de una generación ya compactada se descartan
+	logFile, _ := os.OpenFile(path+".log", os.O_APPEND|os.O_WRONLY, 0o644)
+	stale, _ := json.Marshal(embeddedLogEntry{Generation: index.(*memoryIndex).generation - 1, Index: []HotelDocument{{ID: "3", City: "Rosario"}}})
+	logFile.Write(append(stale, '\n'))
+	logFile.WriteString(`{"generation":1,"index":[{"id":"4"`)
+	logFile.Close()
+
+	reopened, err := NewEmbeddedIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, reopened, "Rosario"); len(ids) != 1 || ids[0] != "2" {
+		t.Fatalf("expected only the logged hotel, got %v", ids)
+	}
+
+	// Cuando el log pasa al snapshot se compacta en uno nuevo
+	big := strings.Repeat("x", embeddedMinCompaction/2)
+	for n := 0; n < 3; n++ {
+		if err := reopened.Index(ctx, HotelDocument{ID: fmt.Sprintf("big-%d", n), Name: big, City: "Salta"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if info, err := os.Stat(path + ".log"); err == nil && info.Size() > embeddedMinCompaction {
+		t.Fatalf("expected the log to be compacted, got %d bytes", info.Size())
+	}
+	if current, _ := os.ReadFile(path); len(current) < embeddedMinCompaction {
+		t.Fatal("expected the compaction to rewrite the snapshot")
+	}
+
+	compacted, err := NewEmbeddedIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, compacted, "Salta"); len(ids) != 3 {
+		t.Fatalf("expected the compacted hotels, got %v", ids)
+	}
+	if ids := searchIDs(t, compacted, "Rosario"); len(ids) != 1 {
+		t.Fatalf("expected the hotels before the compaction, got %v", ids)
+	}
+}
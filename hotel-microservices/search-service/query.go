@@ -0,0 +1,67 @@
+package searchservice
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// solrQuery arma los parámetros de un select. Lo que viene del usuario nunca
+// va en q: entra como filtro con sus caracteres especiales escapados, y todos
+// los parámetros se codifican juntos, así un valor no puede agregar otros.
+type solrQuery struct {
+	params url.Values
+}
+
+// newSolrQuery recibe en q una consulta fija del código, p.ej. *:*
+func newSolrQuery(q string) *solrQuery {
+	return &solrQuery{params: url.Values{"q": {q}, "wt": {"json"}}}
+}
+
+// FilterPhrase agrega fq=field:"value", que busca value como texto literal
+func (q *solrQuery) FilterPhrase(field, value string) *solrQuery {
+	q.params.Add("fq", field+":"+solrPhrase(value))
+	return q
+}
+
+// FilterAfter agrega fq=field:{value TO *], los valores mayores que value
+func (q *solrQuery) FilterAfter(field, value string) *solrQuery {
+	q.params.Add("fq", field+":{"+solrPhrase(value)+" TO *]")
+	return q
+}
+
+func (q *solrQuery) Fields(fields ...string) *solrQuery {
+	q.params.Set("fl", strings.Join(fields, ","))
+	return q
+}
+
+func (q *solrQuery) Sort(sort string) *solrQuery {
+	q.params.Set("sort", sort)
+	return q
+}
+
+func (q *solrQuery) Rows(rows int) *solrQuery {
+	q.params.Set("rows", strconv.Itoa(rows))
+	return q
+}
+
+// Encode devuelve el query string ya codificado
+func (q *solrQuery) Encode() string {
+	return q.params.Encode()
+}
+
+// solrPhrase pone value entre comillas escapando lo único que es especial
+// dentro de una frase del query parser de Solr: las comillas y la barra
+// invertida. Operadores, comodines y espacios quedan como texto literal.
+func solrPhrase(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		if r == '"' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package searchservice
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSolrPhraseEscapesQuotesAndBackslashes(t *testing.T) {
+	cases := map[string]string{
+		`Mendoza`:             `"Mendoza"`,
+		`Buenos Aires`:        `"Buenos Aires"`,
+		`*`:                   `"*"`,
+		`x OR name:y`:         `"x OR name:y"`,
+		`x" OR name:"y`:       `"x\" OR name:\"y"`,
+		`x\" OR *:* OR "`:     `"x\\\" OR *:* OR \""`,
+		`(a) && b~2 [c TO d]`: `"(a) && b~2 [c TO d]"`,
+	}
+	for value, expected := range cases {
+		if got := solrPhrase(value); got != expected {
+			t.Errorf("solrPhrase(%q) = %s, expected %s", value, got, expected)
+		}
+	}
+}
+
+// unquotePhrase deshace solrPhrase como lo hace el query parser y falla si la
+// frase cierra antes del final, es decir, si el valor pudo escaparse de ella
+func unquotePhrase(t *testing.T, phrase string) string {
+	t.Helper()
+
+	if len(phrase) < 2 || phrase[0] != '"' || phrase[len(phrase)-1] != '"' {
+		t.Fatalf("expected a quoted phrase, got %s", phrase)
+	}
+	var b strings.Builder
+	inner := phrase[1 : len(phrase)-1]
+	for i := 0; i < len(inner); i++ {
+		switch inner[i] {
+		case '\\':
+			i++
+			b.WriteByte(inner[i])
+		case '"':
+			t.Fatalf("phrase %s closes early", phrase)
+		default:
+			b.WriteByte(inner[i])
+		}
+	}
+	return b.String()
+}
+
+func TestSolrIndexSearchKeepsHostileCitiesInsideTheFilter(t *testing.T) {
+	var received url.Values
+	solr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.URL.Query()
+		io.WriteString(w, `{"response":{"numFound":0,"docs":[]}}`)
+	}))
+	defer solr.Close()
+
+	index := NewSolrIndex(solr.URL)
+	hostile := []string{
+		`*`,
+		`x OR name:y`,
+		`Buenos Aires`,
+		`x" OR *:* OR "y`,
+		`x\" OR *:*`,
+		`x&rows=100000&q=*:*`,
+		`x#fragment`,
+		`{!lucene}name:y`,
+		"San\tJuan\n",
+	}
+	for _, city := range hostile {
+		if _, err := index.Search(context.Background(), city); err != nil {
+			t.Fatal(err)
+		}
+
+		if received.Get("q") != "*:*" || received.Get("rows") != "100" || len(received["q"]) != 1 || len(received["rows"]) != 1 {
+			t.Errorf("city %q changed the query parameters: %v", city, received)
+		}
+		if len(received["fq"]) != 1 || !strings.HasPrefix(received.Get("fq"), "city:") {
+			t.Fatalf("city %q: unexpected filters %v", city, received["fq"])
+		}
+		if got := unquotePhrase(t, strings.TrimPrefix(received.Get("fq"), "city:")); got != city {
+			t.Errorf("expected the filter to search %q literally, got %q", city, got)
+		}
+	}
+}
+
+func TestSolrIndexDocumentsFiltersAfterID(t *testing.T) {
+	var received url.Values
+	solr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.URL.Query()
+		io.WriteString(w, `{"response":{"numFound":0,"docs":[]}}`)
+	}))
+	defer solr.Close()
+
+	if _, err := NewSolrIndex(solr.URL).(ListableIndex).Documents(context.Background(), `abc"]`, 10); err != nil {
+		t.Fatal(err)
+	}
+	if received.Get("fq") != `id:{"abc\"]" TO *]` || received.Get("sort") != "id asc" || received.Get("rows") != "10" {
+		t.Fatalf("unexpected query %v", received)
+	}
+}
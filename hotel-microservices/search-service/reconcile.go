@@ -0,0 +1,302 @@
+package searchservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultReconcileInterval es cada cuánto se compara Solr con hotel-service
+const DefaultReconcileInterval = 10 * time.Minute
+
+// reconcilePageSize es el tamaño de página al recorrer hotel-service y Solr
+const reconcilePageSize = 500
+
+// reconcileWorkers es cuántas correcciones se aplican a la vez; alcanza para
+// que el índice las agrupe en batches sin abrir una goroutine por documento
+const reconcileWorkers = 16
+
+// ErrIndexNotListable es un SearchIndex que no implementa ListableIndex
+var ErrIndexNotListable = errors.New("search index does not support listing documents")
+
+// ReconcileReport es el resultado de una pasada de Reconcile
+type ReconcileReport struct {
+	// Checked son los hoteles de hotel-service comparados con el índice
+	Checked int `json:"checked"`
+	// Missing son hoteles que no estaban indexados
+	Missing int `json:"missing"`
+	// Stale son hoteles indexados con un updated_at anterior al de hotel-service
+	Stale int `json:"stale"`
+	// Orphaned son documentos de hoteles que ya no existen
+	Orphaned int `json:"orphaned"`
+	// Repaired y Failed cuentan las correcciones que se aplicaron y las que fallaron
+	Repaired int `json:"repaired"`
+	Failed   int `json:"failed"`
+	// Skipped son correcciones que ya no hacían falta al aplicarlas: un evento
+	// llegó mientras corría la pasada
+	Skipped    int       `json:"skipped"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// Drift es la cantidad de documentos que no coincidían con hotel-service
+func (r ReconcileReport) Drift() int {
+	return r.Missing + r.Stale + r.Orphaned
+}
+
+// ReconcileMetrics acumula las pasadas de Reconcile de esta instancia
+type ReconcileMetrics struct {
+	Runs     int              `json:"runs"`
+	Missing  int              `json:"missing_total"`
+	Stale    int              `json:"stale_total"`
+	Orphaned int              `json:"orphaned_total"`
+	Repaired int              `json:"repaired_total"`
+	Failed   int              `json:"failed_total"`
+	Skipped  int              `json:"skipped_total"`
+	Last     *ReconcileReport `json:"last,omitempty"`
+}
+
+// Reconcile compara el índice con hotel-service: indexa los hoteles que
+// faltan o quedaron viejos y borra los documentos de hoteles que ya no
+// existen. Repara lo que dejan los eventos perdidos. Las diferencias se
+// detectan con fotos tomadas al empezar, así que antes de cada corrección se
+// vuelve a leer el hotel en hotel-service y el documento indexado: no se pisa
+// un documento que un evento dejó igual o más nuevo ni se vuelve a agregar un
+// hotel borrado mientras tanto. Queda la ventana entre esa lectura y la
+// escritura; lo que se cuele ahí lo corrige la pasada siguiente.
+func (s *SearchService) Reconcile(ctx context.Context) (ReconcileReport, error) {
+	s.reconcileMu.Lock()
+	defer s.reconcileMu.Unlock()
+
+	report := ReconcileReport{StartedAt: time.Now().UTC()}
+	err := s.reconcile(ctx, &report)
+	report.FinishedAt = time.Now().UTC()
+	if err != nil {
+		report.Error = err.Error()
+	}
+	s.recordReconcile(report)
+
+	if drift := report.Drift(); drift > 0 || err != nil {
+		log.Printf("Reconciled search index: %d checked, %d missing, %d stale, %d orphaned, %d repaired, %d skipped, %d failed (error: %v)",
+			report.Checked, report.Missing, report.Stale, report.Orphaned, report.Repaired, report.Skipped, report.Failed, err)
+	}
+	return report, err
+}
+
+func (s *SearchService) reconcile(ctx context.Context, report *ReconcileReport) error {
+	index, ok := s.index.(ListableIndex)
+	if !ok {
+		return ErrIndexNotListable
+	}
+	// Durante un reindex el alias cambia de colección; lo que quede lo ve la próxima pasada
+	if s.ReindexStatus().State == ReindexRunning {
+		return ErrReindexRunning
+	}
+
+	// Las correcciones las aplica un pool de reconcileWorkers, para que el
+	// índice las agrupe en batches
+	repairs := make(chan reconcileRepair)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i := 0; i < reconcileWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for repair := range repairs {
+				applied, err := repair.fix()
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					report.Failed++
+					log.Printf("Error repairing hotel %s in search index: %v", repair.hotelID, err)
+				case applied:
+					report.Repaired++
+				default:
+					report.Skipped++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	defer func() {
+		close(repairs)
+		wg.Wait()
+	}()
+	repair := func(hotelID string, fix func() (bool, error)) {
+		repairs <- reconcileRepair{hotelID: hotelID, fix: fix}
+	}
+
+	indexed := map[string]time.Time{}
+	after := ""
+	for {
+		docs, err := index.Documents(ctx, after, reconcilePageSize)
+		if err != nil {
+			return fmt.Errorf("listing indexed hotels: %v", err)
+		}
+		if len(docs) == 0 {
+			break
+		}
+		for _, doc := range docs {
+			indexed[doc.ID] = doc.UpdatedAt
+		}
+		after = docs[len(docs)-1].ID
+	}
+
+	after = ""
+	for {
+		hotels, err := s.hotels.HotelPage(ctx, after, reconcilePageSize)
+		if err != nil {
+			return fmt.Errorf("fetching hotels after %q: %v", after, err)
+		}
+		if len(hotels) == 0 {
+			break
+		}
+
+		for _, hotel := range hotels {
+			report.Checked++
+			indexedAt, ok := indexed[hotel.ID]
+			delete(indexed, hotel.ID)
+
+			switch {
+			case !ok:
+				report.Missing++
+			case newerThan(hotel.UpdatedAt, indexedAt):
+				report.Stale++
+			default:
+				continue
+			}
+			hotelID := hotel.ID
+			repair(hotelID, func() (bool, error) { return s.repairHotel(ctx, index, hotelID) })
+		}
+		after = hotels[len(hotels)-1].ID
+	}
+
+	// Lo que queda indexado no está en hotel-service
+	for id := range indexed {
+		report.Orphaned++
+		id := id
+		repair(id, func() (bool, error) { return s.removeOrphan(ctx, index, id) })
+	}
+	return nil
+}
+
+// reconcileRepair es una corrección pendiente de Reconcile; fix devuelve
+// false si al aplicarla ya no hacía falta
+type reconcileRepair struct {
+	hotelID string
+	fix     func() (bool, error)
+}
+
+// repairHotel indexa el estado actual del hotel salvo que se haya borrado o
+// que el documento indexado ya sea igual o más nuevo
+func (s *SearchService) repairHotel(ctx context.Context, index ListableIndex, hotelID string) (bool, error) {
+	hotel, err := s.hotels.Hotel(ctx, hotelID)
+	if err == ErrHotelNotFound {
+		// Se borró después de la foto; el evento lo saca del índice
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	current, ok, err := index.Document(ctx, hotelID)
+	if err != nil {
+		return false, err
+	}
+	if ok && !newerThan(hotel.UpdatedAt, current.UpdatedAt) {
+		return false, nil
+	}
+	return true, index.Index(ctx, hotel)
+}
+
+// removeOrphan borra el documento si el hotel sigue sin existir en hotel-service
+func (s *SearchService) removeOrphan(ctx context.Context, index ListableIndex, hotelID string) (bool, error) {
+	_, err := s.hotels.Hotel(ctx, hotelID)
+	if err == nil {
+		return false, nil
+	}
+	if err != ErrHotelNotFound {
+		return false, err
+	}
+	return true, index.Delete(ctx, hotelID)
+}
+
+// newerThan compara updated_at con la precisión de Solr, que guarda milisegundos
+func newerThan(updatedAt, indexedAt time.Time) bool {
+	return updatedAt.Truncate(time.Millisecond).After(indexedAt.Truncate(time.Millisecond))
+}
+
+func (s *SearchService) recordReconcile(report ReconcileReport) {
+	s.reconcileStatsMu.Lock()
+	defer s.reconcileStatsMu.Unlock()
+
+	s.reconcileStats.Runs++
+	s.reconcileStats.Missing += report.Missing
+	s.reconcileStats.Stale += report.Stale
+	s.reconcileStats.Orphaned += report.Orphaned
+	s.reconcileStats.Repaired += report.Repaired
+	s.reconcileStats.Failed += report.Failed
+	s.reconcileStats.Skipped += report.Skipped
+	s.reconcileStats.Last = &report
+}
+
+// ReconcileMetrics devuelve una copia de las métricas de reconciliación
+func (s *SearchService) ReconcileMetrics() ReconcileMetrics {
+	s.reconcileStatsMu.Lock()
+	defer s.reconcileStatsMu.Unlock()
+
+	return s.reconcileStats
+}
+
+// StartReconciliation agenda una pasada de Reconcile por interval mientras
+// ctx siga vivo. Con Solr el índice es compartido, así que basta con que lo
+// haga una réplica: las demás se arrancan con interval 0, que no agenda nada.
+// Cada índice embebido, en cambio, lo tiene que reconciliar su instancia.
+func (s *SearchService) StartReconciliation(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		log.Printf("Periodic reconciliation is disabled on this instance")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.Reconcile(ctx); err != nil && !errors.Is(err, ErrReindexRunning) {
+					log.Printf("Error reconciling search index: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (s *SearchService) reconcileMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, s.ReconcileMetrics())
+}
+
+func (s *SearchService) runReconcile(c *gin.Context) {
+	report, err := s.Reconcile(c.Request.Context())
+	switch {
+	case errors.Is(err, ErrReindexRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrIndexNotListable):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "report": report})
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
@@ -0,0 +1,185 @@
+package searchservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// concurrencyIndex cuenta cuántos Index corren a la vez
+type concurrencyIndex struct {
+	ListableIndex
+
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (i *concurrencyIndex) Index(ctx context.Context, doc HotelDocument) error {
+	i.mu.Lock()
+	i.running++
+	if i.running > i.peak {
+		i.peak = i.running
+	}
+	i.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+	err := i.ListableIndex.Index(ctx, doc)
+
+	i.mu.Lock()
+	i.running--
+	i.mu.Unlock()
+	return err
+}
+
+func TestReconcileRepairsDrift(t *testing.T) {
+	service := newTestService(stubAvailability{})
+	ctx := context.Background()
+	updated := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	service.index.Index(ctx, HotelDocument{ID: "1", Name: "Plaza", City: "Rosario", UpdatedAt: updated})
+	service.index.Index(ctx, HotelDocument{ID: "2", Name: "Viejo", City: "Rosario", UpdatedAt: updated})
+	service.index.Index(ctx, HotelDocument{ID: "9", Name: "Borrado", City: "Rosario", UpdatedAt: updated})
+	service.hotels = &pagedHotels{hotels: []HotelDocument{
+		// Mongo devuelve el mismo instante con más precisión que Solr
+		{ID: "1", Name: "Plaza", City: "Rosario", UpdatedAt: updated.Add(300 * time.Microsecond)},
+		{ID: "2", Name: "Renovado", City: "Rosario", UpdatedAt: updated.Add(time.Hour)},
+		{ID: "3", Name: "Nuevo", City: "Rosario", UpdatedAt: updated},
+	}}
+
+	report, err := service.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 3 || report.Missing != 1 || report.Stale != 1 || report.Orphaned != 1 || report.Repaired != 3 || report.Failed != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	docs, _ := service.index.Search(ctx, "Rosario")
+	if len(docs) != 3 || docs[1].Name != "Renovado" || docs[2].ID != "3" {
+		t.Fatalf("unexpected documents after reconcile %+v", docs)
+	}
+
+	// Ya reconciliado, la segunda pasada no encuentra diferencias
+	if report, _ := service.Reconcile(ctx); report.Drift() != 0 {
+		t.Fatalf("expected no drift, got %+v", report)
+	}
+
+	req := newAdminRequest(t, "GET", "/admin/reconcile")
+	w := httptest.NewRecorder()
+	NewRouter(service).ServeHTTP(w, req)
+
+	var metrics ReconcileMetrics
+	json.Unmarshal(w.Body.Bytes(), &metrics)
+	if metrics.Runs != 2 || metrics.Missing != 1 || metrics.Orphaned != 1 || metrics.Last == nil || metrics.Last.Checked != 3 {
+		t.Fatalf("unexpected metrics %s", w.Body.String())
+	}
+}
+
+func TestReconcileKeepsNewerIndexedDocuments(t *testing.T) {
+	service := newTestService(stubAvailability{})
+	ctx := context.Background()
+	updated := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	// Un evento ya aplicó un cambio que la página de hotel-service todavía no tenía
+	service.index.Index(ctx, HotelDocument{ID: "1", Name: "Nuevo nombre", City: "Rosario", UpdatedAt: updated.Add(time.Minute)})
+	service.hotels = &pagedHotels{hotels: []HotelDocument{{ID: "1", Name: "Viejo nombre", City: "Rosario", UpdatedAt: updated}}}
+
+	report, err := service.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Drift() != 0 {
+		t.Fatalf("expected no drift, got %+v", report)
+	}
+
+	req := newAdminRequest(t, "POST", "/admin/reconcile")
+	w := httptest.NewRecorder()
+	NewRouter(service).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestReconcileBoundsConcurrentRepairs(t *testing.T) {
+	service := newTestService(stubAvailability{})
+	index := &concurrencyIndex{ListableIndex: service.index.(ListableIndex)}
+	service.index = index
+
+	source := &pagedHotels{}
+	for i := 0; i < reconcileWorkers*5; i++ {
+		source.hotels = append(source.hotels, HotelDocument{ID: fmt.Sprintf("%03d", i), City: "Rosario"})
+	}
+	service.hotels = source
+
+	report, err := service.Reconcile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Missing != len(source.hotels) || report.Repaired != len(source.hotels) {
+		t.Fatalf("expected every hotel to be repaired, got %+v", report)
+	}
+	if index.peak > reconcileWorkers {
+		t.Fatalf("expected at most %d concurrent repairs, got %d", reconcileWorkers, index.peak)
+	}
+}
+
+// changedHotels es un HotelSource cuyas páginas quedaron viejas: Hotel
+// devuelve el estado actual de hotel-service
+type changedHotels struct {
+	pagedHotels
+	current map[string]HotelDocument
+}
+
+func (c *changedHotels) Hotel(ctx context.Context, hotelID string) (HotelDocument, error) {
+	hotel, ok := c.current[hotelID]
+	if !ok {
+		return HotelDocument{}, ErrHotelNotFound
+	}
+	return hotel, nil
+}
+
+func TestReconcileDoesNotUndoEventsAppliedDuringThePass(t *testing.T) {
+	service := newTestService(stubAvailability{})
+	ctx := context.Background()
+	updated := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	service.index.Index(ctx, HotelDocument{ID: "1", Name: "Plaza", City: "Rosario", UpdatedAt: updated})
+	service.index.Index(ctx, HotelDocument{ID: "9", Name: "Ribera", City: "Rosario", UpdatedAt: updated})
+	source := &changedHotels{pagedHotels: pagedHotels{hotels: []HotelDocument{
+		{ID: "1", Name: "Plaza renovado", City: "Rosario", UpdatedAt: updated.Add(time.Minute)},
+		{ID: "2", Name: "Nuevo", City: "Rosario", UpdatedAt: updated},
+	}}}
+	service.hotels = source
+
+	// Después de la foto del índice llegan eventos: el hotel 1 cambió otra vez,
+	// el 2 se borró y el 9, que la foto de hotel-service no tenía, se actualizó
+	source.onPage = func(after string) {
+		if after != "" {
+			return
+		}
+		latest := HotelDocument{ID: "1", Name: "Plaza final", City: "Rosario", UpdatedAt: updated.Add(time.Hour)}
+		ribera := HotelDocument{ID: "9", Name: "Ribera", City: "Rosario", UpdatedAt: updated.Add(time.Hour)}
+		source.current = map[string]HotelDocument{"1": latest, "9": ribera}
+		service.index.Index(ctx, latest)
+		service.index.Index(ctx, ribera)
+	}
+
+	report, err := service.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Stale != 1 || report.Missing != 1 || report.Orphaned != 1 || report.Skipped != 3 || report.Repaired != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	docs, _ := service.index.Search(ctx, "Rosario")
+	if len(docs) != 2 || docs[0].Name != "Plaza final" || docs[1].ID != "9" {
+		t.Fatalf("expected the events to win over the pass, got %+v", docs)
+	}
+}
@@ -0,0 +1,277 @@
+package searchservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"events"
+)
+
+// reindexPageSize es cuántos hoteles se piden a hotel-service y se cargan en Solr por vez
+const reindexPageSize = 500
+
+// Estados de ReindexStatus
+const (
+	ReindexIdle    = "idle"
+	ReindexRunning = "running"
+	ReindexDone    = "done"
+	ReindexFailed  = "failed"
+)
+
+var (
+	// ErrReindexRunning indica que esta instancia ya está reconstruyendo el índice
+	ErrReindexRunning = errors.New("reindex already running")
+	// ErrIndexNotRebuildable es un SearchIndex que no implementa RebuildableIndex
+	ErrIndexNotRebuildable = errors.New("search index does not support reindexing")
+)
+
+// ReindexStatus es el estado del último reindex de esta instancia
+type ReindexStatus struct {
+	State      string     `json:"state"`
+	Collection string     `json:"collection,omitempty"`
+	Indexed    int        `json:"indexed"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// EnsureSearchAlias crea el alias de búsqueda si no existe, reintentando con
+// backoff mientras Solr no responda o hasta que se cancele ctx. Si lo creó,
+// p.ej. en el primer arranque o al pasar del core standalone a modo cloud,
+// la colección nueva está vacía y se llena con un reindex.
+func (s *SearchService) EnsureSearchAlias(ctx context.Context) {
+	rebuildable, ok := s.index.(RebuildableIndex)
+	if !ok {
+		return
+	}
+
+	backoff := time.Second
+	for {
+		created, err := rebuildable.EnsureAlias(ctx)
+		if err == nil {
+			if created {
+				log.Printf("Search alias created, reindexing hotels")
+				if err := s.StartReindex(); err != nil {
+					log.Printf("Error starting initial reindex: %v", err)
+				}
+			}
+			return
+		}
+		log.Printf("Error preparing search alias, retrying in %s: %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+	}
+}
+
+// ReindexStatus devuelve una copia del estado del reindex
+func (s *SearchService) ReindexStatus() ReindexStatus {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+
+	status := s.reindex
+	if status.State == "" {
+		status.State = ReindexIdle
+	}
+	return status
+}
+
+// StartReindex lanza Reindex en segundo plano; el avance se ve en ReindexStatus
+func (s *SearchService) StartReindex() error {
+	index, collection, err := s.beginReindex()
+	if err != nil {
+		return err
+	}
+	go s.runReindex(context.Background(), index, collection)
+	return nil
+}
+
+// Reindex carga todos los hoteles de hotel-service en una colección nueva y
+// recién al final le apunta el alias, así las búsquedas nunca ven un índice a
+// medio armar. Los cambios que llegan mientras tanto se siguen aplicando al
+// índice actual y, vía ObserveHotelEvent, se repiten en la colección nueva
+// antes del cambio de alias. Un evento que se aplique a la colección vieja
+// justo durante el cambio puede perderse; eso lo corrige la reconciliación.
+func (s *SearchService) Reindex(ctx context.Context) error {
+	index, collection, err := s.beginReindex()
+	if err != nil {
+		return err
+	}
+	return s.runReindex(ctx, index, collection)
+}
+
+func (s *SearchService) beginReindex() (RebuildableIndex, string, error) {
+	index, ok := s.index.(RebuildableIndex)
+	if !ok {
+		return nil, "", ErrIndexNotRebuildable
+	}
+
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+
+	if s.reindex.State == ReindexRunning {
+		return nil, "", ErrReindexRunning
+	}
+
+	now := time.Now().UTC()
+	collection := newCollectionName()
+	s.reindex = ReindexStatus{State: ReindexRunning, Collection: collection, StartedAt: &now}
+	s.pending = map[string]*HotelDocument{}
+	return index, collection, nil
+}
+
+func (s *SearchService) runReindex(ctx context.Context, index RebuildableIndex, collection string) error {
+	log.Printf("Reindexing hotels into %s", collection)
+
+	err := s.loadCollection(ctx, index, collection)
+	if err == nil {
+		err = s.publishCollection(ctx, index, collection)
+	}
+
+	s.reindexMu.Lock()
+	now := time.Now().UTC()
+	s.reindex.FinishedAt = &now
+	s.pending = nil
+	if err != nil {
+		s.reindex.State = ReindexFailed
+		s.reindex.Error = err.Error()
+	} else {
+		s.reindex.State = ReindexDone
+	}
+	s.reindexMu.Unlock()
+
+	if err != nil {
+		log.Printf("Reindex into %s failed: %v", collection, err)
+		if dropErr := index.DropCollection(ctx, collection); dropErr != nil {
+			log.Printf("Error dropping collection %s: %v", collection, dropErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// loadCollection crea collection y le carga todos los hoteles, página por página
+func (s *SearchService) loadCollection(ctx context.Context, index RebuildableIndex, collection string) error {
+	if err := index.CreateCollection(ctx, collection); err != nil {
+		return fmt.Errorf("creating collection: %v", err)
+	}
+
+	after := ""
+	for {
+		hotels, err := s.hotels.HotelPage(ctx, after, reindexPageSize)
+		if err != nil {
+			return fmt.Errorf("fetching hotels after %q: %v", after, err)
+		}
+		if len(hotels) == 0 {
+			return nil
+		}
+		if err := index.IndexInto(ctx, collection, hotels); err != nil {
+			return fmt.Errorf("indexing hotels: %v", err)
+		}
+
+		s.reindexMu.Lock()
+		s.reindex.Indexed += len(hotels)
+		s.reindexMu.Unlock()
+
+		after = hotels[len(hotels)-1].ID
+	}
+}
+
+// publishCollection repite en collection los cambios observados durante la
+// carga y le apunta el alias. Tiene tomado reindexMu para que ObserveHotelEvent
+// no registre cambios que ya no se van a aplicar.
+func (s *SearchService) publishCollection(ctx context.Context, index RebuildableIndex, collection string) error {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+
+	for id, doc := range s.pending {
+		var err error
+		if doc == nil {
+			err = index.DeleteFrom(ctx, collection, id)
+		} else {
+			err = index.IndexInto(ctx, collection, []HotelDocument{*doc})
+		}
+		if err != nil {
+			return fmt.Errorf("replaying change of hotel %s: %v", id, err)
+		}
+	}
+
+	if err := index.Commit(ctx, collection); err != nil {
+		return fmt.Errorf("committing collection: %v", err)
+	}
+
+	previous, err := index.SwapAlias(ctx, collection)
+	if err != nil {
+		return fmt.Errorf("swapping alias: %v", err)
+	}
+	log.Printf("Search alias now points to %s (%d hotels)", collection, s.reindex.Indexed)
+
+	if previous != "" && previous != collection {
+		if err := index.DropCollection(ctx, previous); err != nil {
+			log.Printf("Error dropping previous collection %s: %v", previous, err)
+		}
+	}
+	return nil
+}
+
+// ObserveHotelEvent registra un evento hotel.* si hay un reindex en curso. Se
+// consume con ConsumeBroadcast porque el evento puede haberlo indexado otra
+// instancia; los que no son de hoteles se ignoran.
+func (s *SearchService) ObserveHotelEvent(body []byte) error {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+
+	if s.pending == nil {
+		return nil
+	}
+
+	envelope, err := events.Decode(body)
+	if err != nil {
+		return err
+	}
+
+	switch envelope.Type {
+	case events.HotelCreated, events.HotelUpdated:
+		var hotel events.HotelPayload
+		if err := envelope.DecodePayload(&hotel); err != nil {
+			return err
+		}
+		doc := hotelDocumentFrom(hotel)
+		s.pending[hotel.ID] = &doc
+	case events.HotelDeleted:
+		var hotel events.HotelDeletedPayload
+		if err := envelope.DecodePayload(&hotel); err != nil {
+			return err
+		}
+		s.pending[hotel.ID] = nil
+	}
+	return nil
+}
+
+func (s *SearchService) reindexStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, s.ReindexStatus())
+}
+
+func (s *SearchService) startReindex(c *gin.Context) {
+	switch err := s.StartReindex(); {
+	case errors.Is(err, ErrReindexRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrIndexNotRebuildable):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusAccepted, s.ReindexStatus())
+	}
+}
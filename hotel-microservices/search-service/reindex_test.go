@@ -0,0 +1,168 @@
+package searchservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"events"
+)
+
+// pagedHotels es un HotelSource en memoria; onPage corre antes de cada página
+type pagedHotels struct {
+	hotels []HotelDocument
+	onPage func(after string)
+}
+
+func (p *pagedHotels) HotelPage(ctx context.Context, after string, limit int) ([]HotelDocument, error) {
+	if p.onPage != nil {
+		p.onPage(after)
+	}
+	page := []HotelDocument{}
+	for _, hotel := range p.hotels {
+		if hotel.ID > after && len(page) < limit {
+			page = append(page, hotel)
+		}
+	}
+	return page, nil
+}
+
+func (p *pagedHotels) Hotel(ctx context.Context, hotelID string) (HotelDocument, error) {
+	for _, hotel := range p.hotels {
+		if hotel.ID == hotelID {
+			return hotel, nil
+		}
+	}
+	return HotelDocument{}, ErrHotelNotFound
+}
+
+func TestReindexSwapsAliasToRebuiltCollection(t *testing.T) {
+	service := newTestService(stubAvailability{})
+	ctx := context.Background()
+	service.index.Index(ctx, HotelDocument{ID: "stale", City: "Rosario"})
+
+	source := &pagedHotels{hotels: []HotelDocument{{ID: "1", City: "Rosario"}, {ID: "2", City: "Rosario"}}}
+	service.hotels = source
+
+	// Los cambios que llegan durante la carga se repiten en la colección nueva
+	source.onPage = func(after string) {
+		if after != "" {
+			return
+		}
+		service.ObserveHotelEvent(publishedEvent(t, events.HotelCreated, events.HotelPayload{ID: "3", City: "Rosario"}))
+		service.ObserveHotelEvent(publishedEvent(t, events.HotelDeleted, events.HotelDeletedPayload{ID: "2"}))
+	}
+
+	if err := service.Reindex(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	docs, _ := service.index.Search(ctx, "Rosario")
+	if len(docs) != 2 || docs[0].ID != "1" || docs[1].ID != "3" {
+		t.Fatalf("unexpected documents after reindex %+v", docs)
+	}
+
+	status := service.ReindexStatus()
+	if status.State != ReindexDone || status.Indexed != 2 || status.FinishedAt == nil {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	memory := service.index.(*memoryIndex)
+	if len(memory.collections) != 1 || memory.alias != status.Collection {
+		t.Fatalf("expected only %s to remain, got alias %s and %d collections", status.Collection, memory.alias, len(memory.collections))
+	}
+}
+
+// freshIndex es un índice en memoria que recién crea su alias, como Solr en el primer arranque
+type freshIndex struct {
+	*memoryIndex
+}
+
+func (f freshIndex) EnsureAlias(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+func TestEnsureSearchAliasReindexesNewAlias(t *testing.T) {
+	service := newTestService(stubAvailability{})
+	service.index = freshIndex{service.index.(*memoryIndex)}
+	service.hotels = &pagedHotels{hotels: []HotelDocument{{ID: "1", City: "Rosario"}}}
+
+	service.EnsureSearchAlias(context.Background())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for service.ReindexStatus().State != ReindexDone {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the new alias to be reindexed, got %+v", service.ReindexStatus())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if docs, _ := service.index.Search(context.Background(), "Rosario"); len(docs) != 1 {
+		t.Fatalf("expected the hotel to be searchable, got %+v", docs)
+	}
+}
+
+func TestReindexRejectsConcurrentRuns(t *testing.T) {
+	service := newTestService(stubAvailability{})
+	source := &pagedHotels{hotels: []HotelDocument{{ID: "1", City: "Rosario"}}}
+	service.hotels = source
+
+	router := NewRouter(service)
+	source.onPage = func(after string) {
+		req := newAdminRequest(t, "POST", "/admin/reindex")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusConflict {
+			t.Errorf("expected 409 while reindexing, got %d", w.Code)
+		}
+	}
+
+	if err := service.Reindex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.Reindex(context.Background()); errors.Is(err, ErrReindexRunning) {
+		t.Fatal("expected a finished reindex to allow another one")
+	}
+}
+
+func TestSolrIndexSwapAlias(t *testing.T) {
+	var actions []url.Values
+	solr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/solr/admin/collections" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		actions = append(actions, r.URL.Query())
+		if r.URL.Query().Get("action") == "LISTALIASES" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"aliases": map[string]string{"hotels": "hotels_1"}})
+		}
+	}))
+	defer solr.Close()
+
+	index := NewSolrIndex(solr.URL).(RebuildableIndex)
+	previous, err := index.SwapAlias(context.Background(), "hotels_2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous != "hotels_1" {
+		t.Fatalf("expected previous collection hotels_1, got %s", previous)
+	}
+
+	alias := actions[len(actions)-1]
+	if alias.Get("action") != "CREATEALIAS" || alias.Get("name") != "hotels" || alias.Get("collections") != "hotels_2" {
+		t.Fatalf("unexpected alias request %v", alias)
+	}
+
+	// Con el alias ya creado no hace nada
+	actions = nil
+	if created, err := index.EnsureAlias(context.Background()); created || err != nil {
+		t.Fatalf("expected the existing alias to be kept, got %v, %v", created, err)
+	}
+	if len(actions) != 1 {
+		t.Fatalf("expected only LISTALIASES, got %v", actions)
+	}
+}
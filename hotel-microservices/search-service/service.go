@@ -0,0 +1,442 @@
+package searchservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/streadway/amqp"
+
+	"events"
+	"events/amqpx"
+	"events/readiness"
+)
+
+type Hotel struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	City        string   `json:"city"`
+	Address     string   `json:"address"`
+	Amenities   []string `json:"amenities"`
+	Images      []string `json:"images"`
+	Thumbnail   string   `json:"thumbnail"`
+	AmadeusID   string   `json:"amadeus_id"`
+	Available   bool     `json:"available"`
+}
+
+type SearchResult struct {
+	Hotels []Hotel `json:"hotels"`
+	Total  int     `json:"total"`
+}
+
+type SearchService struct {
+	index        SearchIndex
+	availability AvailabilityChecker
+	hotels       HotelSource
+	deadLetters  DeadLetterQueue
+	// maxAttempts y retryBackoff acotan los reintentos de ProcessHotelUpdate
+	maxAttempts  int
+	retryBackoff time.Duration
+
+	readiness readiness.Checks
+
+	reindexMu sync.Mutex
+	reindex   ReindexStatus
+	// pending guarda, mientras corre un reindex, el último estado de cada hotel
+	// que cambió (nil si se borró); es nil si no hay reindex en curso
+	pending map[string]*HotelDocument
+
+	// reconcileMu evita dos Reconcile a la vez en la instancia
+	reconcileMu      sync.Mutex
+	reconcileStatsMu sync.Mutex
+	reconcileStats   ReconcileMetrics
+
+	// jwtSecret valida los tokens de admin de los endpoints /admin
+	jwtSecret string
+	// updatesQueue es la cola de la que ListenForHotelUpdates consume
+	updatesQueue string
+}
+
+type HotelDocument struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	City        string   `json:"city"`
+	Address     string   `json:"address"`
+	Amenities   []string `json:"amenities"`
+	Images      []string `json:"images"`
+	Thumbnail   string   `json:"thumbnail"`
+	AmadeusID   string   `json:"amadeus_id"`
+	// UpdatedAt es el updated_at de hotel-service, con el que se reconcilia
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SolrResponse struct {
+	Response struct {
+		NumFound int             `json:"numFound"`
+		Docs     []HotelDocument `json:"docs"`
+	} `json:"response"`
+}
+
+// NewSearchService recibe en hotelServiceURL la colección de hoteles de
+// hotel-service, p.ej. http://nginx/api/hotels
+func NewSearchService(index SearchIndex, availability AvailabilityChecker, deadLetters DeadLetterQueue, hotelServiceURL string) *SearchService {
+	return &SearchService{
+		index:        index,
+		availability: availability,
+		hotels:       NewHTTPHotelSource(hotelServiceURL),
+		deadLetters:  deadLetters,
+		maxAttempts:  DefaultMaxAttempts,
+		retryBackoff: DefaultRetryBackoff,
+		updatesQueue: hotelUpdatesQueue,
+	}
+}
+
+// AddReadinessCheck suma una dependencia sin la que el servicio no está listo
+// para recibir tráfico; GET /ready la consulta en cada pedido
+func (s *SearchService) AddReadinessCheck(name string, check func() error) {
+	s.readiness.Add(name, check)
+}
+
+func NewRouter(service *SearchService) *gin.Engine {
+	router := gin.Default()
+
+	// CORS middleware mejorado
+	router.Use(func(c *gin.Context) {
+		origin := c.Request.Header.Get("Origin")
+		c.Header("Access-Control-Allow-Origin", origin)
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept, Origin, User-Agent, Cache-Control, Keep-Alive")
+		c.Header("Access-Control-Allow-Credentials", "true")
+		c.Header("Access-Control-Max-Age", "86400")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	})
+
+	router.GET("/search", service.searchHotels)
+	router.GET("/ready", gin.WrapH(&service.readiness))
+
+	// Operación interna: nginx no expone /admin, pero dentro de la red de
+	// docker-compose igual se pide un token de admin
+	admin := router.Group("/admin", service.adminMiddleware())
+	admin.GET("/dead-letters", service.listDeadLetters)
+	admin.POST("/dead-letters/replay", service.replayDeadLetters)
+	admin.GET("/reindex", service.reindexStatus)
+	admin.POST("/reindex", service.startReindex)
+	admin.GET("/reconcile", service.reconcileMetrics)
+	admin.POST("/reconcile", service.runReconcile)
+
+	return router
+}
+
+func (s *SearchService) searchHotels(c *gin.Context) {
+	city := c.Query("city")
+	checkIn := c.Query("check_in")
+	checkOut := c.Query("check_out")
+
+	if city == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "City parameter is required"})
+		return
+	}
+
+	docs, err := s.index.Search(c.Request.Context(), city)
+	if err != nil {
+		log.Printf("Error querying search index: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search service unavailable"})
+		return
+	}
+
+	// Convert indexed docs to Hotel structs and check availability concurrently
+	hotels := make([]Hotel, len(docs))
+	var wg sync.WaitGroup
+
+	for i, doc := range docs {
+		wg.Add(1)
+		go func(index int, indexed HotelDocument) {
+			defer wg.Done()
+
+			hotel := Hotel{
+				ID:          indexed.ID,
+				Name:        indexed.Name,
+				Description: indexed.Description,
+				City:        indexed.City,
+				Address:     indexed.Address,
+				Amenities:   indexed.Amenities,
+				Images:      indexed.Images,
+				Thumbnail:   indexed.Thumbnail,
+				AmadeusID:   indexed.AmadeusID,
+				Available:   true, // Default to true, check availability if dates provided
+			}
+
+			// Check availability if dates are provided
+			if checkIn != "" && checkOut != "" {
+				hotel.Available = s.availability.CheckAvailability(indexed.ID, checkIn, checkOut)
+			}
+
+			hotels[index] = hotel
+		}(i, doc)
+	}
+
+	wg.Wait()
+
+	// Filter only available hotels if dates were provided
+	if checkIn != "" && checkOut != "" {
+		var availableHotels []Hotel
+		for _, hotel := range hotels {
+			if hotel.Available {
+				availableHotels = append(availableHotels, hotel)
+			}
+		}
+		hotels = availableHotels
+	}
+
+	result := SearchResult{
+		Hotels: hotels,
+		Total:  len(hotels),
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SetHotelUpdatesQueue cambia la cola de la que se consumen los eventos de
+// hoteles; tiene que ser la que declara HotelUpdatesTopology
+func (s *SearchService) SetHotelUpdatesQueue(queue string) {
+	s.updatesQueue = queue
+}
+
+// HotelUpdatesTopology declara el exchange de hoteles, la cola queue
+// bindeada a hotel.* y la dead-letter queue. Es la topología que RabbitMQ
+// vuelve a declarar en cada reconexión.
+func HotelUpdatesTopology(queue string) func(channel *amqp.Channel) error {
+	return func(channel *amqp.Channel) error {
+		return declareHotelUpdatesTopology(channel, queue)
+	}
+}
+
+func declareHotelUpdatesTopology(channel *amqp.Channel, queue string) error {
+	err := channel.ExchangeDeclare(
+		hotelEventsExchange,
+		"topic",
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	q, err := channel.QueueDeclare(
+		queue,
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := channel.QueueBind(q.Name, hotelEventsBindingKey, hotelEventsExchange, false, nil); err != nil {
+		return err
+	}
+
+	_, err = channel.QueueDeclare(hotelUpdatesDeadLetterQueue, true, false, false, false, nil)
+	return err
+}
+
+// ListenForHotelUpdates consume la cola de eventos de hoteles hasta que se cancele ctx. Si se
+// cae la conexión espera a que RabbitMQ reconecte y vuelve a consumir; los
+// mensajes sin ack se reentregan.
+func (s *SearchService) ListenForHotelUpdates(ctx context.Context, rabbit *amqpx.RabbitMQ) {
+	for {
+		channel, err := rabbit.WaitChannel(ctx)
+		if err != nil {
+			return
+		}
+
+		if err := s.consumeHotelUpdates(channel); err != nil {
+			log.Printf("Error consuming hotel updates: %v", err)
+		} else {
+			log.Printf("Hotel updates consumer stopped, waiting for RabbitMQ")
+		}
+		channel.Close()
+	}
+}
+
+// consumeHotelUpdates usa ack manual: cada mensaje se confirma recién cuando
+// el índice aplicó el cambio o quedó en la dead-letter queue. Vuelve cuando
+// se cierra el canal y terminaron los mensajes en curso.
+func (s *SearchService) consumeHotelUpdates(channel *amqp.Channel) error {
+	if err := channel.Qos(DefaultBatchSize, 0, false); err != nil {
+		return err
+	}
+
+	msgs, err := channel.Consume(
+		s.updatesQueue,
+		"",
+		false,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	s.processHotelUpdates(msgs)
+	return nil
+}
+
+// hotelUpdateShards es cuántos hoteles distintos se procesan a la vez
+const hotelUpdateShards = 32
+
+// processHotelUpdates lee los mensajes en el orden del broker y los reparte
+// por id de hotel entre hotelUpdateShards workers. Los eventos de un mismo
+// hotel se aplican de a uno y en orden, así un hotel.updated no revive un
+// hotel.deleted anterior; los de hoteles distintos van en paralelo para que
+// el índice los pueda agrupar en batches.
+func (s *SearchService) processHotelUpdates(msgs <-chan amqp.Delivery) {
+	shards := make([]chan amqp.Delivery, hotelUpdateShards)
+	var wg sync.WaitGroup
+	for i := range shards {
+		// Con el prefetch nunca hay más de DefaultBatchSize mensajes sin ack,
+		// así que enviar a un shard no bloquea la lectura
+		shards[i] = make(chan amqp.Delivery, DefaultBatchSize)
+		wg.Add(1)
+		go func(shard <-chan amqp.Delivery) {
+			defer wg.Done()
+			for msg := range shard {
+				if err := s.ProcessHotelUpdate(msg.Body); err != nil {
+					log.Printf("Error dead-lettering hotel update, requeueing: %v", err)
+					msg.Nack(false, true)
+					continue
+				}
+				msg.Ack(false)
+			}
+		}(shards[i])
+	}
+
+	for msg := range msgs {
+		shards[hotelUpdateShard(msg.Body)] <- msg
+	}
+	for _, shard := range shards {
+		close(shard)
+	}
+	wg.Wait()
+}
+
+// hotelUpdateShard elige el worker del hotel del mensaje; los malformados,
+// que no llegan al índice, van al primero
+func hotelUpdateShard(body []byte) int {
+	envelope, err := events.Decode(body)
+	if err != nil {
+		return 0
+	}
+	var hotel struct {
+		ID string `json:"id"`
+	}
+	if err := envelope.DecodePayload(&hotel); err != nil {
+		return 0
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(hotel.ID))
+	return int(hash.Sum32() % hotelUpdateShards)
+}
+
+// hotelEventsExchange es el exchange topic donde hotel-service publica los
+// cambios de hoteles, con el tipo del evento como routing key
+const (
+	hotelEventsExchange   = "hotel_events.topic"
+	hotelEventsBindingKey = "hotel.*"
+)
+
+// hotelUpdatesQueue es la cola durable del grupo search-service: la comparten
+// todas las instancias, así cada cambio se indexa una sola vez en Solr
+const hotelUpdatesQueue = "hotel_updates"
+
+// HotelUpdatesQueue es la cola de eventos de hoteles de instance. Sin
+// instance es la cola compartida, la que sirve con Solr; con el índice
+// embebido cada instancia necesita todos los eventos, así que usa una cola
+// durable propia que conserva los eventos mientras la instancia no está.
+func HotelUpdatesQueue(instance string) string {
+	if instance == "" {
+		return hotelUpdatesQueue
+	}
+	return hotelUpdatesQueue + "." + instance
+}
+
+// ErrMalformedHotelUpdate indica un mensaje que no tiene sentido reintentar
+var ErrMalformedHotelUpdate = errors.New("malformed hotel update")
+
+// HandleHotelUpdate aplica al índice un evento hotel.* de hotel-service
+func (s *SearchService) HandleHotelUpdate(body []byte) error {
+	envelope, err := events.Decode(body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedHotelUpdate, err)
+	}
+
+	ctx := context.Background()
+	switch envelope.Type {
+	case events.HotelCreated, events.HotelUpdated:
+		var hotel events.HotelPayload
+		if err := envelope.DecodePayload(&hotel); err != nil {
+			return fmt.Errorf("%w: %v", ErrMalformedHotelUpdate, err)
+		}
+		if hotel.ID == "" {
+			return fmt.Errorf("%w: missing hotel id", ErrMalformedHotelUpdate)
+		}
+		if err := s.index.Index(ctx, hotelDocumentFrom(hotel)); err != nil {
+			return fmt.Errorf("indexing hotel %s: %v", hotel.ID, err)
+		}
+		log.Printf("Hotel indexed successfully: %s (correlation %s)", hotel.Name, envelope.CorrelationID)
+	case events.HotelDeleted:
+		var hotel events.HotelDeletedPayload
+		if err := envelope.DecodePayload(&hotel); err != nil {
+			return fmt.Errorf("%w: %v", ErrMalformedHotelUpdate, err)
+		}
+		if hotel.ID == "" {
+			return fmt.Errorf("%w: missing hotel id", ErrMalformedHotelUpdate)
+		}
+		if err := s.index.Delete(ctx, hotel.ID); err != nil {
+			return fmt.Errorf("deleting hotel %s from index: %v", hotel.ID, err)
+		}
+		log.Printf("Hotel deleted from index: %s (correlation %s)", hotel.ID, envelope.CorrelationID)
+	default:
+		return fmt.Errorf("%w: unexpected event type %q", ErrMalformedHotelUpdate, envelope.Type)
+	}
+
+	return nil
+}
+
+// hotelDocumentFrom deja del hotel sólo lo que se indexa en Solr
+func hotelDocumentFrom(hotel events.HotelPayload) HotelDocument {
+	return HotelDocument{
+		ID:          hotel.ID,
+		Name:        hotel.Name,
+		Description: hotel.Description,
+		City:        hotel.City,
+		Address:     hotel.Address,
+		Amenities:   hotel.Amenities,
+		Images:      hotel.Images,
+		Thumbnail:   hotel.Thumbnail,
+		AmadeusID:   hotel.AmadeusID,
+		UpdatedAt:   hotel.UpdatedAt,
+	}
+}
@@ -0,0 +1,324 @@
+package searchservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/streadway/amqp"
+
+	"events"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type stubAvailability map[string]bool
+
+func (s stubAvailability) CheckAvailability(hotelID, checkIn, checkOut string) bool {
+	return s[hotelID]
+}
+
+const testJWTSecret = "test-secret"
+
+func newTestService(availability AvailabilityChecker) *SearchService {
+	return &SearchService{
+		index:        NewMemoryIndex(),
+		availability: availability,
+		deadLetters:  NewMemoryDeadLetterQueue(),
+		maxAttempts:  DefaultMaxAttempts,
+		jwtSecret:    testJWTSecret,
+	}
+}
+
+// signToken firma un token como los de user-service
+func signToken(t *testing.T, secret string, isAdmin bool) string {
+	t.Helper()
+
+	claims := AdminClaims{
+		UserID:  1,
+		IsAdmin: isAdmin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// newAdminRequest arma un pedido a /admin con un token de admin válido
+func newAdminRequest(t *testing.T, method, path string) *http.Request {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, testJWTSecret, true))
+	return req
+}
+
+func search(t *testing.T, router http.Handler, query string) (int, SearchResult) {
+	t.Helper()
+
+	req := httptest.NewRequest("GET", "/search?"+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var result SearchResult
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code, result
+}
+
+func TestSearchRequiresCity(t *testing.T) {
+	router := NewRouter(newTestService(stubAvailability{}))
+
+	if code, _ := search(t, router, ""); code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", code)
+	}
+}
+
+func TestSearchByCity(t *testing.T) {
+	service := newTestService(stubAvailability{})
+	ctx := context.Background()
+	service.index.Index(ctx, HotelDocument{ID: "1", Name: "Plaza", City: "Mendoza"})
+	service.index.Index(ctx, HotelDocument{ID: "2", Name: "Andes", City: "Mendoza"})
+	service.index.Index(ctx, HotelDocument{ID: "3", Name: "Puerto", City: "Ushuaia"})
+	router := NewRouter(service)
+
+	code, result := search(t, router, "city=Mendoza")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if result.Total != 2 {
+		t.Fatalf("expected 2 hotels, got %d", result.Total)
+	}
+	for _, hotel := range result.Hotels {
+		if !hotel.Available {
+			t.Errorf("hotel %s should default to available without dates", hotel.ID)
+		}
+	}
+}
+
+func TestSearchFiltersUnavailableHotelsWhenDatesGiven(t *testing.T) {
+	service := newTestService(stubAvailability{"1": true, "2": false})
+	ctx := context.Background()
+	service.index.Index(ctx, HotelDocument{ID: "1", City: "Mendoza"})
+	service.index.Index(ctx, HotelDocument{ID: "2", City: "Mendoza"})
+	router := NewRouter(service)
+
+	code, result := search(t, router, "city=Mendoza&check_in=2026-01-10&check_out=2026-01-12")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if result.Total != 1 || result.Hotels[0].ID != "1" {
+		t.Fatalf("expected only hotel 1, got %+v", result.Hotels)
+	}
+}
+
+// Los hotel_updates sin sobre que quedaron en la cola se siguen aplicando
+func TestHandleHotelUpdate(t *testing.T) {
+	service := newTestService(stubAvailability{})
+	ctx := context.Background()
+
+	created := `{"action":"created","hotel":{"id":"abc","name":"Plaza","city":"Mendoza","pricePerNight":120}}`
+	if err := service.HandleHotelUpdate([]byte(created)); err != nil {
+		t.Fatal(err)
+	}
+	docs, _ := service.index.Search(ctx, "Mendoza")
+	if len(docs) != 1 || docs[0].Name != "Plaza" {
+		t.Fatalf("expected hotel to be indexed, got %+v", docs)
+	}
+
+	updated := `{"action":"updated","hotel":{"id":"abc","name":"Plaza Hotel","city":"Mendoza"}}`
+	if err := service.HandleHotelUpdate([]byte(updated)); err != nil {
+		t.Fatal(err)
+	}
+	docs, _ = service.index.Search(ctx, "Mendoza")
+	if len(docs) != 1 || docs[0].Name != "Plaza Hotel" {
+		t.Fatalf("expected hotel to be re-indexed, got %+v", docs)
+	}
+
+	deleted := `{"action":"deleted","hotel":{"id":"abc"}}`
+	if err := service.HandleHotelUpdate([]byte(deleted)); err != nil {
+		t.Fatal(err)
+	}
+	docs, _ = service.index.Search(ctx, "Mendoza")
+	if len(docs) != 0 {
+		t.Fatalf("expected hotel to be removed, got %+v", docs)
+	}
+}
+
+func TestHandleHotelUpdateEnvelopes(t *testing.T) {
+	service := newTestService(stubAvailability{})
+	ctx := context.Background()
+
+	created, _ := events.New(ctx, events.HotelCreated, "hotel-service", events.HotelPayload{ID: "abc", Name: "Plaza", City: "Mendoza", TotalRooms: 10})
+	body, _ := json.Marshal(created)
+	if err := service.HandleHotelUpdate(body); err != nil {
+		t.Fatal(err)
+	}
+	if docs, _ := service.index.Search(ctx, "Mendoza"); len(docs) != 1 || docs[0].Name != "Plaza" {
+		t.Fatalf("expected hotel to be indexed, got %+v", docs)
+	}
+
+	deleted, _ := events.New(ctx, events.HotelDeleted, "hotel-service", events.HotelDeletedPayload{ID: "abc"})
+	body, _ = json.Marshal(deleted)
+	if err := service.HandleHotelUpdate(body); err != nil {
+		t.Fatal(err)
+	}
+	if docs, _ := service.index.Search(ctx, "Mendoza"); len(docs) != 0 {
+		t.Fatalf("expected hotel to be removed, got %+v", docs)
+	}
+
+	// Una versión que este consumidor todavía no conoce va a la dead-letter queue
+	created.Version = events.CurrentVersion(events.HotelCreated) + 1
+	body, _ = json.Marshal(created)
+	if err := service.HandleHotelUpdate(body); !errors.Is(err, ErrMalformedHotelUpdate) {
+		t.Fatalf("expected an unsupported version error, got %v", err)
+	}
+}
+
+func TestHandleHotelUpdateRejectsMalformedMessages(t *testing.T) {
+	service := newTestService(stubAvailability{})
+
+	for _, body := range []string{`not json`, `{"action":"archived","hotel":{"id":"x"}}`, `{"action":5}`} {
+		if err := service.HandleHotelUpdate([]byte(body)); err == nil {
+			t.Errorf("expected error for %s", body)
+		}
+	}
+}
+
+func TestSolrIndexSearch(t *testing.T) {
+	solr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/solr/hotels/select" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("q") != "*:*" || r.URL.Query().Get("fq") != `city:"Mendoza"` {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		io.WriteString(w, `{"response":{"numFound":1,"docs":[{"id":"1","name":"Plaza","city":"Mendoza"}]}}`)
+	}))
+	defer solr.Close()
+
+	docs, err := NewSolrIndex(solr.URL).Search(context.Background(), "Mendoza")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || docs[0].Name != "Plaza" {
+		t.Fatalf("unexpected docs %+v", docs)
+	}
+}
+
+func TestSolrIndexUpdates(t *testing.T) {
+	var bodies []string
+	solr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/solr/hotels/update" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	}))
+	defer solr.Close()
+
+	index := NewSolrIndex(solr.URL)
+	ctx := context.Background()
+	if err := index.Index(ctx, HotelDocument{ID: "1", Name: "Plaza"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Delete(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bodies) != 2 || !strings.HasPrefix(bodies[0], `[{"id":"1"`) || !strings.Contains(bodies[1], `"delete"`) {
+		t.Fatalf("unexpected update bodies %v", bodies)
+	}
+}
+
+func TestHTTPAvailabilityChecker(t *testing.T) {
+	users := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		available := r.URL.Query().Get("hotel_id") == "free"
+		json.NewEncoder(w).Encode(map[string]interface{}{"available": available})
+	}))
+	defer users.Close()
+
+	checker := NewHTTPAvailabilityChecker(users.URL)
+	if !checker.CheckAvailability("free", "2026-01-10", "2026-01-12") {
+		t.Error("expected hotel to be available")
+	}
+	if checker.CheckAvailability("booked", "2026-01-10", "2026-01-12") {
+		t.Error("expected hotel to be unavailable")
+	}
+}
+
+// recordingAcknowledger guarda los delivery tags confirmados
+type recordingAcknowledger struct {
+	mu    sync.Mutex
+	acked []uint64
+}
+
+func (a *recordingAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acked = append(a.acked, tag)
+	return nil
+}
+
+func (a *recordingAcknowledger) Nack(tag uint64, multiple, requeue bool) error { return nil }
+func (a *recordingAcknowledger) Reject(tag uint64, requeue bool) error         { return nil }
+
+// slowIndex demora los Index, como un batch que tarda más que un borrado
+type slowIndex struct {
+	SearchIndex
+}
+
+func (i slowIndex) Index(ctx context.Context, doc HotelDocument) error {
+	time.Sleep(20 * time.Millisecond)
+	return i.SearchIndex.Index(ctx, doc)
+}
+
+func TestProcessHotelUpdatesKeepsOrderPerHotel(t *testing.T) {
+	service := newTestService(stubAvailability{})
+	service.index = slowIndex{service.index}
+	ctx := context.Background()
+	acknowledger := &recordingAcknowledger{}
+
+	msgs := make(chan amqp.Delivery, 20)
+	tag := uint64(0)
+	deliver := func(eventType string, payload interface{}) {
+		envelope, _ := events.New(ctx, eventType, "hotel-service", payload)
+		body, _ := json.Marshal(envelope)
+		tag++
+		msgs <- amqp.Delivery{Acknowledger: acknowledger, DeliveryTag: tag, Body: body}
+	}
+	for n := 0; n < 5; n++ {
+		id := fmt.Sprintf("hotel-%d", n)
+		deliver(events.HotelUpdated, events.HotelPayload{ID: id, Name: "Plaza", City: "Mendoza"})
+		deliver(events.HotelDeleted, events.HotelDeletedPayload{ID: id})
+	}
+	close(msgs)
+
+	service.processHotelUpdates(msgs)
+
+	if docs, _ := service.index.Search(ctx, "Mendoza"); len(docs) != 0 {
+		t.Fatalf("expected every delete to win over the update before it, got %+v", docs)
+	}
+	if len(acknowledger.acked) != int(tag) {
+		t.Fatalf("expected all %d messages to be acked, got %v", tag, acknowledger.acked)
+	}
+}
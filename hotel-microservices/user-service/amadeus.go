@@ -0,0 +1,660 @@
+package userservice
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+const DefaultAmadeusBaseURL = "https://test.api.amadeus.com"
+
+// El token se renueva un poco antes de que venza, para no usarlo justo al expirar
+const amadeusTokenExpiryMargin = 60 * time.Second
+
+var (
+	ErrAmadeusNotConfigured = errors.New("Amadeus credentials not configured")
+	ErrAmadeusNoOffers      = errors.New("Amadeus has no offers for the requested dates")
+	ErrAmadeusUnauthorized  = errors.New("Amadeus rejected the access token")
+	// ErrAmadeusBookingRejected es un 400 al crear la reserva, p.ej. la oferta ya no se puede reservar
+	ErrAmadeusBookingRejected = errors.New("Amadeus rejected the booking")
+	// ErrAmadeusBookingUnconfirmed es un timeout o un 5xx al crear la reserva:
+	// puede haber quedado hecha upstream, así que no se sabe si existe
+	ErrAmadeusBookingUnconfirmed = errors.New("Amadeus booking result is unknown")
+	ErrAmadeusBookingNotFound    = errors.New("Amadeus has no booking with that reference")
+)
+
+type AmadeusToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// AmadeusStatusError es una respuesta de Amadeus con un status inesperado
+type AmadeusStatusError struct {
+	StatusCode int
+}
+
+func (e *AmadeusStatusError) Error() string {
+	return fmt.Sprintf("Amadeus API returned status %d", e.StatusCode)
+}
+
+// cachedAmadeusToken es lo que se guarda en memcached para compartir el token entre réplicas
+type cachedAmadeusToken struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (t cachedAmadeusToken) valid() bool {
+	return t.AccessToken != "" && time.Now().Add(amadeusTokenExpiryMargin).Before(t.ExpiresAt)
+}
+
+type AmadeusClient struct {
+	baseURL      string
+	clientID     string
+	clientSecret string
+	HTTPClient   *http.Client
+	Breaker      *CircuitBreaker
+	Payment      AmadeusPaymentCard
+	// TokenCache es opcional; si está, el token se comparte entre réplicas
+	TokenCache Cache
+
+	mu        sync.Mutex
+	refreshMu sync.Mutex
+	token     cachedAmadeusToken
+}
+
+func NewAmadeusClient(baseURL, clientID, clientSecret string) *AmadeusClient {
+	if baseURL == "" {
+		baseURL = DefaultAmadeusBaseURL
+	}
+
+	return &AmadeusClient{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+		Breaker:      NewCircuitBreaker(5, 30*time.Second),
+	}
+}
+
+func (a *AmadeusClient) Configured() bool {
+	return a != nil && a.clientID != "" && a.clientSecret != ""
+}
+
+// Token devuelve un access token válido, reutilizando el cacheado en memoria
+// o en memcached. Si hay que pedir uno nuevo, solo una goroutine lo pide y
+// las demás esperan ese resultado.
+func (a *AmadeusClient) Token(ctx context.Context) (string, error) {
+	if !a.Configured() {
+		return "", ErrAmadeusNotConfigured
+	}
+
+	if token, ok := a.cachedToken(); ok {
+		return token, nil
+	}
+
+	a.refreshMu.Lock()
+	defer a.refreshMu.Unlock()
+
+	// Otra goroutine pudo haberlo renovado mientras esperábamos
+	if token, ok := a.cachedToken(); ok {
+		return token, nil
+	}
+
+	token, err := a.fetchToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	a.storeToken(token)
+	return token.AccessToken, nil
+}
+
+// InvalidateToken descarta el token si Amadeus lo rechazó, salvo que ya se haya renovado
+func (a *AmadeusClient) InvalidateToken(token string) {
+	a.mu.Lock()
+	if a.token.AccessToken == token {
+		a.token = cachedAmadeusToken{}
+	}
+	a.mu.Unlock()
+
+	if a.TokenCache == nil {
+		return
+	}
+	if shared, ok := a.sharedToken(); ok && shared.AccessToken == token {
+		a.TokenCache.Delete(a.tokenCacheKey())
+	}
+}
+
+func (a *AmadeusClient) cachedToken() (string, bool) {
+	a.mu.Lock()
+	token := a.token
+	a.mu.Unlock()
+
+	if token.valid() {
+		return token.AccessToken, true
+	}
+
+	if a.TokenCache == nil {
+		return "", false
+	}
+	shared, ok := a.sharedToken()
+	if !ok || !shared.valid() {
+		return "", false
+	}
+
+	a.mu.Lock()
+	a.token = shared
+	a.mu.Unlock()
+	return shared.AccessToken, true
+}
+
+func (a *AmadeusClient) sharedToken() (cachedAmadeusToken, bool) {
+	var token cachedAmadeusToken
+
+	item, err := a.TokenCache.Get(a.tokenCacheKey())
+	if err != nil {
+		if err != memcache.ErrCacheMiss {
+			log.Printf("Error reading Amadeus token from cache: %v", err)
+		}
+		return token, false
+	}
+	if err := json.Unmarshal(item.Value, &token); err != nil {
+		return token, false
+	}
+	return token, true
+}
+
+func (a *AmadeusClient) storeToken(token cachedAmadeusToken) {
+	a.mu.Lock()
+	a.token = token
+	a.mu.Unlock()
+
+	if a.TokenCache == nil {
+		return
+	}
+
+	data, _ := json.Marshal(token)
+	expiration := int32(time.Until(token.ExpiresAt.Add(-amadeusTokenExpiryMargin)).Seconds())
+	if expiration <= 0 {
+		return
+	}
+	if err := a.TokenCache.Set(&memcache.Item{Key: a.tokenCacheKey(), Value: data, Expiration: expiration}); err != nil {
+		log.Printf("Error storing Amadeus token in cache: %v", err)
+	}
+}
+
+// tokenCacheKey depende de las credenciales para no mezclar tokens de distintas cuentas
+func (a *AmadeusClient) tokenCacheKey() string {
+	sum := sha256.Sum256([]byte(a.baseURL + "|" + a.clientID))
+	return fmt.Sprintf("amadeus_token:%x", sum[:8])
+}
+
+func (a *AmadeusClient) fetchToken(ctx context.Context) (cachedAmadeusToken, error) {
+	var cached cachedAmadeusToken
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", a.clientID)
+	form.Set("client_secret", a.clientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.baseURL+"/v1/security/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return cached, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return cached, err
+	}
+	defer resp.Body.Close()
+
+	// No loguear el body: contiene el access token
+	if resp.StatusCode != http.StatusOK {
+		return cached, &AmadeusStatusError{StatusCode: resp.StatusCode}
+	}
+
+	var token AmadeusToken
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return cached, err
+	}
+	if token.AccessToken == "" {
+		return cached, errors.New("Amadeus token response has no access_token")
+	}
+
+	log.Printf("Obtained Amadeus access token %s (expires in %ds)", redactToken(token.AccessToken), token.ExpiresIn)
+
+	cached.AccessToken = token.AccessToken
+	cached.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return cached, nil
+}
+
+// redactToken deja ver solo el final del token, suficiente para correlacionar logs
+func redactToken(token string) string {
+	if len(token) <= 4 {
+		return "****"
+	}
+	return "****" + token[len(token)-4:]
+}
+
+type AmadeusOffer struct {
+	ID           string `json:"id"`
+	CheckInDate  string `json:"checkInDate"`
+	CheckOutDate string `json:"checkOutDate"`
+	Room         struct {
+		Type string `json:"type"`
+	} `json:"room"`
+	Price struct {
+		Currency string `json:"currency"`
+		Total    string `json:"total"`
+	} `json:"price"`
+}
+
+type AmadeusGuest struct {
+	FirstName string
+	LastName  string
+	Email     string
+}
+
+// AmadeusPaymentCard es la tarjeta con la que se garantizan las reservas. No se loguea nunca.
+type AmadeusPaymentCard struct {
+	VendorCode string
+	CardNumber string
+	ExpiryDate string
+}
+
+type AmadeusBooking struct {
+	ID                 string `json:"id"`
+	ConfirmationNumber string `json:"providerConfirmationId"`
+}
+
+// do hace un request a la API con el token y decodifica la respuesta en out.
+// Los status distintos de 2xx vuelven como *AmadeusStatusError, salvo 401.
+func (a *AmadeusClient) do(ctx context.Context, method, path, token string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/vnd.amadeus+json")
+	}
+
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	log.Printf("Amadeus API %s %s response status: %d", method, strings.SplitN(path, "?", 2)[0], resp.StatusCode)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrAmadeusUnauthorized
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return &AmadeusStatusError{StatusCode: resp.StatusCode}
+	case out == nil:
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func isAmadeusStatus(err error, codes ...int) bool {
+	var statusErr *AmadeusStatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	for _, code := range codes {
+		if statusErr.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchOffer devuelve la primera oferta del hotel para esas fechas
+func (a *AmadeusClient) SearchOffer(ctx context.Context, token, amadeusHotelID string, checkIn, checkOut time.Time, adults, rooms int) (AmadeusOffer, error) {
+	query := url.Values{}
+	query.Set("hotelIds", amadeusHotelID)
+	query.Set("checkInDate", checkIn.Format("2006-01-02"))
+	query.Set("checkOutDate", checkOut.Format("2006-01-02"))
+	query.Set("adults", strconv.Itoa(adults))
+	query.Set("roomQuantity", strconv.Itoa(rooms))
+
+	var response struct {
+		Data []struct {
+			Available bool           `json:"available"`
+			Offers    []AmadeusOffer `json:"offers"`
+		} `json:"data"`
+	}
+	err := a.do(ctx, "GET", "/v3/shopping/hotel-offers?"+query.Encode(), token, nil, &response)
+	if isAmadeusStatus(err, http.StatusBadRequest) {
+		// No hay ofertas disponibles
+		return AmadeusOffer{}, ErrAmadeusNoOffers
+	}
+	if err != nil {
+		return AmadeusOffer{}, err
+	}
+
+	for _, hotel := range response.Data {
+		if hotel.Available && len(hotel.Offers) > 0 {
+			return hotel.Offers[0], nil
+		}
+	}
+	return AmadeusOffer{}, ErrAmadeusNoOffers
+}
+
+// PriceOffer confirma el precio actual de la oferta antes de reservar
+func (a *AmadeusClient) PriceOffer(ctx context.Context, token, offerID string) (AmadeusOffer, error) {
+	var response struct {
+		Data struct {
+			Available bool           `json:"available"`
+			Offers    []AmadeusOffer `json:"offers"`
+		} `json:"data"`
+	}
+	err := a.do(ctx, "GET", "/v3/shopping/hotel-offers/"+url.PathEscape(offerID), token, nil, &response)
+	if isAmadeusStatus(err, http.StatusBadRequest, http.StatusNotFound) {
+		// La oferta ya no existe
+		return AmadeusOffer{}, ErrAmadeusNoOffers
+	}
+	if err != nil {
+		return AmadeusOffer{}, err
+	}
+
+	if !response.Data.Available || len(response.Data.Offers) == 0 {
+		return AmadeusOffer{}, ErrAmadeusNoOffers
+	}
+	return response.Data.Offers[0], nil
+}
+
+// amadeusBookingReference es la referencia que identifica upstream a la
+// reserva local; es la misma en cada intento, así un reintento no duplica la
+// reserva y una cuyo resultado no se supo se puede buscar
+func amadeusBookingReference(reservationID int) string {
+	return fmt.Sprintf("reservation-%d", reservationID)
+}
+
+// CreateBooking reserva la oferta. reference hace idempotente la creación:
+// con la misma referencia Amadeus devuelve la reserva existente. Si el
+// resultado no se sabe devuelve ErrAmadeusBookingUnconfirmed.
+func (a *AmadeusClient) CreateBooking(ctx context.Context, token, offerID, reference string, guest AmadeusGuest) (AmadeusBooking, error) {
+	payload := map[string]interface{}{
+		"data": map[string]interface{}{
+			"offerId":         offerID,
+			"clientReference": reference,
+			"guests": []map[string]interface{}{{
+				"name":    map[string]string{"firstName": guest.FirstName, "lastName": guest.LastName},
+				"contact": map[string]string{"email": guest.Email},
+			}},
+			"payments": []map[string]interface{}{{
+				"method": "creditCard",
+				"card": map[string]string{
+					"vendorCode": a.Payment.VendorCode,
+					"cardNumber": a.Payment.CardNumber,
+					"expiryDate": a.Payment.ExpiryDate,
+				},
+			}},
+		},
+	}
+
+	var response struct {
+		Data []AmadeusBooking `json:"data"`
+	}
+	err := a.do(ctx, "POST", "/v1/booking/hotel-bookings", token, payload, &response)
+	if isAmadeusStatus(err, http.StatusBadRequest) {
+		return AmadeusBooking{}, ErrAmadeusBookingRejected
+	}
+	if err == ErrAmadeusUnauthorized {
+		// Con el token rechazado la reserva no llegó a crearse
+		return AmadeusBooking{}, err
+	}
+	if err != nil {
+		return AmadeusBooking{}, fmt.Errorf("%w: %v", ErrAmadeusBookingUnconfirmed, err)
+	}
+	if len(response.Data) == 0 || response.Data[0].ID == "" {
+		return AmadeusBooking{}, fmt.Errorf("%w: response has no booking", ErrAmadeusBookingUnconfirmed)
+	}
+	return response.Data[0], nil
+}
+
+// FindBooking busca la reserva vigente creada con reference
+func (a *AmadeusClient) FindBooking(ctx context.Context, token, reference string) (AmadeusBooking, error) {
+	query := url.Values{}
+	query.Set("clientReference", reference)
+
+	var response struct {
+		Data []AmadeusBooking `json:"data"`
+	}
+	err := a.do(ctx, "GET", "/v1/booking/hotel-bookings?"+query.Encode(), token, nil, &response)
+	if isAmadeusStatus(err, http.StatusNotFound) {
+		return AmadeusBooking{}, ErrAmadeusBookingNotFound
+	}
+	if err != nil {
+		return AmadeusBooking{}, err
+	}
+	if len(response.Data) == 0 || response.Data[0].ID == "" {
+		return AmadeusBooking{}, ErrAmadeusBookingNotFound
+	}
+	return response.Data[0], nil
+}
+
+// CancelBooking cancela la reserva en Amadeus; si ya no existe no es un error
+func (a *AmadeusClient) CancelBooking(ctx context.Context, token, bookingID string) error {
+	err := a.do(ctx, "DELETE", "/v1/booking/hotel-bookings/"+url.PathEscape(bookingID), token, nil, nil)
+	if isAmadeusStatus(err, http.StatusNotFound) {
+		return nil
+	}
+	return err
+}
+
+type AmadeusHotel struct {
+	HotelID  string `json:"hotelId"`
+	Name     string `json:"name"`
+	IATACode string `json:"iataCode"`
+	GeoCode  struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"geoCode"`
+}
+
+// HotelsByCity lista los hoteles de Amadeus de una ciudad (código IATA, p.ej. PAR)
+func (a *AmadeusClient) HotelsByCity(ctx context.Context, token, cityCode string) ([]AmadeusHotel, error) {
+	query := url.Values{}
+	query.Set("cityCode", strings.ToUpper(cityCode))
+	return a.listHotels(ctx, token, "/v1/reference-data/locations/hotels/by-city?"+query.Encode())
+}
+
+func (a *AmadeusClient) HotelsByGeocode(ctx context.Context, token string, latitude, longitude float64, radiusKM int) ([]AmadeusHotel, error) {
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(latitude, 'f', -1, 64))
+	query.Set("longitude", strconv.FormatFloat(longitude, 'f', -1, 64))
+	query.Set("radius", strconv.Itoa(radiusKM))
+	query.Set("radiusUnit", "KM")
+	return a.listHotels(ctx, token, "/v1/reference-data/locations/hotels/by-geocode?"+query.Encode())
+}
+
+func (a *AmadeusClient) listHotels(ctx context.Context, token, path string) ([]AmadeusHotel, error) {
+	var response struct {
+		Data []AmadeusHotel `json:"data"`
+	}
+	err := a.do(ctx, "GET", path, token, nil, &response)
+	if isAmadeusStatus(err, http.StatusBadRequest, http.StatusNotFound) {
+		// Amadeus responde 400 cuando no encuentra hoteles
+		return []AmadeusHotel{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if response.Data == nil {
+		response.Data = []AmadeusHotel{}
+	}
+	return response.Data, nil
+}
+
+// withToken ejecuta fn con un token válido, renovándolo una vez si Amadeus lo rechaza
+func (s *UserService) withToken(ctx context.Context, fn func(token string) error) error {
+	token, err := s.amadeus.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("getting Amadeus token: %w", err)
+	}
+
+	err = fn(token)
+	if err != ErrAmadeusUnauthorized {
+		return err
+	}
+
+	// El token pudo haber expirado: pedir uno nuevo y reintentar una vez
+	s.amadeus.InvalidateToken(token)
+	token, err = s.amadeus.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("refreshing Amadeus token: %w", err)
+	}
+	return fn(token)
+}
+
+// bookWithAmadeus busca una oferta, la re-cotiza y crea la reserva en Amadeus,
+// completando AmadeusID, AmadeusBookingID y ConfirmationNumber. Devuelve
+// ErrMappingNotFound si el hotel no está mapeado, ErrAmadeusNoOffers o
+// ErrAmadeusBookingRejected si Amadeus dice que no; cualquier otro error
+// significa que no se pudo validar. Si envuelve ErrAmadeusBookingUnconfirmed
+// la reserva puede existir upstream y se resuelve con recoverAmadeusBooking.
+func (s *UserService) bookWithAmadeus(ctx context.Context, reservation *Reservation) error {
+	// Los hoteles sin mapping no se pueden reservar en Amadeus
+	mapping, err := s.mappings.Get(ctx, reservation.HotelID)
+	if err != nil {
+		return err
+	}
+	amadeusID := mapping.AmadeusID
+
+	guest, err := s.amadeusGuest(ctx, reservation.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Después de Allow todo camino tiene que registrar Success o Failure: en
+	// half-open Allow deja pasar una sola llamada de prueba
+	if !s.amadeus.Breaker.Allow() {
+		return ErrCircuitOpen
+	}
+	reservation.AmadeusID = amadeusID
+
+	var booking AmadeusBooking
+	err = s.withToken(ctx, func(token string) error {
+		offer, err := s.amadeus.SearchOffer(ctx, token, amadeusID, reservation.CheckIn, reservation.CheckOut, atLeastOne(reservation.Guests), atLeastOne(reservation.Rooms))
+		if err != nil {
+			return err
+		}
+
+		priced, err := s.amadeus.PriceOffer(ctx, token, offer.ID)
+		if err != nil {
+			return err
+		}
+		log.Printf("Amadeus offer %s priced at %s %s", priced.ID, priced.Price.Total, priced.Price.Currency)
+
+		booking, err = s.amadeus.CreateBooking(ctx, token, priced.ID, amadeusBookingReference(reservation.ID), guest)
+		return err
+	})
+
+	if err == nil || err == ErrAmadeusNoOffers || err == ErrAmadeusBookingRejected {
+		s.amadeus.Breaker.Success()
+		if err == nil {
+			reservation.AmadeusBookingID = booking.ID
+			reservation.ConfirmationNumber = booking.ConfirmationNumber
+			log.Printf("Amadeus booking created: %s", booking.ID)
+		}
+		return err
+	}
+
+	s.amadeus.Breaker.Failure()
+	return fmt.Errorf("booking with Amadeus: %w", err)
+}
+
+// recoverAmadeusBooking busca por referencia la reserva upstream de un intento
+// cuyo resultado no se supo y completa AmadeusBookingID y ConfirmationNumber.
+// Devuelve ErrAmadeusBookingNotFound si Amadeus no la tiene.
+func (s *UserService) recoverAmadeusBooking(ctx context.Context, reservation *Reservation) error {
+	if !s.amadeus.Breaker.Allow() {
+		return ErrCircuitOpen
+	}
+
+	var booking AmadeusBooking
+	err := s.withToken(ctx, func(token string) error {
+		var err error
+		booking, err = s.amadeus.FindBooking(ctx, token, amadeusBookingReference(reservation.ID))
+		return err
+	})
+	if err != nil && err != ErrAmadeusBookingNotFound {
+		s.amadeus.Breaker.Failure()
+		return fmt.Errorf("looking up Amadeus booking: %w", err)
+	}
+
+	s.amadeus.Breaker.Success()
+	if err != nil {
+		return err
+	}
+	reservation.AmadeusBookingID = booking.ID
+	reservation.ConfirmationNumber = booking.ConfirmationNumber
+	log.Printf("Amadeus booking recovered: %s", booking.ID)
+	return nil
+}
+
+// cancelWithAmadeus cancela la reserva upstream, si la hay
+func (s *UserService) cancelWithAmadeus(ctx context.Context, reservation Reservation) error {
+	if reservation.AmadeusBookingID == "" || !s.amadeus.Configured() {
+		return nil
+	}
+	if !s.amadeus.Breaker.Allow() {
+		return ErrCircuitOpen
+	}
+
+	err := s.withToken(ctx, func(token string) error {
+		return s.amadeus.CancelBooking(ctx, token, reservation.AmadeusBookingID)
+	})
+	if err != nil {
+		s.amadeus.Breaker.Failure()
+		return fmt.Errorf("cancelling Amadeus booking %s: %w", reservation.AmadeusBookingID, err)
+	}
+
+	s.amadeus.Breaker.Success()
+	log.Printf("Amadeus booking cancelled: %s", reservation.AmadeusBookingID)
+	return nil
+}
+
+// amadeusGuest arma el huésped a partir del usuario. Los usuarios no tienen
+// nombre y apellido, así que se usa el username en ambos.
+func (s *UserService) amadeusGuest(ctx context.Context, userID int) (AmadeusGuest, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return AmadeusGuest{}, err
+	}
+	return AmadeusGuest{FirstName: user.Username, LastName: user.Username, Email: user.Email}, nil
+}
+
+func atLeastOne(value int) int {
+	if value < 1 {
+		return 1
+	}
+	return value
+}
@@ -0,0 +1,464 @@
+package userservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeAmadeus simula la API de Amadeus para tests. Por defecto todo hotel
+// tiene ofertas; NoAvailability marca hoteles que responden 400. Las reservas
+// creadas quedan en Bookings() hasta que se cancelan; crear otra con la misma
+// referencia devuelve la existente.
+type FakeAmadeus struct {
+	Server *httptest.Server
+
+	ClientID     string
+	ClientSecret string
+
+	mu             sync.Mutex
+	noAvailability map[string]bool
+	validTokens    map[string]bool
+	issued         int
+	expireNext     int
+	tokenDelay     time.Duration
+	offersDelay    time.Duration
+	tokenLifetime  time.Duration
+	tokenRequests  int
+	offerRequests  int
+	offers         map[string]string
+	bookings       map[string]FakeAmadeusBooking
+	rejectBookings bool
+	loseResponses  int
+}
+
+// FakeAmadeusBooking es una reserva creada en el fake
+type FakeAmadeusBooking struct {
+	ID                 string
+	ConfirmationNumber string
+	OfferID            string
+	HotelID            string
+	GuestEmail         string
+	CardNumber         string
+	Reference          string
+	Cancelled          bool
+}
+
+func NewFakeAmadeus() *FakeAmadeus {
+	fake := &FakeAmadeus{
+		ClientID:       "fake-client-id",
+		ClientSecret:   "fake-client-secret",
+		noAvailability: map[string]bool{},
+		validTokens:    map[string]bool{},
+		tokenLifetime:  1799 * time.Second,
+		offers:         map[string]string{},
+		bookings:       map[string]FakeAmadeusBooking{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/security/oauth2/token", fake.handleToken)
+	mux.HandleFunc("/v3/shopping/hotel-offers", fake.handleOffers)
+	mux.HandleFunc("/v3/shopping/hotel-offers/", fake.handlePricing)
+	mux.HandleFunc("/v1/reference-data/locations/hotels/by-city", fake.handleHotelList)
+	mux.HandleFunc("/v1/reference-data/locations/hotels/by-geocode", fake.handleHotelList)
+	mux.HandleFunc("/v1/booking/hotel-bookings", fake.handleCreateBooking)
+	mux.HandleFunc("/v1/booking/hotel-bookings/", fake.handleCancelBooking)
+	fake.Server = httptest.NewServer(mux)
+	return fake
+}
+
+func (f *FakeAmadeus) URL() string {
+	return f.Server.URL
+}
+
+func (f *FakeAmadeus) Close() {
+	f.Server.Close()
+}
+
+// Client devuelve un AmadeusClient apuntando al fake con credenciales y tarjeta de prueba
+func (f *FakeAmadeus) Client() *AmadeusClient {
+	client := NewAmadeusClient(f.URL(), f.ClientID, f.ClientSecret)
+	client.Payment = AmadeusPaymentCard{VendorCode: "VI", CardNumber: "4111111111111111", ExpiryDate: "2030-01"}
+	return client
+}
+
+// RejectBookings hace que la creación de reservas responda 400
+func (f *FakeAmadeus) RejectBookings() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rejectBookings = true
+}
+
+// LoseBookingResponses hace que las próximas n reservas se creen pero
+// respondan 502, como un timeout después de reservar
+func (f *FakeAmadeus) LoseBookingResponses(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.loseResponses = n
+}
+
+func (f *FakeAmadeus) Bookings() []FakeAmadeusBooking {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var bookings []FakeAmadeusBooking
+	for _, booking := range f.bookings {
+		bookings = append(bookings, booking)
+	}
+	sort.Slice(bookings, func(i, j int) bool { return bookings[i].ID < bookings[j].ID })
+	return bookings
+}
+
+func (f *FakeAmadeus) NoAvailability(amadeusHotelID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.noAvailability[amadeusHotelID] = true
+}
+
+// ExpireTokens invalida los tokens emitidos, como si hubieran vencido
+func (f *FakeAmadeus) ExpireTokens() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.validTokens = map[string]bool{}
+}
+
+// ExpireNextTokens hace que los próximos n tokens emitidos ya estén vencidos
+func (f *FakeAmadeus) ExpireNextTokens(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expireNext = n
+}
+
+// SetDelay demora todas las respuestas, para simular timeouts
+func (f *FakeAmadeus) SetDelay(delay time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokenDelay = delay
+	f.offersDelay = delay
+}
+
+// SetOffersDelay demora solo la consulta de ofertas
+func (f *FakeAmadeus) SetOffersDelay(delay time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.offersDelay = delay
+}
+
+// SetTokenLifetime cambia el expires_in de los tokens emitidos
+func (f *FakeAmadeus) SetTokenLifetime(lifetime time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokenLifetime = lifetime
+}
+
+func (f *FakeAmadeus) TokenRequests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tokenRequests
+}
+
+func (f *FakeAmadeus) OfferRequests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.offerRequests
+}
+
+func wait(r *http.Request, delay time.Duration) {
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+	}
+}
+
+func (f *FakeAmadeus) handleToken(w http.ResponseWriter, r *http.Request) {
+	// Leer el body antes de esperar, así el server detecta si el cliente cortó
+	formErr := r.ParseForm()
+
+	f.mu.Lock()
+	delay := f.tokenDelay
+	f.mu.Unlock()
+	wait(r, delay)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokenRequests++
+
+	if r.Method != http.MethodPost || formErr != nil ||
+		r.PostForm.Get("grant_type") != "client_credentials" ||
+		r.PostForm.Get("client_id") != f.ClientID ||
+		r.PostForm.Get("client_secret") != f.ClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	f.issued++
+	token := fmt.Sprintf("fake-token-%d", f.issued)
+	if f.expireNext > 0 {
+		f.expireNext--
+	} else {
+		f.validTokens[token] = true
+	}
+
+	json.NewEncoder(w).Encode(AmadeusToken{AccessToken: token, TokenType: "Bearer", ExpiresIn: int(f.tokenLifetime.Seconds())})
+}
+
+func writeAmadeusError(w http.ResponseWriter, status, code int, title string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{"code": code, "title": title}},
+	})
+}
+
+// authorized debe llamarse con f.mu tomado
+func (f *FakeAmadeus) authorized(w http.ResponseWriter, r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !f.validTokens[token] {
+		writeAmadeusError(w, http.StatusUnauthorized, 38192, "Access token expired")
+		return false
+	}
+	return true
+}
+
+func (f *FakeAmadeus) offerJSON(offerID, checkIn, checkOut string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           offerID,
+		"checkInDate":  checkIn,
+		"checkOutDate": checkOut,
+		"room":         map[string]string{"type": "A1K"},
+		"price":        map[string]string{"currency": "EUR", "total": "240.00"},
+	}
+}
+
+func (f *FakeAmadeus) handleOffers(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	delay := f.offersDelay
+	f.mu.Unlock()
+	wait(r, delay)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.offerRequests++
+
+	if !f.authorized(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	hotelID := query.Get("hotelIds")
+	if hotelID == "" || query.Get("checkInDate") == "" || query.Get("checkOutDate") == "" || f.noAvailability[hotelID] {
+		writeAmadeusError(w, http.StatusBadRequest, 3664, "NO ROOMS AVAILABLE AT REQUESTED PROPERTY")
+		return
+	}
+
+	offerID := fmt.Sprintf("OFFER-%s-%d", hotelID, len(f.offers)+1)
+	f.offers[offerID] = hotelID
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": []map[string]interface{}{{
+			"type":      "hotel-offers",
+			"hotel":     map[string]string{"hotelId": hotelID},
+			"available": true,
+			"offers":    []map[string]interface{}{f.offerJSON(offerID, query.Get("checkInDate"), query.Get("checkOutDate"))},
+		}},
+	})
+}
+
+func (f *FakeAmadeus) handlePricing(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.authorized(w, r) {
+		return
+	}
+
+	offerID := strings.TrimPrefix(r.URL.Path, "/v3/shopping/hotel-offers/")
+	hotelID, ok := f.offers[offerID]
+	if !ok || f.noAvailability[hotelID] {
+		writeAmadeusError(w, http.StatusNotFound, 1797, "NOT FOUND")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": map[string]interface{}{
+			"type":      "hotel-offers",
+			"hotel":     map[string]string{"hotelId": hotelID},
+			"available": true,
+			"offers":    []map[string]interface{}{f.offerJSON(offerID, "", "")},
+		},
+	})
+}
+
+// fakeAmadeusHotels es el catálogo que devuelven las búsquedas por ciudad y geocode
+var fakeAmadeusHotels = []AmadeusHotel{
+	fakeAmadeusHotel("ADPAR001", "HOTEL PARIS OPERA", "PAR", 48.8719, 2.3316),
+	fakeAmadeusHotel("YXPARKPR", "HOTEL PARK PARIS", "PAR", 48.8606, 2.3376),
+	fakeAmadeusHotel("MCLONGHM", "GROSVENOR HOUSE", "LON", 51.5098, -0.1547),
+}
+
+func fakeAmadeusHotel(id, name, iataCode string, latitude, longitude float64) AmadeusHotel {
+	hotel := AmadeusHotel{HotelID: id, Name: name, IATACode: iataCode}
+	hotel.GeoCode.Latitude = latitude
+	hotel.GeoCode.Longitude = longitude
+	return hotel
+}
+
+func (f *FakeAmadeus) handleHotelList(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.authorized(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	hotels := []AmadeusHotel{}
+	for _, hotel := range fakeAmadeusHotels {
+		if strings.HasSuffix(r.URL.Path, "/by-city") && hotel.IATACode == query.Get("cityCode") {
+			hotels = append(hotels, hotel)
+		}
+		// Aproximación: un grado son ~111 km
+		if strings.HasSuffix(r.URL.Path, "/by-geocode") {
+			latitude, _ := strconv.ParseFloat(query.Get("latitude"), 64)
+			longitude, _ := strconv.ParseFloat(query.Get("longitude"), 64)
+			radius, _ := strconv.ParseFloat(query.Get("radius"), 64)
+			if math.Hypot(hotel.GeoCode.Latitude-latitude, hotel.GeoCode.Longitude-longitude)*111 <= radius {
+				hotels = append(hotels, hotel)
+			}
+		}
+	}
+
+	if len(hotels) == 0 {
+		writeAmadeusError(w, http.StatusBadRequest, 895, "NOTHING FOUND FOR REQUESTED CITY")
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": hotels})
+}
+
+func (f *FakeAmadeus) handleCreateBooking(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.authorized(w, r) {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		f.findBooking(w, r.URL.Query().Get("clientReference"))
+		return
+	}
+
+	var request struct {
+		Data struct {
+			OfferID         string `json:"offerId"`
+			ClientReference string `json:"clientReference"`
+			Guests          []struct {
+				Contact struct {
+					Email string `json:"email"`
+				} `json:"contact"`
+			} `json:"guests"`
+			Payments []struct {
+				Card struct {
+					CardNumber string `json:"cardNumber"`
+				} `json:"card"`
+			} `json:"payments"`
+		} `json:"data"`
+	}
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&request) != nil {
+		writeAmadeusError(w, http.StatusBadRequest, 477, "INVALID FORMAT")
+		return
+	}
+
+	if booking, ok := f.bookingByReference(request.Data.ClientReference); ok {
+		w.WriteHeader(http.StatusCreated)
+		writeFakeBookings(w, booking)
+		return
+	}
+
+	hotelID, ok := f.offers[request.Data.OfferID]
+	if !ok || f.rejectBookings || len(request.Data.Guests) == 0 || len(request.Data.Payments) == 0 || request.Data.Payments[0].Card.CardNumber == "" {
+		writeAmadeusError(w, http.StatusBadRequest, 3664, "BOOKING REJECTED")
+		return
+	}
+
+	booking := FakeAmadeusBooking{
+		ID:                 fmt.Sprintf("XD_%d", 8138319951000+len(f.bookings)),
+		ConfirmationNumber: fmt.Sprintf("%d", 8138319951000+len(f.bookings)),
+		OfferID:            request.Data.OfferID,
+		HotelID:            hotelID,
+		GuestEmail:         request.Data.Guests[0].Contact.Email,
+		CardNumber:         request.Data.Payments[0].Card.CardNumber,
+		Reference:          request.Data.ClientReference,
+	}
+	f.bookings[booking.ID] = booking
+
+	if f.loseResponses > 0 {
+		f.loseResponses--
+		writeAmadeusError(w, http.StatusBadGateway, 141, "SYSTEM ERROR HAS OCCURRED")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	writeFakeBookings(w, booking)
+}
+
+// bookingByReference devuelve la reserva vigente con esa referencia; f.mu tiene que estar tomado
+func (f *FakeAmadeus) bookingByReference(reference string) (FakeAmadeusBooking, bool) {
+	if reference == "" {
+		return FakeAmadeusBooking{}, false
+	}
+	for _, booking := range f.bookings {
+		if booking.Reference == reference && !booking.Cancelled {
+			return booking, true
+		}
+	}
+	return FakeAmadeusBooking{}, false
+}
+
+func (f *FakeAmadeus) findBooking(w http.ResponseWriter, reference string) {
+	booking, ok := f.bookingByReference(reference)
+	if !ok {
+		writeAmadeusError(w, http.StatusNotFound, 1797, "NOT FOUND")
+		return
+	}
+	writeFakeBookings(w, booking)
+}
+
+func writeFakeBookings(w http.ResponseWriter, booking FakeAmadeusBooking) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": []map[string]string{{
+			"type":                   "hotel-booking",
+			"id":                     booking.ID,
+			"providerConfirmationId": booking.ConfirmationNumber,
+			"clientReference":        booking.Reference,
+		}},
+	})
+}
+
+func (f *FakeAmadeus) handleCancelBooking(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.authorized(w, r) {
+		return
+	}
+
+	bookingID := strings.TrimPrefix(r.URL.Path, "/v1/booking/hotel-bookings/")
+	booking, ok := f.bookings[bookingID]
+	if r.Method != http.MethodDelete || !ok || booking.Cancelled {
+		writeAmadeusError(w, http.StatusNotFound, 1797, "NOT FOUND")
+		return
+	}
+
+	booking.Cancelled = true
+	f.bookings[bookingID] = booking
+	w.WriteHeader(http.StatusNoContent)
+}
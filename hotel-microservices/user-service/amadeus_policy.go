@@ -0,0 +1,174 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// AmadeusFailurePolicy decide qué hacer con una reserva cuando Amadeus no responde
+type AmadeusFailurePolicy string
+
+const (
+	// AmadeusFailOpen confirma la reserva sin validar (comportamiento histórico)
+	AmadeusFailOpen AmadeusFailurePolicy = "open"
+	// AmadeusFailClosed rechaza la reserva con 503
+	AmadeusFailClosed AmadeusFailurePolicy = "closed"
+	// AmadeusFailPending acepta la reserva como pending y la revalida más tarde
+	AmadeusFailPending AmadeusFailurePolicy = "pending"
+)
+
+func ParseAmadeusFailurePolicy(value string) (AmadeusFailurePolicy, error) {
+	switch AmadeusFailurePolicy(value) {
+	case "":
+		return AmadeusFailOpen, nil
+	case AmadeusFailOpen, AmadeusFailClosed, AmadeusFailPending:
+		return AmadeusFailurePolicy(value), nil
+	default:
+		return "", fmt.Errorf("unknown Amadeus failure policy %q (expected open, closed or pending)", value)
+	}
+}
+
+// Motivos que quedan registrados en Reservation.ValidationReason
+const (
+	ReasonAmadeusValidated     = "amadeus_validated"
+	ReasonAmadeusNotConfigured = "amadeus_not_configured"
+	ReasonAmadeusFailOpen      = "amadeus_unavailable_fail_open"
+	ReasonAmadeusPending       = "amadeus_unavailable_pending"
+	ReasonAmadeusUnconfirmed   = "amadeus_booking_unconfirmed"
+	ReasonAmadeusRevalidated   = "amadeus_revalidated"
+	ReasonAmadeusNoOffers      = "amadeus_no_offers"
+	ReasonAmadeusRejected      = "amadeus_booking_rejected"
+	ReasonAmadeusUnmapped      = "amadeus_unmapped_hotel"
+)
+
+// amadeusDecision es el resultado de aplicar la política a una validación
+type amadeusDecision struct {
+	Status string
+	Reason string
+	// Reject indica que la reserva no se crea; RejectCode es el status HTTP
+	Reject     bool
+	RejectCode int
+}
+
+func (s *UserService) decideReservation(ctx context.Context, reservation *Reservation) amadeusDecision {
+	if !s.amadeus.Configured() {
+		log.Printf("Amadeus not configured, proceeding without validation")
+		return amadeusDecision{Status: "confirmed", Reason: ReasonAmadeusNotConfigured}
+	}
+
+	log.Printf("Booking reservation with Amadeus for hotel %s", reservation.HotelID)
+	err := s.bookWithAmadeus(ctx, reservation)
+	switch {
+	case err == nil:
+		return amadeusDecision{Status: "confirmed", Reason: ReasonAmadeusValidated}
+	case err == ErrAmadeusNoOffers:
+		log.Printf("Amadeus has no availability for hotel %s", reservation.HotelID)
+		return amadeusDecision{Reject: true, RejectCode: http.StatusConflict, Reason: ReasonAmadeusNoOffers}
+	case err == ErrAmadeusBookingRejected:
+		log.Printf("Amadeus rejected the booking for hotel %s", reservation.HotelID)
+		return amadeusDecision{Reject: true, RejectCode: http.StatusConflict, Reason: ReasonAmadeusRejected}
+	case err == ErrMappingNotFound:
+		// No es una falla de Amadeus: el hotel no está en Amadeus, ver GET /mappings/unmapped
+		log.Printf("Hotel %s has no Amadeus mapping, proceeding without validation", reservation.HotelID)
+		return amadeusDecision{Status: "confirmed", Reason: ReasonAmadeusUnmapped}
+	case errors.Is(err, ErrAmadeusBookingUnconfirmed):
+		// La reserva puede existir upstream: confirmarla o rechazarla sin saberlo
+		// la dejaría sin registrar, así que con cualquier política queda pending
+		// hasta que la revalidación la encuentre por referencia
+		log.Printf("Amadeus booking result unknown for hotel %s: %v", reservation.HotelID, err)
+		return amadeusDecision{Status: "pending", Reason: failureReason(ReasonAmadeusUnconfirmed, err)}
+	}
+
+	log.Printf("Amadeus validation unavailable for hotel %s (policy %s): %v", reservation.HotelID, s.amadeusPolicy, err)
+	switch s.amadeusPolicy {
+	case AmadeusFailClosed:
+		return amadeusDecision{Reject: true, RejectCode: http.StatusServiceUnavailable}
+	case AmadeusFailPending:
+		return amadeusDecision{Status: "pending", Reason: failureReason(ReasonAmadeusPending, err)}
+	default:
+		return amadeusDecision{Status: "confirmed", Reason: failureReason(ReasonAmadeusFailOpen, err)}
+	}
+}
+
+func failureReason(reason string, err error) string {
+	value := reason + ": " + err.Error()
+	if len(value) > 255 {
+		value = value[:255]
+	}
+	return value
+}
+
+// RevalidatePendingReservations vuelve a consultar Amadeus por las reservas que
+// quedaron pending por una falla. Las que siguen sin poder validarse quedan como están.
+// Devuelve cuántas resolvió. Las que ya tienen reserva en Amadeus no se vuelven a
+// reservar: quedaron pending porque falló lo que vino después, y se confirman.
+// Las que no la tienen se buscan primero por referencia, por si un intento
+// anterior reservó sin enterarse.
+func (s *UserService) RevalidatePendingReservations(ctx context.Context) (int, error) {
+	if !s.amadeus.Configured() {
+		return 0, nil
+	}
+
+	reservations, err := s.reservations.ListByStatus(ctx, "pending")
+	if err != nil {
+		return 0, err
+	}
+
+	resolved := 0
+	for _, reservation := range reservations {
+		var err error
+		if reservation.AmadeusBookingID == "" {
+			err = s.recoverAmadeusBooking(ctx, &reservation)
+			if err == ErrAmadeusBookingNotFound {
+				err = s.bookWithAmadeus(ctx, &reservation)
+			}
+			if err == nil {
+				// La reserva upstream se guarda antes que nada, así si algo falla
+				// después la próxima pasada no la duplica
+				if err := s.reservations.UpdateValidation(ctx, &reservation); err != nil {
+					log.Printf("Amadeus booking %s of reservation %d could not be saved", reservation.AmadeusBookingID, reservation.ID)
+					return resolved, err
+				}
+			}
+		}
+
+		switch {
+		case err == nil:
+			reservation.Status = "confirmed"
+			reservation.ValidationReason = ReasonAmadeusRevalidated
+		case err == ErrAmadeusNoOffers || err == ErrAmadeusBookingRejected:
+			log.Printf("Reservation %d rejected on revalidation: %v", reservation.ID, err)
+			reservation.Status = "rejected"
+			reservation.ValidationReason = ReasonAmadeusNoOffers
+			if err == ErrAmadeusBookingRejected {
+				reservation.ValidationReason = ReasonAmadeusRejected
+			}
+		case err == ErrMappingNotFound:
+			reservation.Status = "confirmed"
+			reservation.ValidationReason = ReasonAmadeusUnmapped
+		case err == ErrCircuitOpen:
+			// No tiene sentido seguir intentando hasta que cierre el breaker
+			return resolved, nil
+		default:
+			log.Printf("Reservation %d still pending: %v", reservation.ID, err)
+			continue
+		}
+
+		if err := s.reservations.UpdateValidation(ctx, &reservation); err != nil {
+			return resolved, err
+		}
+		if !holdsInventory(reservation.Status) {
+			s.invalidateAvailability(reservation.HotelID)
+		}
+		if err := s.settleRevalidatedPayment(ctx, &reservation); err != nil {
+			log.Printf("Error settling payment of reservation %d: %v", reservation.ID, err)
+		}
+		s.publishStatusChange(ctx, reservation, "pending")
+		resolved++
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,607 @@
+package userservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newAmadeusTestEnv(t *testing.T) (*testEnv, *FakeAmadeus) {
+	t.Helper()
+
+	fake := NewFakeAmadeus()
+	t.Cleanup(fake.Close)
+
+	env := newTestEnv(t)
+	env.service.amadeus = fake.Client()
+	for _, hotelID := range []string{"hotel-1", "hotel-2", "hotel-ok"} {
+		env.mapHotel(t, hotelID, "ADPAR001")
+	}
+	return env, fake
+}
+
+func TestNewAmadeusClientDefaultsBaseURL(t *testing.T) {
+	if client := NewAmadeusClient("", "id", "secret"); client.baseURL != DefaultAmadeusBaseURL {
+		t.Fatalf("expected default base URL, got %q", client.baseURL)
+	}
+	if client := NewAmadeusClient("http://localhost:9000/", "id", "secret"); client.baseURL != "http://localhost:9000" {
+		t.Fatalf("expected trailing slash to be trimmed, got %q", client.baseURL)
+	}
+	if NewAmadeusClient("", "", "").Configured() {
+		t.Fatal("expected client without credentials to be unconfigured")
+	}
+}
+
+func TestAmadeusToken(t *testing.T) {
+	fake := NewFakeAmadeus()
+	defer fake.Close()
+
+	token, err := fake.Client().Token(context.Background())
+	if err != nil || token == "" {
+		t.Fatalf("expected a token, got %q, %v", token, err)
+	}
+
+	_, err = NewAmadeusClient(fake.URL(), fake.ClientID, "wrong").Token(context.Background())
+	var statusErr *AmadeusStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected a 401 status error for bad credentials, got %v", err)
+	}
+}
+
+func TestAmadeusBookingFlow(t *testing.T) {
+	fake := NewFakeAmadeus()
+	defer fake.Close()
+	fake.NoAvailability("ADFULL01")
+
+	client := fake.Client()
+	ctx := context.Background()
+	token, err := client.Token(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.SearchOffer(ctx, token, "ADFULL01", date("2026-07-10"), date("2026-07-12"), 2, 1); err != ErrAmadeusNoOffers {
+		t.Fatalf("expected ErrAmadeusNoOffers, got %v", err)
+	}
+	if _, err := client.PriceOffer(ctx, token, "unknown-offer"); err != ErrAmadeusNoOffers {
+		t.Fatalf("expected ErrAmadeusNoOffers for an unknown offer, got %v", err)
+	}
+
+	offer, err := client.SearchOffer(ctx, token, "ADPAR001", date("2026-07-10"), date("2026-07-12"), 2, 1)
+	if err != nil || offer.ID == "" {
+		t.Fatalf("expected an offer, got %+v, %v", offer, err)
+	}
+	priced, err := client.PriceOffer(ctx, token, offer.ID)
+	if err != nil || priced.Price.Total == "" {
+		t.Fatalf("expected a priced offer, got %+v, %v", priced, err)
+	}
+
+	booking, err := client.CreateBooking(ctx, token, priced.ID, "reservation-1", AmadeusGuest{FirstName: "JUANA", LastName: "PEREZ", Email: "juana@example.com"})
+	if err != nil || booking.ID == "" || booking.ConfirmationNumber == "" {
+		t.Fatalf("expected a booking, got %+v, %v", booking, err)
+	}
+
+	if err := client.CancelBooking(ctx, token, booking.ID); err != nil {
+		t.Fatalf("expected cancellation to succeed, got %v", err)
+	}
+	// Cancelar dos veces no es un error
+	if err := client.CancelBooking(ctx, token, booking.ID); err != nil {
+		t.Fatalf("expected repeated cancellation to succeed, got %v", err)
+	}
+	if bookings := fake.Bookings(); len(bookings) != 1 || !bookings[0].Cancelled {
+		t.Fatalf("expected the booking to be cancelled upstream, got %+v", bookings)
+	}
+
+	fake.ExpireTokens()
+	if _, err := client.SearchOffer(ctx, token, "ADPAR001", date("2026-07-10"), date("2026-07-12"), 2, 1); err != ErrAmadeusUnauthorized {
+		t.Fatalf("expected ErrAmadeusUnauthorized, got %v", err)
+	}
+}
+
+func TestAmadeusCreateBookingRejected(t *testing.T) {
+	fake := NewFakeAmadeus()
+	defer fake.Close()
+	fake.RejectBookings()
+
+	client := fake.Client()
+	ctx := context.Background()
+	token, _ := client.Token(ctx)
+	offer, _ := client.SearchOffer(ctx, token, "ADPAR001", date("2026-07-10"), date("2026-07-12"), 1, 1)
+
+	if _, err := client.CreateBooking(ctx, token, offer.ID, "reservation-1", AmadeusGuest{FirstName: "A", LastName: "B"}); err != ErrAmadeusBookingRejected {
+		t.Fatalf("expected ErrAmadeusBookingRejected, got %v", err)
+	}
+}
+
+// amadeusReservation arma una reserva de un usuario existente para bookWithAmadeus
+func amadeusReservation(t *testing.T, env *testEnv, hotelID string) *Reservation {
+	t.Helper()
+
+	user := env.createUser(t, fmt.Sprintf("guest%d", len(env.store.users)+1))
+	return &Reservation{UserID: user.ID, HotelID: hotelID, CheckIn: date("2026-07-10"), CheckOut: date("2026-07-12"), Guests: 2, Rooms: 1}
+}
+
+func TestBookWithAmadeus(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+	env.mapHotel(t, "hotel-1", "ADMAD002")
+
+	reservation := amadeusReservation(t, env, "hotel-1")
+	if err := env.service.bookWithAmadeus(context.Background(), reservation); err != nil {
+		t.Fatal(err)
+	}
+	if reservation.AmadeusID != "ADMAD002" || reservation.AmadeusBookingID == "" || reservation.ConfirmationNumber == "" {
+		t.Fatalf("expected Amadeus references on the reservation, got %+v", reservation)
+	}
+
+	bookings := fake.Bookings()
+	if len(bookings) != 1 || bookings[0].HotelID != "ADMAD002" || bookings[0].GuestEmail == "" || bookings[0].CardNumber == "" {
+		t.Fatalf("expected one booking with guest and payment, got %+v", bookings)
+	}
+	if fake.TokenRequests() != 1 {
+		t.Fatalf("expected one token request, got %d", fake.TokenRequests())
+	}
+}
+
+func TestBookWithAmadeusUnmappedHotel(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+
+	reservation := amadeusReservation(t, env, "unmapped")
+	if err := env.service.bookWithAmadeus(context.Background(), reservation); err != ErrMappingNotFound {
+		t.Fatalf("expected ErrMappingNotFound, got %v", err)
+	}
+	if fake.OfferRequests() != 0 {
+		t.Fatal("expected no Amadeus calls for an unmapped hotel")
+	}
+	if _, err := env.store.Mappings.Get(context.Background(), "unmapped"); err != ErrMappingNotFound {
+		t.Fatal("expected no default mapping to be created")
+	}
+
+	user := env.createUser(t, "juana")
+	w := createReservationRequest(env, t, user.ID, "unmapped")
+	var created Reservation
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusCreated || created.ValidationReason != ReasonAmadeusUnmapped {
+		t.Fatalf("expected unmapped hotel to be booked locally, got %d %+v", w.Code, created)
+	}
+}
+
+func TestBookWithAmadeusNoAvailability(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+	env.mapHotel(t, "hotel-1", "ADFULL01")
+	fake.NoAvailability("ADFULL01")
+
+	reservation := amadeusReservation(t, env, "hotel-1")
+	if err := env.service.bookWithAmadeus(context.Background(), reservation); err != ErrAmadeusNoOffers {
+		t.Fatalf("expected no availability, got %v", err)
+	}
+	if len(fake.Bookings()) != 0 {
+		t.Fatal("expected no booking upstream")
+	}
+}
+
+func TestBookWithAmadeusRefreshesExpiredToken(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+	fake.ExpireNextTokens(1)
+
+	if err := env.service.bookWithAmadeus(context.Background(), amadeusReservation(t, env, "hotel-1")); err != nil {
+		t.Fatalf("expected booking to succeed after refreshing the token, got %v", err)
+	}
+	if fake.TokenRequests() != 2 || fake.OfferRequests() != 2 {
+		t.Fatalf("expected a refresh and a retry, got %d token and %d offer requests", fake.TokenRequests(), fake.OfferRequests())
+	}
+}
+
+func TestBookWithAmadeusTokenTimeout(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+	env.service.amadeus.HTTPClient.Timeout = 20 * time.Millisecond
+	fake.SetDelay(time.Second)
+
+	err := env.service.bookWithAmadeus(context.Background(), amadeusReservation(t, env, "hotel-1"))
+	if err == nil || err == ErrAmadeusNoOffers {
+		t.Fatalf("expected a token error, got %v", err)
+	}
+}
+
+func TestBookWithAmadeusOfferTimeout(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+	env.service.amadeus.HTTPClient.Timeout = 50 * time.Millisecond
+	fake.SetOffersDelay(time.Second)
+
+	reservation := amadeusReservation(t, env, "hotel-1")
+	err := env.service.bookWithAmadeus(context.Background(), reservation)
+	if err == nil || err == ErrAmadeusNoOffers || reservation.AmadeusBookingID != "" {
+		t.Fatalf("expected an offers error without a booking, got %v", err)
+	}
+}
+
+func TestBookWithAmadeusOpensCircuit(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+	env.service.amadeus.Breaker = NewCircuitBreaker(2, time.Hour)
+	fake.Close()
+
+	reservation := amadeusReservation(t, env, "hotel-1")
+	for i := 0; i < 2; i++ {
+		if err := env.service.bookWithAmadeus(context.Background(), reservation); err == nil {
+			t.Fatal("expected an error with Amadeus down")
+		}
+	}
+	if err := env.service.bookWithAmadeus(context.Background(), reservation); err != ErrCircuitOpen {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+}
+
+func TestBookWithAmadeusLocalErrorsDoNotSpendTheProbe(t *testing.T) {
+	env, _ := newAmadeusTestEnv(t)
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.Failure()
+	breaker.now = func() time.Time { return time.Now().Add(time.Hour) }
+	env.service.amadeus.Breaker = breaker
+
+	reservation := amadeusReservation(t, env, "hotel-1")
+	reservation.UserID = 9999
+	if err := env.service.bookWithAmadeus(context.Background(), reservation); err == nil || err == ErrCircuitOpen {
+		t.Fatalf("expected the unknown user to fail before Amadeus, got %v", err)
+	}
+
+	if !breaker.Allow() {
+		t.Fatal("expected the half-open probe to still be available")
+	}
+}
+
+func createReservationRequest(env *testEnv, t *testing.T, userID int, hotelID string) *httptest.ResponseRecorder {
+	return env.do("POST", "/reservations", tokenFor(t, userID, false), map[string]interface{}{
+		"hotel_id":  hotelID,
+		"check_in":  "2026-07-10T00:00:00Z",
+		"check_out": "2026-07-12T00:00:00Z",
+	})
+}
+
+func TestCreateReservationAmadeusFailurePolicies(t *testing.T) {
+	tests := []struct {
+		policy AmadeusFailurePolicy
+		code   int
+		status string
+		reason string
+	}{
+		{AmadeusFailOpen, http.StatusCreated, "confirmed", ReasonAmadeusFailOpen},
+		{AmadeusFailClosed, http.StatusServiceUnavailable, "", ""},
+		{AmadeusFailPending, http.StatusCreated, "pending", ReasonAmadeusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			env, fake := newAmadeusTestEnv(t)
+			env.service.amadeusPolicy = tt.policy
+			user := env.createUser(t, "juana")
+			fake.SetDelay(time.Second)
+			env.service.amadeus.HTTPClient.Timeout = 20 * time.Millisecond
+
+			w := createReservationRequest(env, t, user.ID, "hotel-1")
+			if w.Code != tt.code {
+				t.Fatalf("expected %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if tt.code != http.StatusCreated {
+				return
+			}
+
+			var reservation Reservation
+			json.Unmarshal(w.Body.Bytes(), &reservation)
+			if reservation.Status != tt.status || !strings.HasPrefix(reservation.ValidationReason, tt.reason+": ") {
+				t.Fatalf("expected %s with reason %s, got %s / %q", tt.status, tt.reason, reservation.Status, reservation.ValidationReason)
+			}
+		})
+	}
+}
+
+func TestRevalidatePendingReservations(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+	env.service.amadeusPolicy = AmadeusFailPending
+	user := env.createUser(t, "juana")
+	env.mapHotel(t, "hotel-full", "ADFULL01")
+	fake.NoAvailability("ADFULL01")
+
+	fake.SetDelay(time.Second)
+	env.service.amadeus.HTTPClient.Timeout = 20 * time.Millisecond
+	createReservationRequest(env, t, user.ID, "hotel-ok")
+	createReservationRequest(env, t, user.ID, "hotel-full")
+
+	fake.SetDelay(0)
+	if _, err := env.service.RevalidatePendingReservations(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	reservations, _ := env.store.Reservations.ListByUser(context.Background(), user.ID)
+	if len(reservations) != 2 {
+		t.Fatalf("expected 2 reservations, got %d", len(reservations))
+	}
+	if r := reservations[0]; r.Status != "confirmed" || r.ValidationReason != ReasonAmadeusRevalidated || r.AmadeusID != "ADPAR001" {
+		t.Fatalf("expected first reservation confirmed on revalidation, got %+v", r)
+	}
+	if r := reservations[1]; r.Status != "rejected" || r.ValidationReason != ReasonAmadeusNoOffers {
+		t.Fatalf("expected second reservation rejected on revalidation, got %+v", r)
+	}
+}
+
+func TestCreateReservationRejectedByAmadeus(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+	user := env.createUser(t, "juana")
+	env.mapHotel(t, "hotel-1", "ADFULL01")
+	fake.NoAvailability("ADFULL01")
+
+	w := env.do("POST", "/reservations", tokenFor(t, user.ID, false), map[string]interface{}{
+		"hotel_id":  "hotel-1",
+		"check_in":  "2026-07-10T00:00:00Z",
+		"check_out": "2026-07-12T00:00:00Z",
+	})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = env.do("POST", "/reservations", tokenFor(t, user.ID, false), map[string]interface{}{
+		"hotel_id":  "hotel-2",
+		"check_in":  "2026-07-10T00:00:00Z",
+		"check_out": "2026-07-12T00:00:00Z",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAmadeusTokenIsCached(t *testing.T) {
+	fake := NewFakeAmadeus()
+	defer fake.Close()
+
+	client := fake.Client()
+	first, err := client.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := client.Token(context.Background())
+	if first != second || fake.TokenRequests() != 1 {
+		t.Fatalf("expected cached token to be reused, got %q/%q after %d requests", first, second, fake.TokenRequests())
+	}
+
+	client.InvalidateToken(first)
+	if third, _ := client.Token(context.Background()); third == first || fake.TokenRequests() != 2 {
+		t.Fatalf("expected a new token after invalidation, got %q after %d requests", third, fake.TokenRequests())
+	}
+}
+
+func TestAmadeusTokenRenewedBeforeExpiry(t *testing.T) {
+	fake := NewFakeAmadeus()
+	defer fake.Close()
+	fake.SetTokenLifetime(amadeusTokenExpiryMargin - time.Second)
+
+	client := fake.Client()
+	client.Token(context.Background())
+	client.Token(context.Background())
+	if fake.TokenRequests() != 2 {
+		t.Fatalf("expected a token about to expire to be renewed, got %d requests", fake.TokenRequests())
+	}
+}
+
+func TestAmadeusTokenSharedBetweenReplicas(t *testing.T) {
+	fake := NewFakeAmadeus()
+	defer fake.Close()
+
+	cache := NewMemoryCache()
+	replica1, replica2 := fake.Client(), fake.Client()
+	replica1.TokenCache = cache
+	replica2.TokenCache = cache
+
+	first, _ := replica1.Token(context.Background())
+	second, _ := replica2.Token(context.Background())
+	if first != second || fake.TokenRequests() != 1 {
+		t.Fatalf("expected replicas to share the token, got %q/%q after %d requests", first, second, fake.TokenRequests())
+	}
+
+	// Si una réplica ve el token rechazado, la otra no debe seguir usándolo desde memcached
+	replica1.InvalidateToken(first)
+	if _, err := cache.Get(replica1.tokenCacheKey()); err == nil {
+		t.Fatal("expected invalidated token to be removed from the shared cache")
+	}
+}
+
+func TestAmadeusTokenSingleFlight(t *testing.T) {
+	fake := NewFakeAmadeus()
+	defer fake.Close()
+	fake.SetDelay(20 * time.Millisecond)
+
+	client := fake.Client()
+	var wg sync.WaitGroup
+	tokens := make([]string, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens[i], _ = client.Token(context.Background())
+		}(i)
+	}
+	wg.Wait()
+
+	if fake.TokenRequests() != 1 {
+		t.Fatalf("expected a single token request, got %d", fake.TokenRequests())
+	}
+	for _, token := range tokens {
+		if token != tokens[0] || token == "" {
+			t.Fatalf("expected every caller to get the same token, got %v", tokens)
+		}
+	}
+}
+
+func TestRedactToken(t *testing.T) {
+	if redacted := redactToken("abcdefgh1234"); redacted != "****1234" {
+		t.Fatalf("unexpected redaction %q", redacted)
+	}
+	if redacted := redactToken("abc"); redacted != "****" {
+		t.Fatalf("unexpected redaction %q", redacted)
+	}
+}
+
+func TestCreateReservationStoresAmadeusBooking(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+	user := env.createUser(t, "juana")
+
+	w := createReservationRequest(env, t, user.ID, "hotel-1")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var reservation Reservation
+	json.Unmarshal(w.Body.Bytes(), &reservation)
+	bookings := fake.Bookings()
+	if len(bookings) != 1 || reservation.AmadeusBookingID != bookings[0].ID || reservation.ConfirmationNumber != bookings[0].ConfirmationNumber {
+		t.Fatalf("expected the Amadeus booking on the reservation, got %+v and %+v", reservation, bookings)
+	}
+	if reservation.ValidationReason != ReasonAmadeusValidated {
+		t.Fatalf("expected reason %s, got %q", ReasonAmadeusValidated, reservation.ValidationReason)
+	}
+}
+
+func TestCancelReservationCancelsUpstream(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+	owner := env.createUser(t, "juana")
+	other := env.createUser(t, "pedro")
+
+	var reservation Reservation
+	json.Unmarshal(createReservationRequest(env, t, owner.ID, "hotel-1").Body.Bytes(), &reservation)
+	path := fmt.Sprintf("/reservations/%d/cancel", reservation.ID)
+
+	if w := env.do("POST", path, tokenFor(t, other.ID, false), nil); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another user, got %d", w.Code)
+	}
+	if w := env.do("POST", "/reservations/999/cancel", tokenFor(t, owner.ID, false), nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+
+	w := env.do("POST", path, tokenFor(t, owner.ID, false), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if bookings := fake.Bookings(); len(bookings) != 1 || !bookings[0].Cancelled {
+		t.Fatalf("expected the booking to be cancelled upstream, got %+v", bookings)
+	}
+
+	stored, _ := env.store.Reservations.FindByID(context.Background(), reservation.ID)
+	if stored.Status != "cancelled" {
+		t.Fatalf("expected local reservation cancelled, got %q", stored.Status)
+	}
+	if w := env.do("POST", path, tokenFor(t, owner.ID, false), nil); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 when cancelling twice, got %d", w.Code)
+	}
+
+	// Las fechas vuelven a estar disponibles
+	if w := createReservationRequest(env, t, other.ID, "hotel-1"); w.Code != http.StatusCreated {
+		t.Fatalf("expected the dates to be bookable again, got %d", w.Code)
+	}
+}
+
+func TestCancelReservationKeepsLocalWhenUpstreamFails(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+	user := env.createUser(t, "juana")
+
+	var reservation Reservation
+	json.Unmarshal(createReservationRequest(env, t, user.ID, "hotel-1").Body.Bytes(), &reservation)
+	fake.Close()
+
+	w := env.do("POST", fmt.Sprintf("/reservations/%d/cancel", reservation.ID), tokenFor(t, user.ID, false), nil)
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d: %s", w.Code, w.Body.String())
+	}
+
+	stored, _ := env.store.Reservations.FindByID(context.Background(), reservation.ID)
+	if stored.Status != "confirmed" {
+		t.Fatalf("expected local reservation to stay confirmed, got %q", stored.Status)
+	}
+}
+
+func TestRevalidateDoesNotBookTwice(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+	env.service.amadeusPolicy = AmadeusFailPending
+	user := env.createUser(t, "juana")
+	ctx := context.Background()
+
+	// Quedó pending con la reserva upstream hecha, p.ej. porque falló guardar la confirmación
+	booked := Reservation{UserID: user.ID, HotelID: "hotel-ok", CheckIn: date("2026-07-10"), CheckOut: date("2026-07-12"), Status: "pending", AmadeusID: "ADPAR001", AmadeusBookingID: "BOOKED-1"}
+	env.store.Reservations.Create(ctx, &booked)
+
+	if resolved, err := env.service.RevalidatePendingReservations(ctx); err != nil || resolved != 1 {
+		t.Fatalf("expected one reservation resolved, got %d, %v", resolved, err)
+	}
+	if len(fake.Bookings()) != 0 {
+		t.Fatalf("expected no new upstream booking, got %+v", fake.Bookings())
+	}
+	reservation, _ := env.store.Reservations.FindByID(ctx, booked.ID)
+	if reservation.Status != "confirmed" || reservation.AmadeusBookingID != "BOOKED-1" {
+		t.Fatalf("expected the booked reservation to be confirmed, got %+v", reservation)
+	}
+}
+
+func TestAmadeusCreateBookingIsIdempotent(t *testing.T) {
+	fake := NewFakeAmadeus()
+	defer fake.Close()
+
+	client := fake.Client()
+	ctx := context.Background()
+	token, _ := client.Token(ctx)
+	guest := AmadeusGuest{FirstName: "A", LastName: "B", Email: "a@example.com"}
+
+	if _, err := client.FindBooking(ctx, token, "reservation-7"); err != ErrAmadeusBookingNotFound {
+		t.Fatalf("expected ErrAmadeusBookingNotFound, got %v", err)
+	}
+
+	offer, _ := client.SearchOffer(ctx, token, "ADPAR001", date("2026-07-10"), date("2026-07-12"), 1, 1)
+	fake.LoseBookingResponses(1)
+	if _, err := client.CreateBooking(ctx, token, offer.ID, "reservation-7", guest); !errors.Is(err, ErrAmadeusBookingUnconfirmed) {
+		t.Fatalf("expected ErrAmadeusBookingUnconfirmed, got %v", err)
+	}
+
+	found, err := client.FindBooking(ctx, token, "reservation-7")
+	if err != nil || found.ID == "" {
+		t.Fatalf("expected the lost booking to be found by reference, got %+v, %v", found, err)
+	}
+	retried, err := client.CreateBooking(ctx, token, offer.ID, "reservation-7", guest)
+	if err != nil || retried.ID != found.ID {
+		t.Fatalf("expected the retry to return booking %s, got %+v, %v", found.ID, retried, err)
+	}
+	if bookings := fake.Bookings(); len(bookings) != 1 {
+		t.Fatalf("expected a single upstream booking, got %+v", bookings)
+	}
+}
+
+func TestUnconfirmedBookingIsRecoveredNotRebooked(t *testing.T) {
+	env, fake := newAmadeusTestEnv(t)
+	user := env.createUser(t, "juana")
+	ctx := context.Background()
+
+	// Con fail-open un resultado desconocido no se confirma: la reserva puede existir upstream
+	fake.LoseBookingResponses(1)
+	w := createReservationRequest(env, t, user.ID, "hotel-ok")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created Reservation
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Status != "pending" || !strings.HasPrefix(created.ValidationReason, ReasonAmadeusUnconfirmed+": ") {
+		t.Fatalf("expected a pending unconfirmed reservation, got %s / %q", created.Status, created.ValidationReason)
+	}
+
+	if resolved, err := env.service.RevalidatePendingReservations(ctx); err != nil || resolved != 1 {
+		t.Fatalf("expected one reservation resolved, got %d, %v", resolved, err)
+	}
+	bookings := fake.Bookings()
+	if len(bookings) != 1 || bookings[0].Reference != amadeusBookingReference(created.ID) {
+		t.Fatalf("expected the original upstream booking only, got %+v", bookings)
+	}
+	reservation, _ := env.store.Reservations.FindByID(ctx, created.ID)
+	if reservation.Status != "confirmed" || reservation.AmadeusBookingID != bookings[0].ID {
+		t.Fatalf("expected the reservation confirmed with booking %s, got %+v", bookings[0].ID, reservation)
+	}
+}
@@ -0,0 +1,79 @@
+package userservice
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker corta las llamadas a un servicio externo después de varias
+// fallas seguidas. Pasado openTimeout deja pasar una llamada de prueba
+// (half-open): si funciona se cierra, si falla vuelve a abrirse.
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	openTimeout      time.Duration
+	failures         int
+	openedAt         time.Time
+	probing          bool
+	now              func() time.Time
+}
+
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		now:              time.Now,
+	}
+}
+
+// Allow indica si se puede intentar una llamada
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.failureThreshold {
+		return true
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.openTimeout {
+		return false
+	}
+
+	b.probing = true
+	return true
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.failures >= b.failureThreshold {
+		b.openedAt = b.now()
+	}
+}
+
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.failures < b.failureThreshold:
+		return "closed"
+	case b.probing || b.now().Sub(b.openedAt) >= b.openTimeout:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
@@ -0,0 +1,42 @@
+package userservice
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	breaker.Failure()
+	if !breaker.Allow() || breaker.State() != "closed" {
+		t.Fatal("expected breaker to stay closed below the threshold")
+	}
+
+	breaker.Failure()
+	if breaker.Allow() || breaker.State() != "open" {
+		t.Fatal("expected breaker to open at the threshold")
+	}
+
+	now = now.Add(time.Minute)
+	if !breaker.Allow() {
+		t.Fatal("expected a probe call after the open timeout")
+	}
+	if breaker.Allow() {
+		t.Fatal("expected only one probe call while half-open")
+	}
+
+	breaker.Failure()
+	if breaker.Allow() {
+		t.Fatal("expected a failed probe to reopen the breaker")
+	}
+
+	now = now.Add(time.Minute)
+	breaker.Allow()
+	breaker.Success()
+	if !breaker.Allow() || breaker.State() != "closed" {
+		t.Fatal("expected a successful probe to close the breaker")
+	}
+}
@@ -0,0 +1,65 @@
+package userservice
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// Cache es el subconjunto de *memcache.Client que usa el servicio
+type Cache interface {
+	Get(key string) (*memcache.Item, error)
+	Set(item *memcache.Item) error
+	Delete(key string) error
+}
+
+type memoryCacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+func NewMemoryCache() Cache {
+	return &memoryCache{entries: map[string]memoryCacheEntry{}}
+}
+
+func (m *memoryCache) Get(key string) (*memcache.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok || (!entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt)) {
+		delete(m.entries, key)
+		return nil, memcache.ErrCacheMiss
+	}
+
+	return &memcache.Item{Key: key, Value: entry.value}, nil
+}
+
+func (m *memoryCache) Set(item *memcache.Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := memoryCacheEntry{value: item.Value}
+	if item.Expiration > 0 {
+		entry.expiresAt = time.Now().Add(time.Duration(item.Expiration) * time.Second)
+	}
+	m.entries[item.Key] = entry
+	return nil
+}
+
+func (m *memoryCache) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entries[key]; !ok {
+		return memcache.ErrCacheMiss
+	}
+	delete(m.entries, key)
+	return nil
+}
@@ -0,0 +1,202 @@
+package userservice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"events"
+)
+
+// UserErasuresExchange es un exchange fanout donde se publican los user.erased
+const UserErasuresExchange = "user_erasures"
+
+type UserExport struct {
+	FormatVersion int           `json:"format_version"`
+	ExportedAt    time.Time     `json:"exported_at"`
+	User          User          `json:"user"`
+	Reservations  []Reservation `json:"reservations"`
+}
+
+type ErasureJob struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	RequestedBy int        `json:"requested_by"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// DefaultErasureStaleAfter es cuánto puede estar running un job de borrado
+// antes de que ResumeErasureJobs lo dé por interrumpido
+const DefaultErasureStaleAfter = 10 * time.Minute
+
+func (s *UserService) exportUserData(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	user, err := s.users.FindByID(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	reservations, err := s.reservations.ListByUser(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if reservations == nil {
+		reservations = []Reservation{}
+	}
+
+	export := UserExport{
+		FormatVersion: 1,
+		ExportedAt:    time.Now().UTC(),
+		User:          user,
+		Reservations:  reservations,
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=user-%d-export.json", user.ID))
+	c.JSON(http.StatusOK, export)
+}
+
+func (s *UserService) requestErasure(c *gin.Context) {
+	isAdmin, _ := c.Get("is_admin")
+	if !isAdmin.(bool) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	adminID, _ := c.Get("user_id")
+	if adminID.(int) == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Admins cannot erase their own account"})
+		return
+	}
+
+	if _, err := s.users.FindByID(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	job := ErasureJob{
+		UserID:      userID,
+		RequestedBy: adminID.(int),
+		Status:      "pending",
+	}
+	if err := s.erasureJobs.Create(c.Request.Context(), &job); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Si el proceso se cae antes de terminar, lo retoma ResumeErasureJobs
+	go func() {
+		if _, err := s.runErasureJob(context.Background(), job, time.Now().Add(-DefaultErasureStaleAfter)); err != nil {
+			log.Printf("Error running erasure job %d: %v", job.ID, err)
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (s *UserService) getErasureJob(c *gin.Context) {
+	isAdmin, _ := c.Get("is_admin")
+	if !isAdmin.(bool) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	job, err := s.erasureJobs.LatestForUser(c.Request.Context(), userID)
+	if err != nil {
+		if err == ErrErasureJobNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Erasure job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ResumeErasureJobs corre los jobs de borrado pendientes y los que quedaron
+// running más de DefaultErasureStaleAfter, p.ej. porque el proceso se cayó.
+// Borrar es idempotente, así que repetir uno interrumpido no hace daño.
+func (s *UserService) ResumeErasureJobs(ctx context.Context) (int, error) {
+	startedBefore := time.Now().Add(-DefaultErasureStaleAfter)
+	jobs, err := s.erasureJobs.ListUnfinished(ctx, startedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	for _, job := range jobs {
+		ran, err := s.runErasureJob(ctx, job, startedBefore)
+		if err != nil {
+			log.Printf("Error running erasure job %d: %v", job.ID, err)
+		}
+		if ran {
+			resumed++
+		}
+	}
+	return resumed, nil
+}
+
+// runErasureJob reclama job y lo corre. Devuelve false si ya lo tenía otro.
+// Si no se puede guardar que terminó, el job queda running y se retoma más tarde.
+func (s *UserService) runErasureJob(ctx context.Context, job ErasureJob, startedBefore time.Time) (bool, error) {
+	claimed, err := s.erasureJobs.Claim(ctx, job.ID, startedBefore)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	if err := s.users.Anonymize(ctx, job.UserID); err != nil {
+		log.Printf("Erasure job %d failed for user %d: %v", job.ID, job.UserID, err)
+		if updateErr := s.erasureJobs.UpdateStatus(ctx, job.ID, "failed", err.Error()); updateErr != nil {
+			return true, fmt.Errorf("saving failure of erasure job %d: %w", job.ID, updateErr)
+		}
+		return true, nil
+	}
+
+	if err := s.erasureJobs.UpdateStatus(ctx, job.ID, "completed", ""); err != nil {
+		return true, fmt.Errorf("completing erasure job %d: %w", job.ID, err)
+	}
+	s.publishUserErased(ctx, job)
+
+	log.Printf("Erasure job %d completed for user %d", job.ID, job.UserID)
+	return true, nil
+}
+
+// publishUserErased informa que el usuario del job ya se anonimizó
+func (s *UserService) publishUserErased(ctx context.Context, job ErasureJob) {
+	envelope, err := events.New(ctx, events.UserErased, EventProducer, events.UserErasedPayload{
+		UserID: job.UserID,
+		JobID:  job.ID,
+	})
+	if err != nil {
+		log.Printf("Error building %s for user %d: %v", events.UserErased, job.UserID, err)
+		return
+	}
+
+	if err := s.publisher.Publish(UserErasuresExchange, events.UserErased, envelope); err != nil {
+		log.Printf("Error publishing %s for user %d: %v", events.UserErased, job.UserID, err)
+	}
+}
@@ -0,0 +1,205 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrHoldNotFound = errors.New("inventory hold not found")
+	// ErrHoldNotActive indica que la retención ya se convirtió, se liberó o venció
+	ErrHoldNotActive = errors.New("inventory hold is not active")
+	// ErrDatesUnavailable indica que otra reserva o retención ya ocupa las fechas
+	ErrDatesUnavailable = errors.New("dates are not available")
+)
+
+// DefaultHoldTTL es cuánto dura una retención si no se configura otra cosa
+const DefaultHoldTTL = 10 * time.Minute
+
+// Estados de InventoryHold.Status
+const (
+	HoldActive    = "active"
+	HoldConverted = "converted"
+	HoldReleased  = "released"
+	HoldExpired   = "expired"
+)
+
+// InventoryHold retiene las fechas de un hotel mientras el usuario completa
+// la reserva. Mientras está activa y sin vencer cuenta como ocupación.
+type InventoryHold struct {
+	ID       int       `json:"id"`
+	UserID   int       `json:"user_id"`
+	HotelID  string    `json:"hotel_id"`
+	CheckIn  time.Time `json:"check_in"`
+	CheckOut time.Time `json:"check_out"`
+	Rooms    int       `json:"rooms"`
+	Status   string    `json:"status"`
+	// ReservationID es la reserva en la que se convirtió, si la hay
+	ReservationID int       `json:"reservation_id,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (h InventoryHold) activeAt(now time.Time) bool {
+	return h.Status == HoldActive && h.ExpiresAt.After(now)
+}
+
+// countBooked cuenta las reservas y retenciones activas que ocupan las fechas
+func (s *UserService) countBooked(ctx context.Context, hotelID string, checkIn, checkOut time.Time) (int, error) {
+	reservations, err := s.reservations.CountOverlapping(ctx, hotelID, checkIn, checkOut)
+	if err != nil {
+		return 0, err
+	}
+	holds, err := s.holds.CountActiveOverlapping(ctx, hotelID, checkIn, checkOut, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return reservations + holds, nil
+}
+
+// holdForReservation valida la retención con la que se quiere reservar y
+// completa los datos que la reserva no trae
+func (s *UserService) holdForReservation(ctx context.Context, reservation *Reservation) (InventoryHold, *bookingFailure) {
+	hold, err := s.holds.FindByID(ctx, reservation.HoldID)
+	if err == ErrHoldNotFound {
+		return hold, &bookingFailure{http.StatusNotFound, "Hold not found"}
+	}
+	if err != nil {
+		return hold, &bookingFailure{http.StatusInternalServerError, err.Error()}
+	}
+	if hold.UserID != reservation.UserID {
+		return hold, &bookingFailure{http.StatusForbidden, "Access denied"}
+	}
+	if !hold.activeAt(time.Now()) {
+		return hold, &bookingFailure{http.StatusGone, "La retención ya no está activa"}
+	}
+
+	if reservation.HotelID == "" {
+		reservation.HotelID = hold.HotelID
+	}
+	if reservation.CheckIn.IsZero() && reservation.CheckOut.IsZero() {
+		reservation.CheckIn = hold.CheckIn
+		reservation.CheckOut = hold.CheckOut
+	}
+	if reservation.Rooms == 0 {
+		reservation.Rooms = hold.Rooms
+	}
+	if reservation.HotelID != hold.HotelID || !reservation.CheckIn.Equal(hold.CheckIn) || !reservation.CheckOut.Equal(hold.CheckOut) {
+		return hold, &bookingFailure{http.StatusBadRequest, "La retención no corresponde al hotel y las fechas de la reserva"}
+	}
+	return hold, nil
+}
+
+// ExpireHolds marca como vencidas las retenciones activas cuyo TTL pasó.
+// La disponibilidad ya las ignora al vencer; esto deja el estado al día.
+func (s *UserService) ExpireHolds(ctx context.Context) (int, error) {
+	return s.holds.ExpireBefore(ctx, time.Now())
+}
+
+func (s *UserService) createHold(c *gin.Context) {
+	var request struct {
+		HotelID  string    `json:"hotel_id" binding:"required"`
+		CheckIn  time.Time `json:"check_in" binding:"required"`
+		CheckOut time.Time `json:"check_out" binding:"required"`
+		Rooms    int       `json:"rooms"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !request.CheckOut.After(request.CheckIn) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "check_out must be after check_in"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	hold := InventoryHold{
+		UserID:    userID.(int),
+		HotelID:   request.HotelID,
+		CheckIn:   request.CheckIn,
+		CheckOut:  request.CheckOut,
+		Rooms:     atLeastOne(request.Rooms),
+		Status:    HoldActive,
+		ExpiresAt: time.Now().Add(s.holdTTL),
+	}
+	err := s.holds.Create(c.Request.Context(), &hold, time.Now())
+	if err == ErrDatesUnavailable {
+		c.JSON(http.StatusConflict, gin.H{"error": "Las fechas seleccionadas ya no están disponibles"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.invalidateAvailability(hold.HotelID)
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+func (s *UserService) getHold(c *gin.Context) {
+	hold, ok := s.findOwnHold(c)
+	if !ok {
+		return
+	}
+
+	// Puede estar vencida sin que ExpireHolds la haya marcado todavía
+	if hold.Status == HoldActive && !hold.activeAt(time.Now()) {
+		hold.Status = HoldExpired
+	}
+	c.JSON(http.StatusOK, hold)
+}
+
+func (s *UserService) releaseHold(c *gin.Context) {
+	hold, ok := s.findOwnHold(c)
+	if !ok {
+		return
+	}
+
+	err := s.holds.Release(c.Request.Context(), hold.ID)
+	if err == ErrHoldNotActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "Hold is already " + hold.Status})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.invalidateAvailability(hold.HotelID)
+
+	hold.Status = HoldReleased
+	c.JSON(http.StatusOK, hold)
+}
+
+// findOwnHold busca la retención de :id y responde el error si no existe o
+// no es del usuario
+func (s *UserService) findOwnHold(c *gin.Context) (InventoryHold, bool) {
+	holdID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hold ID"})
+		return InventoryHold{}, false
+	}
+
+	hold, err := s.holds.FindByID(c.Request.Context(), holdID)
+	if err == ErrHoldNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hold not found"})
+		return hold, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return hold, false
+	}
+
+	currentUserID, _ := c.Get("user_id")
+	isAdmin, _ := c.Get("is_admin")
+	if currentUserID.(int) != hold.UserID && !isAdmin.(bool) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return hold, false
+	}
+	return hold, true
+}
@@ -0,0 +1,260 @@
+package userservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func holdRequest(env *testEnv, t *testing.T, userID int, hotelID string) *InventoryHold {
+	t.Helper()
+
+	w := env.do("POST", "/holds", tokenFor(t, userID, false), map[string]interface{}{
+		"hotel_id":  hotelID,
+		"check_in":  "2026-07-10T00:00:00Z",
+		"check_out": "2026-07-13T00:00:00Z",
+		"rooms":     2,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for the hold, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var hold InventoryHold
+	json.Unmarshal(w.Body.Bytes(), &hold)
+	return &hold
+}
+
+func TestHoldIsConvertedIntoReservation(t *testing.T) {
+	env := newTestEnv(t)
+	juana := env.createUser(t, "juana")
+	pedro := env.createUser(t, "pedro")
+
+	hold := holdRequest(env, t, juana.ID, "hotel-1")
+	if hold.Status != HoldActive || !hold.ExpiresAt.After(time.Now()) {
+		t.Fatalf("expected an active hold, got %+v", hold)
+	}
+
+	// La retención ocupa las fechas para los demás
+	w := env.do("POST", "/reservations", tokenFor(t, pedro.ID, false), map[string]interface{}{
+		"hotel_id":  "hotel-1",
+		"check_in":  "2026-07-11T00:00:00Z",
+		"check_out": "2026-07-12T00:00:00Z",
+	})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for another user, got %d", w.Code)
+	}
+	w = env.do("GET", "/availability?hotel_id=hotel-1&check_in=2026-07-10&check_out=2026-07-11", "", nil)
+	var availability map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &availability)
+	if availability["available"] != false {
+		t.Fatalf("expected the held dates to be unavailable, got %v", availability)
+	}
+
+	w = env.do("POST", "/reservations", tokenFor(t, juana.ID, false), map[string]interface{}{"hold_id": hold.ID})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var reservation Reservation
+	json.Unmarshal(w.Body.Bytes(), &reservation)
+	if reservation.HotelID != "hotel-1" || reservation.Rooms != 2 || !reservation.CheckIn.Equal(hold.CheckIn) {
+		t.Fatalf("expected the reservation to take the hold details, got %+v", reservation)
+	}
+
+	stored, _ := env.store.Holds.FindByID(context.Background(), hold.ID)
+	if stored.Status != HoldConverted || stored.ReservationID != reservation.ID {
+		t.Fatalf("expected the hold to be converted, got %+v", stored)
+	}
+
+	w = env.do("POST", "/reservations", tokenFor(t, juana.ID, false), map[string]interface{}{"hold_id": hold.ID})
+	if w.Code != http.StatusGone {
+		t.Fatalf("expected 410 when reusing the hold, got %d", w.Code)
+	}
+}
+
+func TestHoldOfAnotherUserCannotBeUsed(t *testing.T) {
+	env := newTestEnv(t)
+	juana := env.createUser(t, "juana")
+	pedro := env.createUser(t, "pedro")
+	hold := holdRequest(env, t, juana.ID, "hotel-1")
+
+	if w := env.do("POST", "/reservations", tokenFor(t, pedro.ID, false), map[string]interface{}{"hold_id": hold.ID}); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+	if w := env.do("GET", fmt.Sprintf("/holds/%d", hold.ID), tokenFor(t, pedro.ID, false), nil); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 reading another user's hold, got %d", w.Code)
+	}
+	if w := env.do("POST", "/holds", tokenFor(t, pedro.ID, false), map[string]interface{}{
+		"hotel_id":  "hotel-1",
+		"check_in":  "2026-07-12T00:00:00Z",
+		"check_out": "2026-07-14T00:00:00Z",
+	}); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for overlapping holds, got %d", w.Code)
+	}
+}
+
+func TestReleaseHold(t *testing.T) {
+	env := newTestEnv(t)
+	juana := env.createUser(t, "juana")
+	hold := holdRequest(env, t, juana.ID, "hotel-1")
+	path := fmt.Sprintf("/holds/%d", hold.ID)
+
+	if w := env.do("DELETE", path, tokenFor(t, juana.ID, false), nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := env.do("DELETE", path, tokenFor(t, juana.ID, false), nil); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 releasing twice, got %d", w.Code)
+	}
+
+	pedro := env.createUser(t, "pedro")
+	holdRequest(env, t, pedro.ID, "hotel-1")
+}
+
+func TestExpiredHoldReleasesInventory(t *testing.T) {
+	env := newTestEnv(t)
+	env.service.holdTTL = -time.Second
+	juana := env.createUser(t, "juana")
+	hold := holdRequest(env, t, juana.ID, "hotel-1")
+
+	w := env.do("GET", fmt.Sprintf("/holds/%d", hold.ID), tokenFor(t, juana.ID, false), nil)
+	var stored InventoryHold
+	json.Unmarshal(w.Body.Bytes(), &stored)
+	if stored.Status != HoldExpired {
+		t.Fatalf("expected the hold to read as expired, got %q", stored.Status)
+	}
+
+	if w := env.do("POST", "/reservations", tokenFor(t, juana.ID, false), map[string]interface{}{"hold_id": hold.ID}); w.Code != http.StatusGone {
+		t.Fatalf("expected 410 for an expired hold, got %d", w.Code)
+	}
+
+	if _, err := env.service.ExpireHolds(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ = env.store.Holds.FindByID(context.Background(), hold.ID)
+	if stored.Status != HoldExpired {
+		t.Fatalf("expected the expiry job to mark the hold, got %q", stored.Status)
+	}
+
+	pedro := env.createUser(t, "pedro")
+	holdRequest(env, t, pedro.ID, "hotel-1")
+}
+
+func TestConcurrentHoldsTakeTheDatesOnce(t *testing.T) {
+	env := newTestEnv(t)
+	juana := env.createUser(t, "juana")
+
+	const attempts = 10
+	codes := make(chan int, attempts)
+	for i := 0; i < attempts; i++ {
+		go func() {
+			codes <- env.do("POST", "/holds", tokenFor(t, juana.ID, false), map[string]interface{}{
+				"hotel_id":  "hotel-1",
+				"check_in":  "2026-07-10T00:00:00Z",
+				"check_out": "2026-07-13T00:00:00Z",
+			}).Code
+		}()
+	}
+
+	created := 0
+	for i := 0; i < attempts; i++ {
+		switch code := <-codes; code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+		default:
+			t.Fatalf("unexpected status %d", code)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("expected exactly one hold for the same dates, got %d", created)
+	}
+}
+
+func TestHoldInvalidatesCachedAvailability(t *testing.T) {
+	env := newTestEnv(t)
+	juana := env.createUser(t, "juana")
+
+	available := func() interface{} {
+		w := env.do("GET", "/availability?hotel_id=hotel-1&check_in=2026-07-11&check_out=2026-07-12", "", nil)
+		var availability map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &availability)
+		return availability["available"]
+	}
+
+	if available() != true {
+		t.Fatal("expected the hotel to be available before the hold")
+	}
+	holdRequest(env, t, juana.ID, "hotel-1")
+	// La consulta anterior quedó cacheada, pero la retención la descarta
+	if available() != false {
+		t.Fatal("expected the hold to show up before the cache expires")
+	}
+}
+
+func TestConcurrentBookingsAndHoldsTakeTheDatesOnce(t *testing.T) {
+	env := newTestEnv(t)
+	juana := env.createUser(t, "juana")
+
+	// Las reservas sin retención compiten con las retenciones por las mismas fechas
+	const attempts = 10
+	codes := make(chan int, attempts)
+	for i := 0; i < attempts; i++ {
+		path := "/reservations"
+		if i%2 == 0 {
+			path = "/holds"
+		}
+		go func(path string) {
+			codes <- env.do("POST", path, tokenFor(t, juana.ID, false), map[string]interface{}{
+				"hotel_id":  "hotel-1",
+				"check_in":  "2026-07-10T00:00:00Z",
+				"check_out": "2026-07-13T00:00:00Z",
+			}).Code
+		}(path)
+	}
+
+	created := 0
+	for i := 0; i < attempts; i++ {
+		switch code := <-codes; code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+		default:
+			t.Fatalf("unexpected status %d", code)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("expected exactly one booking or hold for the same dates, got %d", created)
+	}
+}
+
+func TestCancelInvalidatesCachedAvailability(t *testing.T) {
+	env := newTestEnv(t)
+	juana := env.createUser(t, "juana")
+
+	available := func() interface{} {
+		w := env.do("GET", "/availability?hotel_id=hotel-1&check_in=2026-07-10&check_out=2026-07-11", "", nil)
+		var availability map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &availability)
+		return availability["available"]
+	}
+
+	w := createReservationRequest(env, t, juana.ID, "hotel-1")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var reservation Reservation
+	json.Unmarshal(w.Body.Bytes(), &reservation)
+	if available() != false {
+		t.Fatal("expected the hotel to be unavailable while booked")
+	}
+
+	if w := env.do("POST", fmt.Sprintf("/reservations/%d/cancel", reservation.ID), tokenFor(t, juana.ID, false), nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for the cancel, got %d: %s", w.Code, w.Body.String())
+	}
+	// La consulta anterior quedó cacheada, pero la cancelación la descarta
+	if available() != true {
+		t.Fatal("expected the cancelled dates to show up before the cache expires")
+	}
+}
@@ -0,0 +1,163 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/streadway/amqp"
+
+	"events"
+	"events/amqpx"
+)
+
+const (
+	// HotelEventsExchange es el exchange topic de hotel-service
+	HotelEventsExchange = "hotel_events.topic"
+	// hotelUpdatesQueue es la cola durable del grupo user-service: cada evento
+	// lo procesa una sola de las instancias
+	hotelUpdatesQueue     = "user_service_hotel_updates"
+	hotelEventsBindingKey = "hotel.*"
+	// hotelUpdatesPrefetch es cuántos mensajes sin ack puede tener el consumidor
+	hotelUpdatesPrefetch = 10
+)
+
+// ErrMalformedHotelUpdate indica un mensaje que no tiene sentido reintentar
+var ErrMalformedHotelUpdate = errors.New("malformed hotel update")
+
+// DeclareTopology declara los exchanges donde publica user-service, el de
+// hotel-service y la cola que consume; RabbitMQ la corre cada vez que conecta
+func DeclareTopology(channel *amqp.Channel) error {
+	exchanges := []struct{ name, kind string }{
+		{UserErasuresExchange, "fanout"},
+		{HotelEventsExchange, "topic"},
+		{ReservationEventsExchange, "topic"},
+	}
+	for _, exchange := range exchanges {
+		if err := channel.ExchangeDeclare(exchange.name, exchange.kind, true, false, false, false, nil); err != nil {
+			return err
+		}
+	}
+
+	q, err := channel.QueueDeclare(
+		hotelUpdatesQueue,
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	return channel.QueueBind(q.Name, hotelEventsBindingKey, HotelEventsExchange, false, nil)
+}
+
+// ListenForHotelUpdates mantiene los mappings en sync con el amadeus_id de los
+// hoteles hasta que se cancele ctx. Si se cae la conexión espera a que RabbitMQ
+// reconecte y vuelve a consumir; los mensajes sin ack se reentregan.
+func (s *UserService) ListenForHotelUpdates(ctx context.Context, rabbit *amqpx.RabbitMQ) {
+	for {
+		channel, err := rabbit.WaitChannel(ctx)
+		if err != nil {
+			return
+		}
+
+		if err := s.consumeHotelUpdates(channel); err != nil {
+			log.Printf("Error consuming hotel updates: %v", err)
+		} else {
+			log.Printf("Hotel updates consumer stopped, waiting for RabbitMQ")
+		}
+		channel.Close()
+	}
+}
+
+// consumeHotelUpdates usa ack manual: cada mensaje se confirma recién cuando
+// el mapping quedó guardado. Los que fallan se reencolan, salvo los mal
+// formados, que se descartan. Vuelve cuando se cierra el canal.
+func (s *UserService) consumeHotelUpdates(channel *amqp.Channel) error {
+	if err := channel.Qos(hotelUpdatesPrefetch, 0, false); err != nil {
+		return err
+	}
+
+	msgs, err := channel.Consume(
+		hotelUpdatesQueue,
+		"",
+		false,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	for msg := range msgs {
+		err := s.HandleHotelUpdate(msg.Body)
+		switch {
+		case err == nil:
+			msg.Ack(false)
+		case errors.Is(err, ErrMalformedHotelUpdate):
+			log.Printf("Discarding hotel update: %v", err)
+			msg.Ack(false)
+		default:
+			log.Printf("Error handling hotel update, requeueing: %v", err)
+			msg.Nack(false, true)
+		}
+	}
+	return nil
+}
+
+// HandleHotelUpdate aplica a los mappings un evento hotel.* de hotel-service
+func (s *UserService) HandleHotelUpdate(body []byte) error {
+	envelope, err := events.Decode(body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedHotelUpdate, err)
+	}
+
+	ctx := context.Background()
+	switch envelope.Type {
+	case events.HotelCreated, events.HotelUpdated:
+		var hotel events.HotelPayload
+		if err := envelope.DecodePayload(&hotel); err != nil {
+			return fmt.Errorf("%w: %v", ErrMalformedHotelUpdate, err)
+		}
+		if hotel.AmadeusID != "" {
+			mapping := HotelMapping{InternalID: hotel.ID, AmadeusID: hotel.AmadeusID, Source: MappingSourceHotel}
+			if err := s.mappings.Set(ctx, &mapping); err != nil {
+				return fmt.Errorf("saving mapping for hotel %s: %v", hotel.ID, err)
+			}
+			log.Printf("Hotel mapping synced: %s -> %s", hotel.ID, hotel.AmadeusID)
+			return nil
+		}
+		// Si el hotel dejó de tener amadeus_id, el mapping que venía de él ya no vale
+		return s.deleteHotelSourcedMapping(ctx, hotel.ID)
+	case events.HotelDeleted:
+		var hotel events.HotelDeletedPayload
+		if err := envelope.DecodePayload(&hotel); err != nil {
+			return fmt.Errorf("%w: %v", ErrMalformedHotelUpdate, err)
+		}
+		return s.deleteHotelSourcedMapping(ctx, hotel.ID)
+	default:
+		return fmt.Errorf("%w: unexpected hotel event type %q", ErrMalformedHotelUpdate, envelope.Type)
+	}
+}
+
+func (s *UserService) deleteHotelSourcedMapping(ctx context.Context, hotelID string) error {
+	mapping, err := s.mappings.Get(ctx, hotelID)
+	if err == ErrMappingNotFound || (err == nil && mapping.Source != MappingSourceHotel) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.mappings.Delete(ctx, hotelID); err != nil && err != ErrMappingNotFound {
+		return fmt.Errorf("deleting mapping for hotel %s: %v", hotelID, err)
+	}
+	log.Printf("Hotel mapping removed: %s", hotelID)
+	return nil
+}
@@ -0,0 +1,164 @@
+package userservice
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultPendingReservationTTL es cuánto puede quedar una reserva pending
+// esperando que Amadeus la confirme antes de vencer
+const DefaultPendingReservationTTL = 24 * time.Hour
+
+// Columnas de fecha por las que filtra ReservationRepository.ListByStatusBefore
+const (
+	ReservationCreatedAt = "created_at"
+	ReservationCheckIn   = "check_in"
+	ReservationCheckOut  = "check_out"
+)
+
+// LifecycleJobs son los jobs que hacen avanzar las reservas después de confirmadas
+func (s *UserService) LifecycleJobs() []Job {
+	return []Job{
+		{Name: "expire_pending_reservations", Interval: 15 * time.Minute, Run: s.ExpireStalePendingReservations},
+		{Name: "mark_no_shows", Interval: time.Hour, Run: s.MarkNoShows},
+		{Name: "complete_stays", Interval: time.Hour, Run: s.CompleteStays},
+		{Name: "expire_holds", Interval: time.Minute, Run: s.ExpireHolds},
+	}
+}
+
+// Jobs son todos los jobs periódicos de user-service según la configuración:
+// los del ciclo de vida y los que retoman lo que quedó a medias por una falla
+func (s *UserService) Jobs() []Job {
+	jobs := append(s.LifecycleJobs(), Job{
+		Name:     "resume_booking_sagas",
+		Interval: time.Minute,
+		Run: func(ctx context.Context) (int, error) {
+			return s.ResumeBookingSagas(ctx, DefaultSagaStaleAfter)
+		},
+	}, Job{Name: "resume_erasure_jobs", Interval: time.Minute, Run: s.ResumeErasureJobs})
+	// Las reservas cuyo resultado en Amadeus no se supo quedan pending con
+	// cualquier política
+	if s.amadeusPolicy == AmadeusFailPending || s.amadeus.Configured() {
+		jobs = append(jobs, Job{Name: "revalidate_pending_reservations", Interval: time.Minute, Run: s.RevalidatePendingReservations})
+	}
+	if s.payments != nil && s.paymentPolicy == PaymentFailPending {
+		jobs = append(jobs, Job{Name: "capture_pending_payments", Interval: time.Minute, Run: s.CapturePendingPayments})
+	}
+	return jobs
+}
+
+// startOfDay es la medianoche UTC de t; check_in y check_out se guardan como DATE
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// ExpireStalePendingReservations vence las reservas que siguen pending después
+// de pendingTTL y libera su pago. Si alguna llegó a reservarse en Amadeus sin
+// que se supiera, la busca por referencia para cancelarla.
+func (s *UserService) ExpireStalePendingReservations(ctx context.Context) (int, error) {
+	reservations, err := s.reservations.ListByStatusBefore(ctx, "pending", ReservationCreatedAt, time.Now().Add(-s.pendingTTL))
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, reservation := range reservations {
+		if err := s.releasePayment(ctx, reservation.ID); err != nil {
+			log.Printf("Error releasing payment of stale reservation %d: %v", reservation.ID, err)
+			continue
+		}
+		if reservation.AmadeusBookingID == "" && s.amadeus.Configured() {
+			if err := s.recoverAmadeusBooking(ctx, &reservation); err != nil && err != ErrAmadeusBookingNotFound {
+				log.Printf("Error looking up stale reservation %d upstream: %v", reservation.ID, err)
+				continue
+			}
+		}
+		if err := s.cancelWithAmadeus(ctx, reservation); err != nil {
+			log.Printf("Error cancelling stale reservation %d upstream: %v", reservation.ID, err)
+			continue
+		}
+
+		reservation.Status = "expired"
+		if err := s.reservations.UpdateValidation(ctx, &reservation); err != nil {
+			return expired, err
+		}
+		s.invalidateAvailability(reservation.HotelID)
+		s.publishStatusChange(ctx, reservation, "pending")
+		expired++
+	}
+	return expired, nil
+}
+
+// MarkNoShows marca no_show las reservas confirmadas sin check-in cuyo día de entrada ya pasó
+func (s *UserService) MarkNoShows(ctx context.Context) (int, error) {
+	return s.transitionBefore(ctx, "confirmed", ReservationCheckIn, "no_show")
+}
+
+// CompleteStays marca completed las estadías con check-in cuyo día de salida ya pasó
+func (s *UserService) CompleteStays(ctx context.Context) (int, error) {
+	return s.transitionBefore(ctx, "checked_in", ReservationCheckOut, "completed")
+}
+
+func (s *UserService) transitionBefore(ctx context.Context, from, column, to string) (int, error) {
+	reservations, err := s.reservations.ListByStatusBefore(ctx, from, column, startOfDay(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+
+	for i, reservation := range reservations {
+		reservation.Status = to
+		if err := s.reservations.UpdateValidation(ctx, &reservation); err != nil {
+			return i, err
+		}
+		s.publishStatusChange(ctx, reservation, from)
+	}
+	return len(reservations), nil
+}
+
+// checkInReservation registra que el huésped llegó; sin esto la reserva pasa a no_show
+func (s *UserService) checkInReservation(c *gin.Context) {
+	isAdmin, _ := c.Get("is_admin")
+	if !isAdmin.(bool) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	reservationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		return
+	}
+
+	reservation, err := s.reservations.FindByID(c.Request.Context(), reservationID)
+	if err == ErrReservationNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reservation not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if reservation.Status != "confirmed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only confirmed reservations can be checked in, this one is " + reservation.Status})
+		return
+	}
+	if time.Now().Before(reservation.CheckIn) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Check-in date has not been reached"})
+		return
+	}
+
+	reservation.Status = "checked_in"
+	if err := s.reservations.UpdateValidation(c.Request.Context(), &reservation); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.publishStatusChange(c.Request.Context(), reservation, "confirmed")
+
+	c.JSON(http.StatusOK, reservation)
+}
@@ -0,0 +1,192 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsJobOnOneReplica(t *testing.T) {
+	store := NewMemoryStore()
+	first := NewScheduler(store.Jobs, "replica-1")
+	second := NewScheduler(store.Jobs, "replica-2")
+	ctx := context.Background()
+
+	runs := 0
+	job := Job{Name: "count", Interval: time.Hour, Run: func(ctx context.Context) (int, error) {
+		runs++
+		return 3, nil
+	}}
+
+	if ran, err := first.RunOnce(ctx, job); !ran || err != nil {
+		t.Fatalf("expected the first replica to run the job, got %v, %v", ran, err)
+	}
+	if ran, _ := second.RunOnce(ctx, job); ran {
+		t.Fatal("expected the second replica to skip a job leased by the first")
+	}
+	if runs != 1 {
+		t.Fatalf("expected one run, got %d", runs)
+	}
+
+	failing := Job{Name: "failing", Interval: -time.Second, Run: func(ctx context.Context) (int, error) {
+		return 0, errors.New("boom")
+	}}
+	first.RunOnce(ctx, failing)
+	// Con el lease vencido la toma cualquier réplica
+	if ran, _ := second.RunOnce(ctx, failing); !ran {
+		t.Fatal("expected an expired lease to be taken over")
+	}
+
+	recorded, _ := store.Jobs.ListRuns(ctx, "", 10)
+	if len(recorded) != 3 || recorded[0].Owner != "replica-2" || recorded[0].Status != JobRunFailed || recorded[0].Error != "boom" {
+		t.Fatalf("expected the failed run to be recorded last, got %+v", recorded)
+	}
+	if recorded[2].Job != "count" || recorded[2].Processed != 3 || recorded[2].Status != JobRunSucceeded || recorded[2].FinishedAt == nil {
+		t.Fatalf("expected the first run to be recorded as succeeded, got %+v", recorded[2])
+	}
+}
+
+func TestSchedulerRenewsLeaseWhileJobRuns(t *testing.T) {
+	store := NewMemoryStore()
+	first := NewScheduler(store.Jobs, "replica-1")
+	first.lease = 30 * time.Millisecond
+	second := NewScheduler(store.Jobs, "replica-2")
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	slow := Job{Name: "slow", Interval: -time.Second, Run: func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		return 0, nil
+	}}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		first.RunOnce(ctx, slow)
+	}()
+
+	<-started
+	// La corrida dura varias veces el lease y otra réplica igual no la puede tomar
+	for i := 0; i < 5; i++ {
+		time.Sleep(first.lease)
+		if ran, _ := second.RunOnce(ctx, Job{Name: "slow", Run: slow.Run}); ran {
+			t.Fatal("expected the lease to be renewed while the job runs")
+		}
+	}
+	close(release)
+	<-done
+
+	if ran, _ := second.RunOnce(ctx, Job{Name: "slow", Interval: time.Hour, Run: func(ctx context.Context) (int, error) {
+		return 0, nil
+	}}); !ran {
+		t.Fatal("expected the lease to be free once the run finished past its interval")
+	}
+}
+
+func TestJobsDependOnConfig(t *testing.T) {
+	names := func(service *UserService) map[string]bool {
+		found := map[string]bool{}
+		for _, job := range service.Jobs() {
+			found[job.Name] = true
+		}
+		return found
+	}
+
+	service := NewUserService(NewMemoryStore().Repositories(), nil, nil, Config{})
+	jobs := names(service)
+	if !jobs["resume_booking_sagas"] || !jobs["resume_erasure_jobs"] || !jobs["expire_holds"] || jobs["revalidate_pending_reservations"] || jobs["capture_pending_payments"] {
+		t.Fatalf("unexpected default jobs %v", jobs)
+	}
+
+	service = NewUserService(NewMemoryStore().Repositories(), nil, nil, Config{
+		AmadeusFailurePolicy: AmadeusFailPending,
+		PaymentProvider:      NewFakePaymentProvider(),
+		PaymentFailurePolicy: PaymentFailPending,
+	})
+	jobs = names(service)
+	if !jobs["revalidate_pending_reservations"] || !jobs["capture_pending_payments"] {
+		t.Fatalf("expected the retry jobs to be registered, got %v", jobs)
+	}
+
+	// Con Amadeus configurado hay que revalidar aunque la política sea fail-open
+	service = NewUserService(NewMemoryStore().Repositories(), nil, nil, Config{AmadeusClientID: "id", AmadeusSecret: "secret"})
+	if jobs = names(service); !jobs["revalidate_pending_reservations"] {
+		t.Fatalf("expected revalidation with Amadeus configured, got %v", jobs)
+	}
+}
+
+func TestLifecycleJobs(t *testing.T) {
+	env, provider := newPaymentTestEnv(t)
+	ctx := context.Background()
+	yesterday := startOfDay(time.Now()).AddDate(0, 0, -1)
+
+	stalePending := interruptedBooking(t, env, provider, "pending", SagaStepCommitted)
+	env.store.mu.Lock()
+	env.store.reservations[stalePending.ID-1].CreatedAt = time.Now().Add(-2 * DefaultPendingReservationTTL)
+	env.store.mu.Unlock()
+	freshPending := Reservation{UserID: 1, HotelID: "hotel-2", CheckIn: date("2026-07-10"), CheckOut: date("2026-07-12"), Status: "pending"}
+	noShow := Reservation{UserID: 1, HotelID: "hotel-3", CheckIn: yesterday, CheckOut: yesterday.AddDate(0, 0, 3), Status: "confirmed"}
+	upcoming := Reservation{UserID: 1, HotelID: "hotel-4", CheckIn: yesterday.AddDate(0, 0, 2), CheckOut: yesterday.AddDate(0, 0, 4), Status: "confirmed"}
+	stay := Reservation{UserID: 1, HotelID: "hotel-5", CheckIn: yesterday.AddDate(0, 0, -2), CheckOut: yesterday, Status: "checked_in"}
+	for _, reservation := range []*Reservation{&freshPending, &noShow, &upcoming, &stay} {
+		env.store.Reservations.Create(ctx, reservation)
+	}
+
+	for _, job := range env.service.LifecycleJobs() {
+		if _, err := job.Run(ctx); err != nil {
+			t.Fatalf("job %s failed: %v", job.Name, err)
+		}
+	}
+
+	expected := map[int]string{
+		stalePending.ID: "expired",
+		freshPending.ID: "pending",
+		noShow.ID:       "no_show",
+		upcoming.ID:     "confirmed",
+		stay.ID:         "completed",
+	}
+	for id, status := range expected {
+		if stored, _ := env.store.Reservations.FindByID(ctx, id); stored.Status != status {
+			t.Fatalf("expected reservation %d to be %s, got %s", id, status, stored.Status)
+		}
+	}
+
+	payment, _ := env.store.Payments.FindByReservation(ctx, stalePending.ID)
+	if payment.Status != PaymentVoided {
+		t.Fatalf("expected the stale reservation's payment to be voided, got %+v", payment)
+	}
+}
+
+func TestCheckInReservation(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	today := startOfDay(time.Now())
+
+	arriving := Reservation{UserID: 1, HotelID: "hotel-1", CheckIn: today, CheckOut: today.AddDate(0, 0, 2), Status: "confirmed"}
+	future := Reservation{UserID: 1, HotelID: "hotel-2", CheckIn: today.AddDate(0, 0, 5), CheckOut: today.AddDate(0, 0, 7), Status: "confirmed"}
+	env.store.Reservations.Create(ctx, &arriving)
+	env.store.Reservations.Create(ctx, &future)
+
+	path := fmt.Sprintf("/reservations/%d/check-in", arriving.ID)
+	if w := env.do("POST", path, tokenFor(t, arriving.UserID, false), nil); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-admins, got %d", w.Code)
+	}
+	if w := env.do("POST", path, tokenFor(t, 1, true), nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := env.do("POST", path, tokenFor(t, 1, true), nil); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 checking in twice, got %d", w.Code)
+	}
+	if w := env.do("POST", fmt.Sprintf("/reservations/%d/check-in", future.ID), tokenFor(t, 1, true), nil); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 before the check-in date, got %d", w.Code)
+	}
+
+	// Un huésped alojado sigue ocupando las fechas
+	if count, _ := env.service.countBooked(ctx, "hotel-1", today, today.AddDate(0, 0, 1)); count != 1 {
+		t.Fatalf("expected the checked-in stay to hold inventory, got %d", count)
+	}
+}
@@ -0,0 +1,243 @@
+package userservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MappingSourceManual = "manual"
+	// MappingSourceHotel es un mapping que viene del amadeus_id del documento del hotel
+	MappingSourceHotel = "hotel"
+)
+
+type HotelMapping struct {
+	InternalID string    `json:"hotel_id"`
+	AmadeusID  string    `json:"amadeus_id"`
+	Source     string    `json:"source"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// UnmappedHotel es un hotel de hotel-service que no tiene mapping a Amadeus
+type UnmappedHotel struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	City      string `json:"city"`
+	AmadeusID string `json:"amadeus_id,omitempty"`
+}
+
+func requireAdmin(c *gin.Context) bool {
+	isAdmin, _ := c.Get("is_admin")
+	if !isAdmin.(bool) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return false
+	}
+	return true
+}
+
+func (s *UserService) listMappings(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	mappings, err := s.mappings.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, mappings)
+}
+
+func (s *UserService) getMapping(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	mapping, err := s.mappings.Get(c.Request.Context(), c.Param("hotel_id"))
+	if err == ErrMappingNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mapping not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, mapping)
+}
+
+func (s *UserService) setMapping(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var request struct {
+		AmadeusID string `json:"amadeus_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hotelID := c.Param("hotel_id")
+	existing, err := s.mappings.Get(c.Request.Context(), hotelID)
+	if err != nil && err != ErrMappingNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// El amadeus_id del hotel tiene prioridad: se cambia editando el hotel
+	if err == nil && existing.Source == MappingSourceHotel {
+		c.JSON(http.StatusConflict, gin.H{"error": "Mapping comes from the hotel document; update the hotel's amadeus_id instead"})
+		return
+	}
+
+	mapping := HotelMapping{InternalID: hotelID, AmadeusID: request.AmadeusID, Source: MappingSourceManual}
+	if err := s.mappings.Set(c.Request.Context(), &mapping); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, mapping)
+}
+
+func (s *UserService) deleteMapping(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	err := s.mappings.Delete(c.Request.Context(), c.Param("hotel_id"))
+	if err == ErrMappingNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mapping not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Mapping deleted successfully"})
+}
+
+// lookupAmadeusHotels busca IDs de Amadeus por city_code (IATA) o por latitude/longitude
+func (s *UserService) lookupAmadeusHotels(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	if !s.amadeus.Configured() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Amadeus not configured"})
+		return
+	}
+
+	var lookup func(ctx context.Context, token string) ([]AmadeusHotel, error)
+	if cityCode := c.Query("city_code"); cityCode != "" {
+		lookup = func(ctx context.Context, token string) ([]AmadeusHotel, error) {
+			return s.amadeus.HotelsByCity(ctx, token, cityCode)
+		}
+	} else {
+		latitude, latErr := strconv.ParseFloat(c.Query("latitude"), 64)
+		longitude, lngErr := strconv.ParseFloat(c.Query("longitude"), 64)
+		if latErr != nil || lngErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "city_code or latitude and longitude are required"})
+			return
+		}
+
+		radius := 5
+		if value := c.Query("radius"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid radius"})
+				return
+			}
+			radius = parsed
+		}
+
+		lookup = func(ctx context.Context, token string) ([]AmadeusHotel, error) {
+			return s.amadeus.HotelsByGeocode(ctx, token, latitude, longitude, radius)
+		}
+	}
+
+	if !s.amadeus.Breaker.Allow() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": ErrCircuitOpen.Error()})
+		return
+	}
+
+	var hotels []AmadeusHotel
+	err := s.withToken(c.Request.Context(), func(token string) error {
+		var err error
+		hotels, err = lookup(c.Request.Context(), token)
+		return err
+	})
+	if err != nil {
+		s.amadeus.Breaker.Failure()
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	s.amadeus.Breaker.Success()
+
+	c.JSON(http.StatusOK, hotels)
+}
+
+// getUnmappedHotels compara los hoteles de hotel-service con los mappings
+func (s *UserService) getUnmappedHotels(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	hotels, err := s.fetchHotels(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	mappings, err := s.mappings.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	mapped := map[string]bool{}
+	for _, mapping := range mappings {
+		mapped[mapping.InternalID] = true
+	}
+
+	unmapped := []UnmappedHotel{}
+	for _, hotel := range hotels {
+		if !mapped[hotel.ID] {
+			unmapped = append(unmapped, hotel)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"hotels": unmapped, "total": len(unmapped)})
+}
+
+func (s *UserService) fetchHotels(ctx context.Context) ([]UnmappedHotel, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.hotelServiceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching hotels: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching hotels: hotel-service returned status %d", resp.StatusCode)
+	}
+
+	var hotels []UnmappedHotel
+	if err := json.NewDecoder(resp.Body).Decode(&hotels); err != nil {
+		return nil, fmt.Errorf("decoding hotels: %v", err)
+	}
+	return hotels, nil
+}
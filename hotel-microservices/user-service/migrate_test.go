@@ -0,0 +1,134 @@
+package userservice
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		expected []string
+	}{
+		{
+			name:     "one statement per line",
+			script:   "CREATE TABLE a (id INT);\nDROP TABLE b;\n",
+			expected: []string{"CREATE TABLE a (id INT)", "DROP TABLE b"},
+		},
+		{
+			name:     "statement across lines",
+			script:   "CREATE TABLE a (\n\tid INT\n);",
+			expected: []string{"CREATE TABLE a (\n\tid INT\n)"},
+		},
+		{
+			name:     "several statements on one line",
+			script:   "DELETE FROM a; DELETE FROM b;",
+			expected: []string{"DELETE FROM a", "DELETE FROM b"},
+		},
+		{
+			name:     "trailing statement without semicolon",
+			script:   "DELETE FROM a;\nDELETE FROM b\n",
+			expected: []string{"DELETE FROM a", "DELETE FROM b"},
+		},
+		{
+			name:     "semicolons inside string literals",
+			script:   "INSERT INTO a VALUES ('x;y', \"z;\");\nINSERT INTO a VALUES ('end;\nof line;');",
+			expected: []string{"INSERT INTO a VALUES ('x;y', \"z;\")", "INSERT INTO a VALUES ('end;\nof line;')"},
+		},
+		{
+			name:     "escaped and doubled quotes",
+			script:   `INSERT INTO a VALUES ('it\'s;', 'it''s;'); DELETE FROM a;`,
+			expected: []string{`INSERT INTO a VALUES ('it\'s;', 'it''s;')`, "DELETE FROM a"},
+		},
+		{
+			name:     "semicolon inside a quoted identifier",
+			script:   "SELECT `a;b` FROM c;",
+			expected: []string{"SELECT `a;b` FROM c"},
+		},
+		{
+			name:     "line comments are dropped",
+			script:   "-- crea la tabla; con cuidado\nCREATE TABLE a (id INT); # comentario;\n-- fin",
+			expected: []string{"CREATE TABLE a (id INT)"},
+		},
+		{
+			name:     "block comments are dropped",
+			script:   "/* uno; dos */ DELETE /* tres; */ FROM a;",
+			expected: []string{"DELETE   FROM a"},
+		},
+		{
+			name:     "double dash without space is an operator",
+			script:   "UPDATE a SET n = n--1;",
+			expected: []string{"UPDATE a SET n = n--1"},
+		},
+		{
+			name:     "only comments and blanks",
+			script:   "\n-- nada\n;;\n",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func migrationFS(names ...string) fstest.MapFS {
+	files := fstest.MapFS{}
+	for _, name := range names {
+		files["migrations/"+name] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	}
+	return files
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFS(
+		"0002_add_b.down.sql", "0002_add_b.up.sql",
+		"0001_create_a.up.sql", "0001_create_a.down.sql",
+		"README.md",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[0].Name != "create_a" || migrations[1].Version != 2 {
+		t.Fatalf("expected the migrations in order, got %+v", migrations)
+	}
+
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		err   string
+	}{
+		{"duplicate version", migrationFS("0001_create_a.up.sql", "0001_create_a.down.sql", "0001_create_b.up.sql", "0001_create_b.down.sql"), "conflicting names"},
+		{"missing version", migrationFS("0001_create_a.up.sql", "0001_create_a.down.sql", "0003_create_c.up.sql", "0003_create_c.down.sql"), "missing migration 0002"},
+		{"not starting at one", migrationFS("0002_create_b.up.sql", "0002_create_b.down.sql"), "missing migration 0001"},
+		{"missing down file", migrationFS("0001_create_a.up.sql"), "both up and down"},
+		{"invalid version", migrationFS("one_create_a.up.sql", "one_create_a.down.sql"), "invalid migration version"},
+		{"invalid name", migrationFS("0001.up.sql", "0001.down.sql"), "invalid migration file name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadMigrations(tt.files); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, migration := range migrations {
+		if len(splitStatements(migration.Up)) == 0 || len(splitStatements(migration.Down)) == 0 {
+			t.Errorf("migration %04d_%s has an empty direction", migration.Version, migration.Name)
+		}
+	}
+}
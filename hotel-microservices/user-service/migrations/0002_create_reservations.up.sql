@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS reservations (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	hotel_id VARCHAR(50) NOT NULL,
+	check_in DATE NOT NULL,
+	check_out DATE NOT NULL,
+	status VARCHAR(20) DEFAULT 'pending',
+	amadeus_id VARCHAR(100),
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id)
+);
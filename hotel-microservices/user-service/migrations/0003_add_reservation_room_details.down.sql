@@ -0,0 +1 @@
+ALTER TABLE reservations DROP COLUMN room_type, DROP COLUMN rooms, DROP COLUMN guests;
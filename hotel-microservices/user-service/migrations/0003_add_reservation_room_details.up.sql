@@ -0,0 +1,15 @@
+-- Las bases creadas con el viejo init.sql no tienen estas columnas
+SET @stmt = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE reservations ADD COLUMN guests INT DEFAULT 2', 'DO 0') FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'reservations' AND column_name = 'guests');
+PREPARE add_column FROM @stmt;
+EXECUTE add_column;
+DEALLOCATE PREPARE add_column;
+
+SET @stmt = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE reservations ADD COLUMN rooms INT DEFAULT 1', 'DO 0') FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'reservations' AND column_name = 'rooms');
+PREPARE add_column FROM @stmt;
+EXECUTE add_column;
+DEALLOCATE PREPARE add_column;
+
+SET @stmt = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE reservations ADD COLUMN room_type VARCHAR(100)', 'DO 0') FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'reservations' AND column_name = 'room_type');
+PREPARE add_column FROM @stmt;
+EXECUTE add_column;
+DEALLOCATE PREPARE add_column;
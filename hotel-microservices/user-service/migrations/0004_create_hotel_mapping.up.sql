@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS hotel_mapping (
+	internal_id VARCHAR(50) PRIMARY KEY,
+	amadeus_id VARCHAR(100) NOT NULL
+);
+
+INSERT IGNORE INTO hotel_mapping (internal_id, amadeus_id) VALUES
+('1', 'YXPARKPR'),
+('2', 'MCLONGHM'),
+('3', 'ADPARADT');
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS erasure_jobs (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	requested_by INT NOT NULL,
+	status VARCHAR(20) DEFAULT 'pending',
+	error TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	completed_at TIMESTAMP NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id)
+);
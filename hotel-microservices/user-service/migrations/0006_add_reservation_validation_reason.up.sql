@@ -0,0 +1 @@
+ALTER TABLE reservations ADD COLUMN validation_reason VARCHAR(255) NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE reservations DROP COLUMN confirmation_number;
+ALTER TABLE reservations DROP COLUMN amadeus_booking_id;
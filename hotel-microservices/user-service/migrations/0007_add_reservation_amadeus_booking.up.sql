@@ -0,0 +1,2 @@
+ALTER TABLE reservations ADD COLUMN amadeus_booking_id VARCHAR(100) NULL;
+ALTER TABLE reservations ADD COLUMN confirmation_number VARCHAR(100) NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE hotel_mapping DROP COLUMN updated_at;
+ALTER TABLE hotel_mapping DROP COLUMN source;
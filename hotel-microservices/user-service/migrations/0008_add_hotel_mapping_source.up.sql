@@ -0,0 +1,3 @@
+-- source indica si el mapping lo cargó un admin ('manual') o viene del documento del hotel ('hotel')
+ALTER TABLE hotel_mapping ADD COLUMN source VARCHAR(20) NOT NULL DEFAULT 'manual';
+ALTER TABLE hotel_mapping ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS payments (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	reservation_id INT NOT NULL,
+	provider VARCHAR(50) NOT NULL,
+	authorization_id VARCHAR(100) NOT NULL,
+	amount DECIMAL(10, 2) NOT NULL,
+	currency VARCHAR(3) NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	error VARCHAR(255) NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	UNIQUE KEY uq_payments_reservation (reservation_id),
+	KEY idx_payments_status (status),
+	FOREIGN KEY (reservation_id) REFERENCES reservations(id)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS booking_sagas (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	reservation_id INT NOT NULL,
+	step VARCHAR(30) NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	error VARCHAR(255) NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	UNIQUE KEY uq_booking_sagas_reservation (reservation_id),
+	KEY idx_booking_sagas_status (status, updated_at),
+	FOREIGN KEY (reservation_id) REFERENCES reservations(id)
+);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS inventory_holds (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	hotel_id VARCHAR(50) NOT NULL,
+	check_in DATE NOT NULL,
+	check_out DATE NOT NULL,
+	rooms INT NOT NULL DEFAULT 1,
+	status VARCHAR(20) NOT NULL,
+	reservation_id INT NULL,
+	expires_at DATETIME NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	KEY idx_inventory_holds_hotel (hotel_id, status, expires_at),
+	KEY idx_inventory_holds_status (status, expires_at),
+	FOREIGN KEY (user_id) REFERENCES users(id),
+	FOREIGN KEY (reservation_id) REFERENCES reservations(id)
+);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS job_locks (
+	job VARCHAR(100) PRIMARY KEY,
+	owner VARCHAR(255) NOT NULL,
+	locked_until DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS job_runs (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	job VARCHAR(100) NOT NULL,
+	owner VARCHAR(255) NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	processed INT NOT NULL DEFAULT 0,
+	error VARCHAR(255) NULL,
+	started_at DATETIME NOT NULL,
+	finished_at DATETIME NULL,
+	KEY idx_job_runs_job (job, id)
+);
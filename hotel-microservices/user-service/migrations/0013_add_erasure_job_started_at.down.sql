@@ -0,0 +1 @@
+ALTER TABLE erasure_jobs DROP COLUMN started_at;
@@ -0,0 +1 @@
+ALTER TABLE erasure_jobs ADD COLUMN started_at TIMESTAMP NULL;
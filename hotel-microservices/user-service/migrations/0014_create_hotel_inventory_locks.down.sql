@@ -0,0 +1 @@
+DROP TABLE IF EXISTS hotel_inventory_locks;
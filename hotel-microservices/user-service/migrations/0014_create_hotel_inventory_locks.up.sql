@@ -0,0 +1,3 @@
+CREATE TABLE IF NOT EXISTS hotel_inventory_locks (
+	hotel_id VARCHAR(50) PRIMARY KEY
+);
@@ -0,0 +1 @@
+ALTER TABLE payments ADD COLUMN claimed_until TIMESTAMP NULL;
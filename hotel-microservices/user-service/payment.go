@@ -0,0 +1,366 @@
+package userservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrPaymentDeclined es un rechazo definitivo del medio de pago; no tiene sentido reintentar
+	ErrPaymentDeclined = errors.New("payment declined")
+	ErrPaymentNotFound = errors.New("payment not found")
+)
+
+// DefaultPaymentCurrency es la moneda de los precios de hotel-service
+const DefaultPaymentCurrency = "USD"
+
+// paymentClaimTTL es cuánto dura el reclamo de un pago para cobrarlo
+const paymentClaimTTL = 5 * time.Minute
+
+// Estados de Payment.Status
+const (
+	PaymentAuthorized    = "authorized"
+	PaymentCaptured      = "captured"
+	PaymentCaptureFailed = "capture_failed"
+	// PaymentCapturing es un pago que un worker reclamó para cobrar; si el
+	// worker se cae, otro lo puede reclamar cuando vence ClaimedUntil
+	PaymentCapturing = "capturing"
+	PaymentVoided    = "voided"
+	PaymentRefunded  = "refunded"
+)
+
+// PaymentProvider es la pasarela de pagos. Authorize reserva el monto en la
+// tarjeta y devuelve el ID de la autorización; Capture la cobra, Void la
+// libera sin cobrar y Refund devuelve un cobro ya capturado.
+type PaymentProvider interface {
+	Name() string
+	Authorize(ctx context.Context, request PaymentRequest) (string, error)
+	Capture(ctx context.Context, authorizationID string, amount float64) error
+	Void(ctx context.Context, authorizationID string) error
+	Refund(ctx context.Context, authorizationID string, amount float64) error
+}
+
+type PaymentRequest struct {
+	Amount      float64
+	Currency    string
+	Token       string
+	Description string
+}
+
+// Payment es el cobro asociado a una reserva
+type Payment struct {
+	ID              int       `json:"id"`
+	ReservationID   int       `json:"reservation_id"`
+	Provider        string    `json:"provider"`
+	AuthorizationID string    `json:"authorization_id"`
+	Amount          float64   `json:"amount"`
+	Currency        string    `json:"currency"`
+	Status          string    `json:"status"`
+	Error           string    `json:"error,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	// ClaimedUntil es hasta cuándo vale el reclamo de un pago capturing
+	ClaimedUntil *time.Time `json:"claimed_until,omitempty"`
+}
+
+// PaymentFailurePolicy decide qué pasa con la reserva si falla el cobro
+type PaymentFailurePolicy string
+
+const (
+	// PaymentFailPending deja la reserva en pending_payment y reintenta el cobro más tarde
+	PaymentFailPending PaymentFailurePolicy = "pending"
+	// PaymentFailRollback cancela la reserva y libera la autorización
+	PaymentFailRollback PaymentFailurePolicy = "rollback"
+)
+
+func ParsePaymentFailurePolicy(value string) (PaymentFailurePolicy, error) {
+	switch PaymentFailurePolicy(value) {
+	case "":
+		return PaymentFailPending, nil
+	case PaymentFailPending, PaymentFailRollback:
+		return PaymentFailurePolicy(value), nil
+	default:
+		return "", fmt.Errorf("unknown payment failure policy %q (expected pending or rollback)", value)
+	}
+}
+
+// NewPaymentProvider crea el provider configurado; "" deshabilita los pagos
+func NewPaymentProvider(name string) (PaymentProvider, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "fake":
+		return NewFakePaymentProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q (expected fake)", name)
+	}
+}
+
+// reservationAmount calcula el total de la estadía con el precio por noche de hotel-service
+func (s *UserService) reservationAmount(ctx context.Context, reservation Reservation) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.hotelServiceURL+"/"+reservation.HotelID, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("fetching hotel price: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("fetching hotel price: hotel-service returned status %d", resp.StatusCode)
+	}
+
+	var hotel struct {
+		PricePerNight float64 `json:"pricePerNight"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&hotel); err != nil {
+		return 0, fmt.Errorf("decoding hotel price: %v", err)
+	}
+
+	nights := int(math.Round(reservation.CheckOut.Sub(reservation.CheckIn).Hours() / 24))
+	amount := hotel.PricePerNight * float64(atLeastOne(nights)) * float64(atLeastOne(reservation.Rooms))
+	return math.Round(amount*100) / 100, nil
+}
+
+// authorizePayment reserva el monto de la estadía antes de reservar en
+// Amadeus. Devuelve nil si los pagos están deshabilitados o el hotel no tiene precio.
+func (s *UserService) authorizePayment(ctx context.Context, reservation Reservation, token string) (*Payment, error) {
+	if s.payments == nil {
+		return nil, nil
+	}
+
+	amount, err := s.reservationAmount(ctx, reservation)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	authorizationID, err := s.payments.Authorize(ctx, PaymentRequest{
+		Amount:      amount,
+		Currency:    DefaultPaymentCurrency,
+		Token:       token,
+		Description: fmt.Sprintf("Hotel %s, %s to %s", reservation.HotelID, reservation.CheckIn.Format("2006-01-02"), reservation.CheckOut.Format("2006-01-02")),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Payment{
+		Provider:        s.payments.Name(),
+		AuthorizationID: authorizationID,
+		Amount:          amount,
+		Currency:        DefaultPaymentCurrency,
+		Status:          PaymentAuthorized,
+	}, nil
+}
+
+// voidAuthorization libera una autorización que no llegó a tener reserva
+func (s *UserService) voidAuthorization(payment *Payment) {
+	if payment == nil {
+		return
+	}
+	if err := s.payments.Void(context.Background(), payment.AuthorizationID); err != nil {
+		log.Printf("Error voiding payment authorization %s: %v", payment.AuthorizationID, err)
+	}
+}
+
+// capturePayment cobra la autorización. Si el cobro falla aplica la política:
+// la reserva queda pending_payment o se cancela junto con la autorización y
+// la reserva en Amadeus. Devuelve el error del cobro para que el handler lo informe.
+func (s *UserService) capturePayment(ctx context.Context, reservation *Reservation, payment *Payment) error {
+	captureErr := s.payments.Capture(ctx, payment.AuthorizationID, payment.Amount)
+	if captureErr == nil {
+		payment.Status = PaymentCaptured
+		payment.Error = ""
+		if err := s.paymentRecords.Update(ctx, payment); err != nil {
+			return err
+		}
+		if reservation.Status == "pending_payment" {
+			reservation.Status = "confirmed"
+			return s.reservations.UpdateValidation(ctx, reservation)
+		}
+		return nil
+	}
+
+	log.Printf("Capture of payment %d for reservation %d failed (policy %s): %v", payment.ID, reservation.ID, s.paymentPolicy, captureErr)
+	payment.Status = PaymentCaptureFailed
+	payment.Error = failureReason("capture_failed", captureErr)
+
+	if s.paymentPolicy == PaymentFailRollback || captureErr == ErrPaymentDeclined {
+		if err := s.payments.Void(ctx, payment.AuthorizationID); err != nil {
+			log.Printf("Error voiding payment %d: %v", payment.ID, err)
+		} else {
+			payment.Status = PaymentVoided
+		}
+		if err := s.cancelWithAmadeus(ctx, *reservation); err != nil {
+			log.Printf("Error compensating Amadeus booking: %v", err)
+		}
+		reservation.Status = "cancelled"
+	} else {
+		reservation.Status = "pending_payment"
+	}
+
+	if err := s.paymentRecords.Update(ctx, payment); err != nil {
+		return err
+	}
+	if err := s.reservations.UpdateValidation(ctx, reservation); err != nil {
+		return err
+	}
+	if reservation.Status == "cancelled" {
+		s.invalidateAvailability(reservation.HotelID)
+	}
+	return captureErr
+}
+
+// releasePayment libera el cobro de una reserva cancelada: anula la
+// autorización o devuelve lo capturado
+func (s *UserService) releasePayment(ctx context.Context, reservationID int) error {
+	if s.payments == nil {
+		return nil
+	}
+
+	payment, err := s.paymentRecords.FindByReservation(ctx, reservationID)
+	if err == ErrPaymentNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch payment.Status {
+	case PaymentAuthorized, PaymentCaptureFailed:
+		if err := s.payments.Void(ctx, payment.AuthorizationID); err != nil {
+			return fmt.Errorf("voiding payment %d: %w", payment.ID, err)
+		}
+		payment.Status = PaymentVoided
+	case PaymentCaptured:
+		if err := s.payments.Refund(ctx, payment.AuthorizationID, payment.Amount); err != nil {
+			return fmt.Errorf("refunding payment %d: %w", payment.ID, err)
+		}
+		payment.Status = PaymentRefunded
+	case PaymentCapturing:
+		// Otro worker lo está cobrando; se devuelve cuando termine
+		return fmt.Errorf("payment %d is being captured", payment.ID)
+	default:
+		return nil
+	}
+
+	return s.paymentRecords.Update(ctx, &payment)
+}
+
+// settleRevalidatedPayment cobra la autorización de una reserva que Amadeus
+// terminó confirmando, o la libera si la rechazó
+func (s *UserService) settleRevalidatedPayment(ctx context.Context, reservation *Reservation) error {
+	if s.payments == nil {
+		return nil
+	}
+
+	switch reservation.Status {
+	case "confirmed":
+		payment, err := s.paymentRecords.FindByReservation(ctx, reservation.ID)
+		if err == ErrPaymentNotFound || (err == nil && payment.Status != PaymentAuthorized) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return s.capturePayment(ctx, reservation, &payment)
+	case "rejected":
+		return s.releasePayment(ctx, reservation.ID)
+	}
+	return nil
+}
+
+// CapturePendingPayments reintenta el cobro de las reservas pending_payment.
+// Cada pago se reclama pasándolo a capturing antes de llamar al provider, así
+// no se cobra dos veces aunque otra réplica esté reintentando el mismo.
+// Devuelve cuántas cobró.
+func (s *UserService) CapturePendingPayments(ctx context.Context) (int, error) {
+	if s.payments == nil {
+		return 0, nil
+	}
+
+	reservations, err := s.reservations.ListByStatus(ctx, "pending_payment")
+	if err != nil {
+		return 0, err
+	}
+
+	captured := 0
+	for _, reservation := range reservations {
+		payment, err := s.paymentRecords.FindByReservation(ctx, reservation.ID)
+		if err != nil {
+			log.Printf("Reservation %d has no payment to capture: %v", reservation.ID, err)
+			continue
+		}
+		now := time.Now()
+		claimed, err := s.paymentRecords.Claim(ctx, &payment, now, now.Add(paymentClaimTTL))
+		if err != nil {
+			log.Printf("Error claiming payment %d: %v", payment.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.capturePayment(ctx, &reservation, &payment); err != nil {
+			log.Printf("Reservation %d still pending payment: %v", reservation.ID, err)
+		} else {
+			captured++
+		}
+		s.publishStatusChange(ctx, reservation, "pending_payment")
+	}
+
+	return captured, nil
+}
+
+func (s *UserService) getReservationPayment(c *gin.Context) {
+	reservationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		return
+	}
+
+	reservation, err := s.reservations.FindByID(c.Request.Context(), reservationID)
+	if err == ErrReservationNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reservation not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentUserID, _ := c.Get("user_id")
+	isAdmin, _ := c.Get("is_admin")
+	if currentUserID.(int) != reservation.UserID && !isAdmin.(bool) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	payment, err := s.paymentRecords.FindByReservation(c.Request.Context(), reservationID)
+	if err == ErrPaymentNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
@@ -0,0 +1,144 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Tokens de prueba que entiende FakePaymentProvider; cualquier otro se aprueba
+const (
+	FakeTokenDeclined    = "tok_declined"
+	FakeTokenUnavailable = "tok_unavailable"
+)
+
+var errFakePaymentUnavailable = errors.New("fake payment provider unavailable")
+
+// FakePaymentProvider es un provider determinístico para desarrollo y tests:
+// aprueba todo salvo los tokens FakeToken*, y FailNextCaptures simula
+// fallas transitorias al cobrar.
+type FakePaymentProvider struct {
+	mu             sync.Mutex
+	authorizations map[string]*FakeAuthorization
+	issued         int
+	failCaptures   int
+}
+
+// FakeAuthorization es el estado de una autorización en el fake
+type FakeAuthorization struct {
+	ID       string
+	Amount   float64
+	Currency string
+	Status   string
+	Refunded float64
+}
+
+func NewFakePaymentProvider() *FakePaymentProvider {
+	return &FakePaymentProvider{authorizations: map[string]*FakeAuthorization{}}
+}
+
+func (p *FakePaymentProvider) Name() string {
+	return "fake"
+}
+
+// FailNextCaptures hace que los próximos n Capture fallen
+func (p *FakePaymentProvider) FailNextCaptures(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failCaptures = n
+}
+
+func (p *FakePaymentProvider) Authorization(id string) (FakeAuthorization, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	authorization, ok := p.authorizations[id]
+	if !ok {
+		return FakeAuthorization{}, false
+	}
+	return *authorization, true
+}
+
+func (p *FakePaymentProvider) Authorize(ctx context.Context, request PaymentRequest) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch request.Token {
+	case FakeTokenDeclined:
+		return "", ErrPaymentDeclined
+	case FakeTokenUnavailable:
+		return "", errFakePaymentUnavailable
+	}
+	if request.Amount <= 0 {
+		return "", fmt.Errorf("invalid amount %.2f", request.Amount)
+	}
+
+	p.issued++
+	id := fmt.Sprintf("fake_auth_%d", p.issued)
+	p.authorizations[id] = &FakeAuthorization{ID: id, Amount: request.Amount, Currency: request.Currency, Status: PaymentAuthorized}
+	return id, nil
+}
+
+func (p *FakePaymentProvider) Capture(ctx context.Context, authorizationID string, amount float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	authorization, err := p.find(authorizationID, PaymentAuthorized)
+	if err != nil {
+		return err
+	}
+	if p.failCaptures > 0 {
+		p.failCaptures--
+		return errFakePaymentUnavailable
+	}
+	if amount > authorization.Amount {
+		return fmt.Errorf("capture of %.2f exceeds authorized %.2f", amount, authorization.Amount)
+	}
+
+	authorization.Status = PaymentCaptured
+	return nil
+}
+
+func (p *FakePaymentProvider) Void(ctx context.Context, authorizationID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	authorization, err := p.find(authorizationID, PaymentAuthorized)
+	if err != nil {
+		return err
+	}
+
+	authorization.Status = PaymentVoided
+	return nil
+}
+
+func (p *FakePaymentProvider) Refund(ctx context.Context, authorizationID string, amount float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	authorization, err := p.find(authorizationID, PaymentCaptured)
+	if err != nil {
+		return err
+	}
+	if authorization.Refunded+amount > authorization.Amount {
+		return fmt.Errorf("refund of %.2f exceeds captured %.2f", amount, authorization.Amount-authorization.Refunded)
+	}
+
+	authorization.Refunded += amount
+	if authorization.Refunded == authorization.Amount {
+		authorization.Status = PaymentRefunded
+	}
+	return nil
+}
+
+func (p *FakePaymentProvider) find(authorizationID, status string) (*FakeAuthorization, error) {
+	authorization, ok := p.authorizations[authorizationID]
+	if !ok {
+		return nil, fmt.Errorf("unknown authorization %s", authorizationID)
+	}
+	if authorization.Status != status {
+		return nil, fmt.Errorf("authorization %s is %s, expected %s", authorizationID, authorization.Status, status)
+	}
+	return authorization, nil
+}
@@ -0,0 +1,295 @@
+package userservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newPaymentTestEnv arma un entorno con el provider fake y un hotel-service
+// donde todo hotel cuesta 100 por noche, salvo "hotel-free" que no tiene precio
+func newPaymentTestEnv(t *testing.T) (*testEnv, *FakePaymentProvider) {
+	t.Helper()
+
+	hotels := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		price := 100.0
+		if strings.HasSuffix(r.URL.Path, "/hotel-free") {
+			price = 0
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"pricePerNight": price})
+	}))
+	t.Cleanup(hotels.Close)
+
+	provider := NewFakePaymentProvider()
+	env := newTestEnv(t)
+	env.service.hotelServiceURL = hotels.URL + "/hotels"
+	env.service.payments = provider
+	env.service.paymentPolicy = PaymentFailPending
+	return env, provider
+}
+
+func paidReservationRequest(env *testEnv, t *testing.T, userID int, hotelID, token string) *httptest.ResponseRecorder {
+	return env.do("POST", "/reservations", tokenFor(t, userID, false), map[string]interface{}{
+		"hotel_id":      hotelID,
+		"check_in":      "2026-07-10T00:00:00Z",
+		"check_out":     "2026-07-13T00:00:00Z",
+		"rooms":         2,
+		"payment_token": token,
+	})
+}
+
+func TestCreateReservationCapturesPayment(t *testing.T) {
+	env, provider := newPaymentTestEnv(t)
+	user := env.createUser(t, "juana")
+
+	w := paidReservationRequest(env, t, user.ID, "hotel-1", "tok_visa")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var reservation Reservation
+	json.Unmarshal(w.Body.Bytes(), &reservation)
+	if reservation.Status != "confirmed" || reservation.PaymentToken != "" {
+		t.Fatalf("expected a confirmed reservation without the token, got %+v", reservation)
+	}
+
+	payment, err := env.store.Payments.FindByReservation(context.Background(), reservation.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 noches x 2 habitaciones x 100
+	if payment.Amount != 600 || payment.Status != PaymentCaptured || payment.Provider != "fake" {
+		t.Fatalf("expected a captured payment of 600, got %+v", payment)
+	}
+	if authorization, _ := provider.Authorization(payment.AuthorizationID); authorization.Status != PaymentCaptured {
+		t.Fatalf("expected the provider to have captured the authorization, got %+v", authorization)
+	}
+
+	w = env.do("GET", fmt.Sprintf("/reservations/%d/payment", reservation.ID), tokenFor(t, user.ID, false), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for the payment, got %d", w.Code)
+	}
+	other := env.createUser(t, "pedro")
+	if w := env.do("GET", fmt.Sprintf("/reservations/%d/payment", reservation.ID), tokenFor(t, other.ID, false), nil); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another user, got %d", w.Code)
+	}
+}
+
+func TestCreateReservationPaymentDeclined(t *testing.T) {
+	env, _ := newPaymentTestEnv(t)
+	user := env.createUser(t, "juana")
+
+	if w := paidReservationRequest(env, t, user.ID, "hotel-1", FakeTokenDeclined); w.Code != http.StatusPaymentRequired {
+		t.Fatalf("expected 402, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := paidReservationRequest(env, t, user.ID, "hotel-1", FakeTokenUnavailable); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// La saga deja las reservas como failed y libera las fechas
+	reservations, _ := env.store.Reservations.List(context.Background())
+	if len(reservations) != 2 {
+		t.Fatalf("expected 2 reservations, got %d", len(reservations))
+	}
+	for _, reservation := range reservations {
+		if reservation.Status != "failed" {
+			t.Fatalf("expected failed reservations, got %+v", reservations)
+		}
+	}
+	if w := paidReservationRequest(env, t, user.ID, "hotel-1", "tok_visa"); w.Code != http.StatusCreated {
+		t.Fatalf("expected the dates to be bookable again, got %d", w.Code)
+	}
+}
+
+func TestCreateReservationWithoutPriceSkipsPayment(t *testing.T) {
+	env, _ := newPaymentTestEnv(t)
+	user := env.createUser(t, "juana")
+
+	w := paidReservationRequest(env, t, user.ID, "hotel-free", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var reservation Reservation
+	json.Unmarshal(w.Body.Bytes(), &reservation)
+	if _, err := env.store.Payments.FindByReservation(context.Background(), reservation.ID); err != ErrPaymentNotFound {
+		t.Fatalf("expected no payment for a hotel without price, got %v", err)
+	}
+}
+
+func TestCaptureFailureLeavesPendingPayment(t *testing.T) {
+	env, provider := newPaymentTestEnv(t)
+	user := env.createUser(t, "juana")
+	provider.FailNextCaptures(1)
+
+	w := paidReservationRequest(env, t, user.ID, "hotel-1", "tok_visa")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var reservation Reservation
+	json.Unmarshal(w.Body.Bytes(), &reservation)
+	if reservation.Status != "pending_payment" {
+		t.Fatalf("expected pending_payment, got %q", reservation.Status)
+	}
+
+	// pending_payment sigue ocupando las fechas
+	other := env.createUser(t, "pedro")
+	if w := paidReservationRequest(env, t, other.ID, "hotel-1", "tok_visa"); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 while the payment is pending, got %d", w.Code)
+	}
+
+	if _, err := env.service.CapturePendingPayments(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := env.store.Reservations.FindByID(context.Background(), reservation.ID)
+	payment, _ := env.store.Payments.FindByReservation(context.Background(), reservation.ID)
+	if stored.Status != "confirmed" || payment.Status != PaymentCaptured || payment.Error != "" {
+		t.Fatalf("expected the retry to capture and confirm, got %q / %+v", stored.Status, payment)
+	}
+}
+
+func TestCapturePendingPaymentsClaimsEachPayment(t *testing.T) {
+	env, provider := newPaymentTestEnv(t)
+	ctx := context.Background()
+	user := env.createUser(t, "juana")
+	provider.FailNextCaptures(1)
+
+	var reservation Reservation
+	json.Unmarshal(paidReservationRequest(env, t, user.ID, "hotel-1", "tok_visa").Body.Bytes(), &reservation)
+
+	// Varias réplicas leen el mismo pago y lo reclaman a la vez; sólo una gana
+	read, _ := env.store.Payments.FindByReservation(ctx, reservation.ID)
+	now := time.Now()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(payment Payment) {
+			defer wg.Done()
+			claimed, err := env.store.Payments.Claim(ctx, &payment, now, now.Add(time.Minute))
+			if err != nil {
+				t.Error(err)
+			}
+			if claimed {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			}
+		}(read)
+	}
+	wg.Wait()
+	if winners != 1 {
+		t.Fatalf("expected exactly one claim to win, got %d", winners)
+	}
+	if payment, _ := env.store.Payments.FindByReservation(ctx, reservation.ID); payment.Status != PaymentCapturing {
+		t.Fatalf("expected the claimed payment to be capturing, got %q", payment.Status)
+	}
+
+	// Mientras el reclamo vale nadie más lo cobra
+	if captured, err := env.service.CapturePendingPayments(ctx); err != nil || captured != 0 {
+		t.Fatalf("expected a claimed payment to be skipped, got %d, %v", captured, err)
+	}
+	// Si el worker que lo reclamó se cayó, al vencer el reclamo otro lo retoma
+	later := now.Add(2 * time.Minute)
+	if claimed, _ := env.store.Payments.Claim(ctx, &read, later, later.Add(time.Minute)); !claimed {
+		t.Fatal("expected an expired claim to be taken over")
+	}
+	env.store.Payments.Update(ctx, &Payment{ID: read.ID, Status: PaymentCaptureFailed})
+
+	if captured, err := env.service.CapturePendingPayments(ctx); err != nil || captured != 1 {
+		t.Fatalf("expected one capture, got %d, %v", captured, err)
+	}
+	// Ya cobrado no hay nada más que reintentar
+	if captured, _ := env.service.CapturePendingPayments(ctx); captured != 0 {
+		t.Fatalf("expected nothing left to capture, got %d", captured)
+	}
+}
+
+func TestCaptureFailureRollsBack(t *testing.T) {
+	env, provider := newPaymentTestEnv(t)
+	env.service.paymentPolicy = PaymentFailRollback
+	user := env.createUser(t, "juana")
+	provider.FailNextCaptures(1)
+
+	w := paidReservationRequest(env, t, user.ID, "hotel-1", "tok_visa")
+	if w.Code != http.StatusPaymentRequired {
+		t.Fatalf("expected 402, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reservations, _ := env.store.Reservations.List(context.Background())
+	if len(reservations) != 1 || reservations[0].Status != "cancelled" {
+		t.Fatalf("expected a cancelled reservation, got %+v", reservations)
+	}
+	payment, _ := env.store.Payments.FindByReservation(context.Background(), reservations[0].ID)
+	if authorization, _ := provider.Authorization(payment.AuthorizationID); payment.Status != PaymentVoided || authorization.Status != PaymentVoided {
+		t.Fatalf("expected the authorization to be voided, got %+v / %+v", payment, authorization)
+	}
+
+	// Las fechas quedan libres
+	if w := paidReservationRequest(env, t, user.ID, "hotel-1", "tok_visa"); w.Code != http.StatusCreated {
+		t.Fatalf("expected the dates to be bookable again, got %d", w.Code)
+	}
+}
+
+func TestCancelReservationRefundsPayment(t *testing.T) {
+	env, provider := newPaymentTestEnv(t)
+	user := env.createUser(t, "juana")
+
+	var reservation Reservation
+	json.Unmarshal(paidReservationRequest(env, t, user.ID, "hotel-1", "tok_visa").Body.Bytes(), &reservation)
+
+	w := env.do("POST", fmt.Sprintf("/reservations/%d/cancel", reservation.ID), tokenFor(t, user.ID, false), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	payment, _ := env.store.Payments.FindByReservation(context.Background(), reservation.ID)
+	authorization, _ := provider.Authorization(payment.AuthorizationID)
+	if payment.Status != PaymentRefunded || authorization.Status != PaymentRefunded || authorization.Refunded != 600 {
+		t.Fatalf("expected a full refund, got %+v / %+v", payment, authorization)
+	}
+}
+
+func TestAmadeusPendingReservationIsCapturedOnRevalidation(t *testing.T) {
+	env, provider := newPaymentTestEnv(t)
+	fake := NewFakeAmadeus()
+	t.Cleanup(fake.Close)
+	env.service.amadeus = fake.Client()
+	env.service.amadeusPolicy = AmadeusFailPending
+	env.mapHotel(t, "hotel-1", "ADPAR001")
+	user := env.createUser(t, "juana")
+
+	fake.SetDelay(time.Second)
+	env.service.amadeus.HTTPClient.Timeout = 20 * time.Millisecond
+	var reservation Reservation
+	json.Unmarshal(paidReservationRequest(env, t, user.ID, "hotel-1", "tok_visa").Body.Bytes(), &reservation)
+	if reservation.Status != "pending" || reservation.Payment == nil || reservation.Payment.Status != PaymentAuthorized {
+		t.Fatalf("expected a pending reservation with an authorized payment, got %+v", reservation)
+	}
+
+	fake.SetDelay(0)
+	if _, err := env.service.RevalidatePendingReservations(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	payment, _ := env.store.Payments.FindByReservation(context.Background(), reservation.ID)
+	if authorization, _ := provider.Authorization(payment.AuthorizationID); payment.Status != PaymentCaptured || authorization.Status != PaymentCaptured {
+		t.Fatalf("expected the payment to be captured once Amadeus confirmed, got %+v", payment)
+	}
+}
+
+func TestParsePaymentFailurePolicy(t *testing.T) {
+	if policy, err := ParsePaymentFailurePolicy(""); err != nil || policy != PaymentFailPending {
+		t.Fatalf("expected pending by default, got %q, %v", policy, err)
+	}
+	if _, err := ParsePaymentFailurePolicy("retry"); err == nil {
+		t.Fatal("expected an error for an unknown policy")
+	}
+}
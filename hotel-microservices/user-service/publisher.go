@@ -0,0 +1,114 @@
+package userservice
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/streadway/amqp"
+
+	"events/amqpx"
+)
+
+type EventPublisher interface {
+	Publish(exchange, routingKey string, message interface{}) error
+}
+
+type amqpPublisher struct {
+	mu      sync.Mutex
+	rabbit  *amqpx.RabbitMQ
+	channel *amqp.Channel
+}
+
+// NewAMQPPublisher publica por la conexión de rabbit. Sin conexión Publish
+// devuelve amqpx.ErrUnavailable en lugar de esperar a que vuelva.
+func NewAMQPPublisher(rabbit *amqpx.RabbitMQ) EventPublisher {
+	return &amqpPublisher{rabbit: rabbit}
+}
+
+func (p *amqpPublisher) Publish(exchange, routingKey string, message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Un canal de AMQP no sobrevive a la conexión que lo creó: se abre la
+	// primera vez y de nuevo después de cada error
+	if p.channel == nil {
+		channel, err := p.rabbit.Channel()
+		if err != nil {
+			return err
+		}
+		p.channel = channel
+	}
+
+	err = p.channel.Publish(
+		exchange,
+		routingKey,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+		},
+	)
+	if err != nil {
+		p.channel.Close()
+		p.channel = nil
+	}
+	return err
+}
+
+type PublishedMessage struct {
+	Exchange   string
+	RoutingKey string
+	Body       []byte
+}
+
+// MemoryPublisher guarda los mensajes publicados y se los entrega a los
+// suscriptores en el mismo proceso, en lugar de pasar por RabbitMQ
+type MemoryPublisher struct {
+	mu          sync.Mutex
+	messages    []PublishedMessage
+	subscribers []func(PublishedMessage)
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(exchange, routingKey string, message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	msg := PublishedMessage{Exchange: exchange, RoutingKey: routingKey, Body: body}
+
+	p.mu.Lock()
+	p.messages = append(p.messages, msg)
+	subscribers := append([]func(PublishedMessage){}, p.subscribers...)
+	p.mu.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber(msg)
+	}
+
+	return nil
+}
+
+func (p *MemoryPublisher) Subscribe(fn func(PublishedMessage)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.subscribers = append(p.subscribers, fn)
+}
+
+func (p *MemoryPublisher) Messages() []PublishedMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]PublishedMessage{}, p.messages...)
+}
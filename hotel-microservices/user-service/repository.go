@@ -0,0 +1,888 @@
+package userservice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	ErrUserNotFound        = errors.New("user not found")
+	ErrDuplicateUser       = errors.New("username or email already exists")
+	ErrMappingNotFound     = errors.New("hotel mapping not found")
+	ErrErasureJobNotFound  = errors.New("erasure job not found")
+	ErrReservationNotFound = errors.New("reservation not found")
+)
+
+type UserRepository interface {
+	// Create espera el password ya hasheado
+	Create(ctx context.Context, user *User) error
+	FindByCredentials(ctx context.Context, username, hashedPassword string) (User, error)
+	FindByID(ctx context.Context, id int) (User, error)
+	List(ctx context.Context) ([]User, error)
+	CountAdmins(ctx context.Context) (int, error)
+	// Anonymize borra los datos personales del usuario y de sus reservas
+	Anonymize(ctx context.Context, id int) error
+}
+
+type ReservationRepository interface {
+	Create(ctx context.Context, reservation *Reservation) error
+	// CreateIfAvailable crea la reserva sólo si ninguna reserva ni retención
+	// activa a now ocupa sus fechas; si no, devuelve ErrDatesUnavailable. Usa
+	// el mismo lock por hotel que InventoryHoldRepository.Create.
+	CreateIfAvailable(ctx context.Context, reservation *Reservation, now time.Time) error
+	FindByID(ctx context.Context, id int) (Reservation, error)
+	List(ctx context.Context) ([]Reservation, error)
+	ListByUser(ctx context.Context, userID int) ([]Reservation, error)
+	CountOverlapping(ctx context.Context, hotelID string, checkIn, checkOut time.Time) (int, error)
+	ListByStatus(ctx context.Context, status string) ([]Reservation, error)
+	// ListByStatusBefore devuelve las reservas con status cuya columna de fecha
+	// (ReservationCreatedAt, ReservationCheckIn o ReservationCheckOut) es anterior a before
+	ListByStatusBefore(ctx context.Context, status, column string, before time.Time) ([]Reservation, error)
+	// UpdateValidation guarda status, motivo y referencias de Amadeus
+	UpdateValidation(ctx context.Context, reservation *Reservation) error
+}
+
+type HotelMappingRepository interface {
+	Get(ctx context.Context, internalID string) (HotelMapping, error)
+	List(ctx context.Context) ([]HotelMapping, error)
+	// Set crea el mapping o pisa el existente
+	Set(ctx context.Context, mapping *HotelMapping) error
+	Delete(ctx context.Context, internalID string) error
+}
+
+type ErasureJobRepository interface {
+	Create(ctx context.Context, job *ErasureJob) error
+	LatestForUser(ctx context.Context, userID int) (ErasureJob, error)
+	UpdateStatus(ctx context.Context, id int, status, errorMessage string) error
+	// ListUnfinished devuelve los jobs pending y los running que empezaron antes de startedBefore
+	ListUnfinished(ctx context.Context, startedBefore time.Time) ([]ErasureJob, error)
+	// Claim pasa el job a running si sigue pending, o si quedó running desde
+	// antes de startedBefore. Devuelve false si ya lo tomó otro.
+	Claim(ctx context.Context, id int, startedBefore time.Time) (bool, error)
+}
+
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *Payment) error
+	FindByReservation(ctx context.Context, reservationID int) (Payment, error)
+	// Update guarda status y error y suelta el reclamo
+	Update(ctx context.Context, payment *Payment) error
+	// Claim pasa el pago a capturing hasta until si está authorized o
+	// capture_failed, o si quedó capturing con el reclamo vencido antes de now.
+	// Devuelve false si otro lo tomó antes.
+	Claim(ctx context.Context, payment *Payment, now, until time.Time) (bool, error)
+}
+
+type BookingSagaRepository interface {
+	Create(ctx context.Context, saga *BookingSaga) error
+	// Update guarda step, status y error
+	Update(ctx context.Context, saga *BookingSaga) error
+	// ListUnfinished devuelve las sagas running o compensating sin cambios desde updatedBefore
+	ListUnfinished(ctx context.Context, updatedBefore time.Time) ([]BookingSaga, error)
+	// Claim toca updated_at de la saga si sigue en el mismo paso y estado y sin
+	// cambios desde updatedBefore. Devuelve false si otro la tomó o la avanzó antes.
+	Claim(ctx context.Context, saga *BookingSaga, updatedBefore time.Time) (bool, error)
+}
+
+type InventoryHoldRepository interface {
+	// Create inserta la retención si a now ninguna reserva ni retención activa
+	// ocupa las fechas, o devuelve ErrDatesUnavailable. El chequeo y el insert
+	// son atómicos, así dos retenciones concurrentes no pueden tomar el mismo cupo.
+	Create(ctx context.Context, hold *InventoryHold, now time.Time) error
+	FindByID(ctx context.Context, id int) (InventoryHold, error)
+	// CountActiveOverlapping cuenta las retenciones activas y sin vencer a now que se superponen con las fechas
+	CountActiveOverlapping(ctx context.Context, hotelID string, checkIn, checkOut, now time.Time) (int, error)
+	// Convert asocia una retención activa y sin vencer a la reserva; ErrHoldNotActive si ya no lo estaba
+	Convert(ctx context.Context, id, reservationID int, now time.Time) error
+	// Release libera una retención activa; ErrHoldNotActive si ya no lo estaba
+	Release(ctx context.Context, id int) error
+	// ExpireBefore marca expired las retenciones activas vencidas a now y devuelve cuántas
+	ExpireBefore(ctx context.Context, now time.Time) (int, error)
+}
+
+type JobRepository interface {
+	// AcquireLock toma el lease del job hasta until si está libre a now o ya es de owner
+	AcquireLock(ctx context.Context, job, owner string, now, until time.Time) (bool, error)
+	CreateRun(ctx context.Context, run *JobRun) error
+	// FinishRun guarda status, procesados, error y la hora de fin
+	FinishRun(ctx context.Context, run *JobRun) error
+	// ListRuns devuelve las últimas corridas, de todos los jobs si job es ""
+	ListRuns(ctx context.Context, job string, limit int) ([]JobRun, error)
+}
+
+type sqlUserRepository struct {
+	db *sql.DB
+}
+
+func NewSQLUserRepository(db *sql.DB) UserRepository {
+	return &sqlUserRepository{db: db}
+}
+
+func (r *sqlUserRepository) Create(ctx context.Context, user *User) error {
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO users (username, email, password, is_admin) VALUES (?, ?, ?, ?)",
+		user.Username, user.Email, user.Password, user.IsAdmin,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return ErrDuplicateUser
+		}
+		return err
+	}
+
+	userID, _ := result.LastInsertId()
+	user.ID = int(userID)
+	return nil
+}
+
+func (r *sqlUserRepository) FindByCredentials(ctx context.Context, username, hashedPassword string) (User, error) {
+	var user User
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, username, email, is_admin, created_at FROM users WHERE username = ? AND password = ?",
+		username, hashedPassword,
+	).Scan(&user.ID, &user.Username, &user.Email, &user.IsAdmin, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *sqlUserRepository) FindByID(ctx context.Context, id int) (User, error) {
+	var user User
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, username, email, is_admin, created_at FROM users WHERE id = ?",
+		id,
+	).Scan(&user.ID, &user.Username, &user.Email, &user.IsAdmin, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *sqlUserRepository) List(ctx context.Context) ([]User, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, username, email, is_admin, created_at FROM users")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.IsAdmin, &user.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *sqlUserRepository) CountAdmins(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE is_admin = TRUE").Scan(&count)
+	return count, err
+}
+
+func (r *sqlUserRepository) Anonymize(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// El password vacío nunca coincide con un hash MD5, así que la cuenta queda inutilizable
+	_, err = tx.ExecContext(ctx,
+		"UPDATE users SET username = ?, email = ?, password = '', is_admin = FALSE WHERE id = ?",
+		anonymizedUsername(id), anonymizedEmail(id), id,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Upstream booking references can identify the guest at the hotel
+	_, err = tx.ExecContext(ctx, "UPDATE reservations SET amadeus_id = '', room_type = '', amadeus_booking_id = '', confirmation_number = '' WHERE user_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func anonymizedUsername(id int) string {
+	return fmt.Sprintf("deleted_user_%d", id)
+}
+
+func anonymizedEmail(id int) string {
+	return fmt.Sprintf("deleted_%d@erased.invalid", id)
+}
+
+type sqlReservationRepository struct {
+	db *sql.DB
+}
+
+func NewSQLReservationRepository(db *sql.DB) ReservationRepository {
+	return &sqlReservationRepository{db: db}
+}
+
+const reservationColumns = "id, user_id, hotel_id, check_in, check_out, guests, rooms, room_type, status, amadeus_id, validation_reason, amadeus_booking_id, confirmation_number, created_at"
+
+func (r *sqlReservationRepository) Create(ctx context.Context, reservation *Reservation) error {
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO reservations (user_id, hotel_id, check_in, check_out, guests, rooms, room_type, status, amadeus_id, validation_reason, amadeus_booking_id, confirmation_number) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		reservation.UserID, reservation.HotelID, reservation.CheckIn, reservation.CheckOut, reservation.Guests, reservation.Rooms, reservation.RoomType, reservation.Status, reservation.AmadeusID, reservation.ValidationReason, reservation.AmadeusBookingID, reservation.ConfirmationNumber,
+	)
+	if err != nil {
+		return err
+	}
+
+	reservationID, _ := result.LastInsertId()
+	reservation.ID = int(reservationID)
+	return nil
+}
+
+func (r *sqlReservationRepository) CreateIfAvailable(ctx context.Context, reservation *Reservation, now time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockHotelInventory(ctx, tx, reservation.HotelID, reservation.CheckIn, reservation.CheckOut, now); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO reservations (user_id, hotel_id, check_in, check_out, guests, rooms, room_type, status, amadeus_id, validation_reason, amadeus_booking_id, confirmation_number) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		reservation.UserID, reservation.HotelID, reservation.CheckIn, reservation.CheckOut, reservation.Guests, reservation.Rooms, reservation.RoomType, reservation.Status, reservation.AmadeusID, reservation.ValidationReason, reservation.AmadeusBookingID, reservation.ConfirmationNumber,
+	)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	reservationID, _ := result.LastInsertId()
+	reservation.ID = int(reservationID)
+	return nil
+}
+
+func (r *sqlReservationRepository) List(ctx context.Context) ([]Reservation, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+reservationColumns+" FROM reservations")
+	if err != nil {
+		return nil, err
+	}
+	return scanReservations(rows)
+}
+
+func (r *sqlReservationRepository) ListByUser(ctx context.Context, userID int) ([]Reservation, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+reservationColumns+" FROM reservations WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanReservations(rows)
+}
+
+func (r *sqlReservationRepository) CountOverlapping(ctx context.Context, hotelID string, checkIn, checkOut time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM reservations WHERE hotel_id = ? AND status IN ('confirmed', 'pending', 'pending_payment', 'processing', 'checked_in') AND ((check_in <= ? AND check_out > ?) OR (check_in < ? AND check_out >= ?))",
+		hotelID, checkIn, checkIn, checkOut, checkOut,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *sqlReservationRepository) ListByStatus(ctx context.Context, status string) ([]Reservation, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+reservationColumns+" FROM reservations WHERE status = ? ORDER BY id", status)
+	if err != nil {
+		return nil, err
+	}
+	return scanReservations(rows)
+}
+
+func (r *sqlReservationRepository) ListByStatusBefore(ctx context.Context, status, column string, before time.Time) ([]Reservation, error) {
+	switch column {
+	case ReservationCreatedAt, ReservationCheckIn, ReservationCheckOut:
+	default:
+		return nil, fmt.Errorf("unknown reservation date column %q", column)
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+reservationColumns+" FROM reservations WHERE status = ? AND "+column+" < ? ORDER BY id", status, before)
+	if err != nil {
+		return nil, err
+	}
+	return scanReservations(rows)
+}
+
+func (r *sqlReservationRepository) FindByID(ctx context.Context, id int) (Reservation, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+reservationColumns+" FROM reservations WHERE id = ?", id)
+	if err != nil {
+		return Reservation{}, err
+	}
+	reservations, err := scanReservations(rows)
+	if err != nil {
+		return Reservation{}, err
+	}
+	if len(reservations) == 0 {
+		return Reservation{}, ErrReservationNotFound
+	}
+	return reservations[0], nil
+}
+
+func (r *sqlReservationRepository) UpdateValidation(ctx context.Context, reservation *Reservation) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE reservations SET status = ?, amadeus_id = ?, validation_reason = ?, amadeus_booking_id = ?, confirmation_number = ? WHERE id = ?",
+		reservation.Status, reservation.AmadeusID, reservation.ValidationReason, reservation.AmadeusBookingID, reservation.ConfirmationNumber, reservation.ID,
+	)
+	return err
+}
+
+func scanReservations(rows *sql.Rows) ([]Reservation, error) {
+	defer rows.Close()
+
+	var reservations []Reservation
+	for rows.Next() {
+		var reservation Reservation
+		var roomType, amadeusID, validationReason, bookingID, confirmationNumber sql.NullString
+		err := rows.Scan(&reservation.ID, &reservation.UserID, &reservation.HotelID, &reservation.CheckIn, &reservation.CheckOut, &reservation.Guests, &reservation.Rooms, &roomType, &reservation.Status, &amadeusID, &validationReason, &bookingID, &confirmationNumber, &reservation.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		reservation.RoomType = roomType.String
+		reservation.AmadeusID = amadeusID.String
+		reservation.ValidationReason = validationReason.String
+		reservation.AmadeusBookingID = bookingID.String
+		reservation.ConfirmationNumber = confirmationNumber.String
+		reservations = append(reservations, reservation)
+	}
+
+	return reservations, rows.Err()
+}
+
+type sqlHotelMappingRepository struct {
+	db *sql.DB
+}
+
+func NewSQLHotelMappingRepository(db *sql.DB) HotelMappingRepository {
+	return &sqlHotelMappingRepository{db: db}
+}
+
+func (r *sqlHotelMappingRepository) Get(ctx context.Context, internalID string) (HotelMapping, error) {
+	var mapping HotelMapping
+	err := r.db.QueryRowContext(ctx,
+		"SELECT internal_id, amadeus_id, source, updated_at FROM hotel_mapping WHERE internal_id = ?",
+		internalID,
+	).Scan(&mapping.InternalID, &mapping.AmadeusID, &mapping.Source, &mapping.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return mapping, ErrMappingNotFound
+	}
+	return mapping, err
+}
+
+func (r *sqlHotelMappingRepository) List(ctx context.Context) ([]HotelMapping, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT internal_id, amadeus_id, source, updated_at FROM hotel_mapping ORDER BY internal_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mappings []HotelMapping
+	for rows.Next() {
+		var mapping HotelMapping
+		if err := rows.Scan(&mapping.InternalID, &mapping.AmadeusID, &mapping.Source, &mapping.UpdatedAt); err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	return mappings, rows.Err()
+}
+
+func (r *sqlHotelMappingRepository) Set(ctx context.Context, mapping *HotelMapping) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO hotel_mapping (internal_id, amadeus_id, source) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE amadeus_id = VALUES(amadeus_id), source = VALUES(source)",
+		mapping.InternalID, mapping.AmadeusID, mapping.Source,
+	)
+	if err != nil {
+		return err
+	}
+	mapping.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *sqlHotelMappingRepository) Delete(ctx context.Context, internalID string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM hotel_mapping WHERE internal_id = ?", internalID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrMappingNotFound
+	}
+	return nil
+}
+
+type sqlErasureJobRepository struct {
+	db *sql.DB
+}
+
+func NewSQLErasureJobRepository(db *sql.DB) ErasureJobRepository {
+	return &sqlErasureJobRepository{db: db}
+}
+
+func (r *sqlErasureJobRepository) Create(ctx context.Context, job *ErasureJob) error {
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO erasure_jobs (user_id, requested_by, status) VALUES (?, ?, ?)",
+		job.UserID, job.RequestedBy, job.Status,
+	)
+	if err != nil {
+		return err
+	}
+
+	jobID, _ := result.LastInsertId()
+	job.ID = int(jobID)
+	job.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *sqlErasureJobRepository) LatestForUser(ctx context.Context, userID int) (ErasureJob, error) {
+	job, err := scanErasureJob(r.db.QueryRowContext(ctx,
+		"SELECT "+erasureJobColumns+" FROM erasure_jobs WHERE user_id = ? ORDER BY id DESC LIMIT 1",
+		userID,
+	))
+	if err == sql.ErrNoRows {
+		return job, ErrErasureJobNotFound
+	}
+	return job, err
+}
+
+const erasureJobColumns = "id, user_id, requested_by, status, error, created_at, started_at, completed_at"
+
+// rowScanner es lo que tienen en común *sql.Row y *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanErasureJob(row rowScanner) (ErasureJob, error) {
+	var job ErasureJob
+	var jobError sql.NullString
+	var startedAt, completedAt sql.NullTime
+	if err := row.Scan(&job.ID, &job.UserID, &job.RequestedBy, &job.Status, &jobError, &job.CreatedAt, &startedAt, &completedAt); err != nil {
+		return job, err
+	}
+
+	job.Error = jobError.String
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	return job, nil
+}
+
+func (r *sqlErasureJobRepository) ListUnfinished(ctx context.Context, startedBefore time.Time) ([]ErasureJob, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+erasureJobColumns+" FROM erasure_jobs WHERE status = 'pending' OR (status = 'running' AND (started_at IS NULL OR started_at < ?)) ORDER BY id",
+		startedBefore,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []ErasureJob
+	for rows.Next() {
+		job, err := scanErasureJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *sqlErasureJobRepository) Claim(ctx context.Context, id int, startedBefore time.Time) (bool, error) {
+	// El UPDATE condicional es atómico: de dos réplicas que compiten solo una cambia la fila
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE erasure_jobs SET status = 'running', started_at = NOW() WHERE id = ? AND (status = 'pending' OR (status = 'running' AND (started_at IS NULL OR started_at < ?)))",
+		id, startedBefore,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+func (r *sqlErasureJobRepository) UpdateStatus(ctx context.Context, id int, status, errorMessage string) error {
+	if status == "completed" || status == "failed" {
+		_, err := r.db.ExecContext(ctx,
+			"UPDATE erasure_jobs SET status = ?, error = NULLIF(?, ''), completed_at = NOW() WHERE id = ?",
+			status, errorMessage, id,
+		)
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, "UPDATE erasure_jobs SET status = ? WHERE id = ?", status, id)
+	return err
+}
+
+type sqlPaymentRepository struct {
+	db *sql.DB
+}
+
+func NewSQLPaymentRepository(db *sql.DB) PaymentRepository {
+	return &sqlPaymentRepository{db: db}
+}
+
+func (r *sqlPaymentRepository) Create(ctx context.Context, payment *Payment) error {
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO payments (reservation_id, provider, authorization_id, amount, currency, status, error) VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''))",
+		payment.ReservationID, payment.Provider, payment.AuthorizationID, payment.Amount, payment.Currency, payment.Status, payment.Error,
+	)
+	if err != nil {
+		return err
+	}
+
+	paymentID, _ := result.LastInsertId()
+	payment.ID = int(paymentID)
+	payment.CreatedAt = time.Now()
+	payment.UpdatedAt = payment.CreatedAt
+	return nil
+}
+
+func (r *sqlPaymentRepository) FindByReservation(ctx context.Context, reservationID int) (Payment, error) {
+	var payment Payment
+	var paymentError sql.NullString
+	var claimedUntil sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, reservation_id, provider, authorization_id, amount, currency, status, error, claimed_until, created_at, updated_at FROM payments WHERE reservation_id = ?",
+		reservationID,
+	).Scan(&payment.ID, &payment.ReservationID, &payment.Provider, &payment.AuthorizationID, &payment.Amount, &payment.Currency, &payment.Status, &paymentError, &claimedUntil, &payment.CreatedAt, &payment.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return payment, ErrPaymentNotFound
+	}
+	payment.Error = paymentError.String
+	if claimedUntil.Valid {
+		payment.ClaimedUntil = &claimedUntil.Time
+	}
+	return payment, err
+}
+
+func (r *sqlPaymentRepository) Update(ctx context.Context, payment *Payment) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE payments SET status = ?, error = NULLIF(?, ''), claimed_until = NULL WHERE id = ?",
+		payment.Status, payment.Error, payment.ID,
+	)
+	if err != nil {
+		return err
+	}
+	payment.ClaimedUntil = nil
+	payment.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *sqlPaymentRepository) Claim(ctx context.Context, payment *Payment, now, until time.Time) (bool, error) {
+	// El UPDATE condicional es atómico: de dos réplicas que compiten solo una cambia el status
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE payments SET status = ?, claimed_until = ? WHERE id = ? AND (status IN (?, ?) OR (status = ? AND claimed_until < ?))",
+		PaymentCapturing, until, payment.ID, PaymentAuthorized, PaymentCaptureFailed, PaymentCapturing, now,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := result.RowsAffected()
+	if affected == 0 {
+		return false, nil
+	}
+	payment.Status = PaymentCapturing
+	payment.ClaimedUntil = &until
+	payment.UpdatedAt = time.Now()
+	return true, nil
+}
+
+type sqlBookingSagaRepository struct {
+	db *sql.DB
+}
+
+func NewSQLBookingSagaRepository(db *sql.DB) BookingSagaRepository {
+	return &sqlBookingSagaRepository{db: db}
+}
+
+func (r *sqlBookingSagaRepository) Create(ctx context.Context, saga *BookingSaga) error {
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO booking_sagas (reservation_id, step, status) VALUES (?, ?, ?)",
+		saga.ReservationID, saga.Step, saga.Status,
+	)
+	if err != nil {
+		return err
+	}
+
+	sagaID, _ := result.LastInsertId()
+	saga.ID = int(sagaID)
+	saga.CreatedAt = time.Now()
+	saga.UpdatedAt = saga.CreatedAt
+	return nil
+}
+
+func (r *sqlBookingSagaRepository) Update(ctx context.Context, saga *BookingSaga) error {
+	// updated_at se pisa a mano: ON UPDATE no cambia si la fila queda igual
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE booking_sagas SET step = ?, status = ?, error = NULLIF(?, ''), updated_at = NOW() WHERE id = ?",
+		saga.Step, saga.Status, saga.Error, saga.ID,
+	)
+	if err != nil {
+		return err
+	}
+	saga.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *sqlBookingSagaRepository) Claim(ctx context.Context, saga *BookingSaga, updatedBefore time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE booking_sagas SET updated_at = NOW() WHERE id = ? AND step = ? AND status = ? AND updated_at < ?",
+		saga.ID, saga.Step, saga.Status, updatedBefore,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := result.RowsAffected()
+	if affected == 0 {
+		return false, nil
+	}
+	saga.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (r *sqlBookingSagaRepository) ListUnfinished(ctx context.Context, updatedBefore time.Time) ([]BookingSaga, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, reservation_id, step, status, error, created_at, updated_at FROM booking_sagas WHERE status IN ('running', 'compensating') AND updated_at < ? ORDER BY id",
+		updatedBefore,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sagas []BookingSaga
+	for rows.Next() {
+		var saga BookingSaga
+		var sagaError sql.NullString
+		if err := rows.Scan(&saga.ID, &saga.ReservationID, &saga.Step, &saga.Status, &sagaError, &saga.CreatedAt, &saga.UpdatedAt); err != nil {
+			return nil, err
+		}
+		saga.Error = sagaError.String
+		sagas = append(sagas, saga)
+	}
+
+	return sagas, rows.Err()
+}
+
+type sqlInventoryHoldRepository struct {
+	db *sql.DB
+}
+
+func NewSQLInventoryHoldRepository(db *sql.DB) InventoryHoldRepository {
+	return &sqlInventoryHoldRepository{db: db}
+}
+
+// lockHotelInventory toma la fila del hotel en hotel_inventory_locks, que
+// serializa hasta el commit las reservas y retenciones del mismo hotel (las de
+// otros hoteles no se esperan), y devuelve ErrDatesUnavailable si alguna
+// reserva o retención activa a now ocupa las fechas
+func lockHotelInventory(ctx context.Context, tx *sql.Tx, hotelID string, checkIn, checkOut, now time.Time) error {
+	if _, err := tx.ExecContext(ctx, "INSERT IGNORE INTO hotel_inventory_locks (hotel_id) VALUES (?)", hotelID); err != nil {
+		return err
+	}
+	var locked string
+	if err := tx.QueryRowContext(ctx, "SELECT hotel_id FROM hotel_inventory_locks WHERE hotel_id = ? FOR UPDATE", hotelID).Scan(&locked); err != nil {
+		return err
+	}
+
+	var booked int
+	err := tx.QueryRowContext(ctx,
+		"SELECT (SELECT COUNT(*) FROM reservations WHERE hotel_id = ? AND status IN ('confirmed', 'pending', 'pending_payment', 'processing', 'checked_in') AND ((check_in <= ? AND check_out > ?) OR (check_in < ? AND check_out >= ?)))"+
+			" + (SELECT COUNT(*) FROM inventory_holds WHERE hotel_id = ? AND status = 'active' AND expires_at > ? AND ((check_in <= ? AND check_out > ?) OR (check_in < ? AND check_out >= ?)))",
+		hotelID, checkIn, checkIn, checkOut, checkOut,
+		hotelID, now, checkIn, checkIn, checkOut, checkOut,
+	).Scan(&booked)
+	if err != nil {
+		return err
+	}
+	if booked > 0 {
+		return ErrDatesUnavailable
+	}
+	return nil
+}
+
+func (r *sqlInventoryHoldRepository) Create(ctx context.Context, hold *InventoryHold, now time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockHotelInventory(ctx, tx, hold.HotelID, hold.CheckIn, hold.CheckOut, now); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO inventory_holds (user_id, hotel_id, check_in, check_out, rooms, status, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		hold.UserID, hold.HotelID, hold.CheckIn, hold.CheckOut, hold.Rooms, hold.Status, hold.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	holdID, _ := result.LastInsertId()
+	hold.ID = int(holdID)
+	hold.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *sqlInventoryHoldRepository) FindByID(ctx context.Context, id int) (InventoryHold, error) {
+	var hold InventoryHold
+	var reservationID sql.NullInt64
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, user_id, hotel_id, check_in, check_out, rooms, status, reservation_id, expires_at, created_at FROM inventory_holds WHERE id = ?",
+		id,
+	).Scan(&hold.ID, &hold.UserID, &hold.HotelID, &hold.CheckIn, &hold.CheckOut, &hold.Rooms, &hold.Status, &reservationID, &hold.ExpiresAt, &hold.CreatedAt)
+	if err == sql.ErrNoRows {
+		return hold, ErrHoldNotFound
+	}
+	hold.ReservationID = int(reservationID.Int64)
+	return hold, err
+}
+
+func (r *sqlInventoryHoldRepository) CountActiveOverlapping(ctx context.Context, hotelID string, checkIn, checkOut, now time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM inventory_holds WHERE hotel_id = ? AND status = 'active' AND expires_at > ? AND ((check_in <= ? AND check_out > ?) OR (check_in < ? AND check_out >= ?))",
+		hotelID, now, checkIn, checkIn, checkOut, checkOut,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *sqlInventoryHoldRepository) Convert(ctx context.Context, id, reservationID int, now time.Time) error {
+	// La condición sobre status hace que dos reservas no puedan usar la misma retención
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE inventory_holds SET status = 'converted', reservation_id = ? WHERE id = ? AND status = 'active' AND expires_at > ?",
+		reservationID, id, now,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrHoldNotActive
+	}
+	return nil
+}
+
+func (r *sqlInventoryHoldRepository) Release(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE inventory_holds SET status = 'released' WHERE id = ? AND status = 'active'", id)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrHoldNotActive
+	}
+	return nil
+}
+
+func (r *sqlInventoryHoldRepository) ExpireBefore(ctx context.Context, now time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, "UPDATE inventory_holds SET status = 'expired' WHERE status = 'active' AND expires_at <= ?", now)
+	if err != nil {
+		return 0, err
+	}
+	affected, _ := result.RowsAffected()
+	return int(affected), nil
+}
+
+type sqlJobRepository struct {
+	db *sql.DB
+}
+
+func NewSQLJobRepository(db *sql.DB) JobRepository {
+	return &sqlJobRepository{db: db}
+}
+
+func (r *sqlJobRepository) AcquireLock(ctx context.Context, job, owner string, now, until time.Time) (bool, error) {
+	if _, err := r.db.ExecContext(ctx, "INSERT IGNORE INTO job_locks (job, owner, locked_until) VALUES (?, '', ?)", job, now); err != nil {
+		return false, err
+	}
+
+	// El UPDATE condicional es atómico: de dos réplicas que compiten solo una cambia la fila
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE job_locks SET owner = ?, locked_until = ? WHERE job = ? AND (locked_until <= ? OR owner = ?)",
+		owner, until, job, now, owner,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+func (r *sqlJobRepository) CreateRun(ctx context.Context, run *JobRun) error {
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO job_runs (job, owner, status, started_at) VALUES (?, ?, ?, ?)",
+		run.Job, run.Owner, run.Status, run.StartedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	runID, _ := result.LastInsertId()
+	run.ID = int(runID)
+	return nil
+}
+
+func (r *sqlJobRepository) FinishRun(ctx context.Context, run *JobRun) error {
+	finishedAt := time.Now()
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE job_runs SET status = ?, processed = ?, error = NULLIF(?, ''), finished_at = ? WHERE id = ?",
+		run.Status, run.Processed, run.Error, finishedAt, run.ID,
+	)
+	if err != nil {
+		return err
+	}
+	run.FinishedAt = &finishedAt
+	return nil
+}
+
+func (r *sqlJobRepository) ListRuns(ctx context.Context, job string, limit int) ([]JobRun, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, job, owner, status, processed, error, started_at, finished_at FROM job_runs WHERE ? = '' OR job = ? ORDER BY id DESC LIMIT ?",
+		job, job, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []JobRun{}
+	for rows.Next() {
+		var run JobRun
+		var runError sql.NullString
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&run.ID, &run.Job, &run.Owner, &run.Status, &run.Processed, &runError, &run.StartedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		run.Error = runError.String
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
@@ -0,0 +1,723 @@
+package userservice
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore agrupa los repositorios en memoria, para tests y para correr
+// el servicio sin MySQL. Comparten un mutex porque Anonymize toca usuarios y
+// reservas a la vez, igual que la transacción de la versión SQL.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	users        []User
+	reservations []Reservation
+	mappings     map[string]HotelMapping
+	erasureJobs  []ErasureJob
+	payments     []Payment
+	sagas        []BookingSaga
+	holds        []InventoryHold
+	jobLocks     map[string]jobLock
+	jobRuns      []JobRun
+
+	Users        UserRepository
+	Reservations ReservationRepository
+	Mappings     HotelMappingRepository
+	ErasureJobs  ErasureJobRepository
+	Payments     PaymentRepository
+	BookingSagas BookingSagaRepository
+	Holds        InventoryHoldRepository
+	Jobs         JobRepository
+}
+
+func NewMemoryStore() *MemoryStore {
+	store := &MemoryStore{mappings: map[string]HotelMapping{}, jobLocks: map[string]jobLock{}}
+	store.Users = &memoryUserRepository{store}
+	store.Reservations = &memoryReservationRepository{store}
+	store.Mappings = &memoryHotelMappingRepository{store}
+	store.ErasureJobs = &memoryErasureJobRepository{store}
+	store.Payments = &memoryPaymentRepository{store}
+	store.BookingSagas = &memoryBookingSagaRepository{store}
+	store.Holds = &memoryInventoryHoldRepository{store}
+	store.Jobs = &memoryJobRepository{store}
+	return store
+}
+
+func (m *MemoryStore) Repositories() Repositories {
+	return Repositories{
+		Users:        m.Users,
+		Reservations: m.Reservations,
+		Mappings:     m.Mappings,
+		ErasureJobs:  m.ErasureJobs,
+		Payments:     m.Payments,
+		BookingSagas: m.BookingSagas,
+		Holds:        m.Holds,
+		Jobs:         m.Jobs,
+	}
+}
+
+type memoryUserRepository struct {
+	store *MemoryStore
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.users {
+		if strings.EqualFold(existing.Username, user.Username) || strings.EqualFold(existing.Email, user.Email) {
+			return ErrDuplicateUser
+		}
+	}
+
+	user.ID = len(r.store.users) + 1
+	user.CreatedAt = time.Now()
+	r.store.users = append(r.store.users, *user)
+	return nil
+}
+
+func (r *memoryUserRepository) FindByCredentials(ctx context.Context, username, hashedPassword string) (User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, user := range r.store.users {
+		if user.Username == username && user.Password == hashedPassword {
+			user.Password = ""
+			return user, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, id int) (User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, user := range r.store.users {
+		if user.ID == id {
+			user.Password = ""
+			return user, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+func (r *memoryUserRepository) List(ctx context.Context) ([]User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	users := make([]User, 0, len(r.store.users))
+	for _, user := range r.store.users {
+		user.Password = ""
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (r *memoryUserRepository) CountAdmins(ctx context.Context) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	count := 0
+	for _, user := range r.store.users {
+		if user.IsAdmin {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryUserRepository) Anonymize(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.users {
+		if r.store.users[i].ID == id {
+			r.store.users[i].Username = anonymizedUsername(id)
+			r.store.users[i].Email = anonymizedEmail(id)
+			r.store.users[i].Password = ""
+			r.store.users[i].IsAdmin = false
+		}
+	}
+
+	for i := range r.store.reservations {
+		if r.store.reservations[i].UserID == id {
+			r.store.reservations[i].AmadeusID = ""
+			r.store.reservations[i].RoomType = ""
+		}
+	}
+	return nil
+}
+
+type memoryReservationRepository struct {
+	store *MemoryStore
+}
+
+func (r *memoryReservationRepository) Create(ctx context.Context, reservation *Reservation) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	reservation.ID = len(r.store.reservations) + 1
+	reservation.CreatedAt = time.Now()
+	r.store.reservations = append(r.store.reservations, *reservation)
+	return nil
+}
+
+func (r *memoryReservationRepository) CreateIfAvailable(ctx context.Context, reservation *Reservation, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.datesTaken(reservation.HotelID, reservation.CheckIn, reservation.CheckOut, now) {
+		return ErrDatesUnavailable
+	}
+	reservation.ID = len(r.store.reservations) + 1
+	reservation.CreatedAt = time.Now()
+	r.store.reservations = append(r.store.reservations, *reservation)
+	return nil
+}
+
+// datesTaken dice si una reserva o retención activa a now ocupa las fechas.
+// Se llama con mu tomado.
+func (s *MemoryStore) datesTaken(hotelID string, checkIn, checkOut, now time.Time) bool {
+	for _, reservation := range s.reservations {
+		if reservation.HotelID == hotelID && holdsInventory(reservation.Status) && overlaps(reservation.CheckIn, reservation.CheckOut, checkIn, checkOut) {
+			return true
+		}
+	}
+	for _, hold := range s.holds {
+		if hold.HotelID == hotelID && hold.activeAt(now) && overlaps(hold.CheckIn, hold.CheckOut, checkIn, checkOut) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryReservationRepository) List(ctx context.Context) ([]Reservation, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return append([]Reservation(nil), r.store.reservations...), nil
+}
+
+func (r *memoryReservationRepository) ListByUser(ctx context.Context, userID int) ([]Reservation, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var reservations []Reservation
+	for _, reservation := range r.store.reservations {
+		if reservation.UserID == userID {
+			reservations = append(reservations, reservation)
+		}
+	}
+	return reservations, nil
+}
+
+// CountOverlapping usa la misma condición que la consulta SQL
+func (r *memoryReservationRepository) CountOverlapping(ctx context.Context, hotelID string, checkIn, checkOut time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	count := 0
+	for _, reservation := range r.store.reservations {
+		if reservation.HotelID != hotelID || !holdsInventory(reservation.Status) {
+			continue
+		}
+
+		if overlaps(reservation.CheckIn, reservation.CheckOut, checkIn, checkOut) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// overlaps replica la condición de fechas de las consultas SQL
+func overlaps(existingIn, existingOut, checkIn, checkOut time.Time) bool {
+	overlapsCheckIn := !existingIn.After(checkIn) && existingOut.After(checkIn)
+	overlapsCheckOut := existingIn.Before(checkOut) && !existingOut.Before(checkOut)
+	return overlapsCheckIn || overlapsCheckOut
+}
+
+// holdsInventory replica el status IN (...) de la consulta SQL
+func holdsInventory(status string) bool {
+	switch status {
+	case "confirmed", "pending", "pending_payment", "processing", "checked_in":
+		return true
+	}
+	return false
+}
+
+func (r *memoryReservationRepository) ListByStatus(ctx context.Context, status string) ([]Reservation, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var reservations []Reservation
+	for _, reservation := range r.store.reservations {
+		if reservation.Status == status {
+			reservations = append(reservations, reservation)
+		}
+	}
+	return reservations, nil
+}
+
+func (r *memoryReservationRepository) ListByStatusBefore(ctx context.Context, status, column string, before time.Time) ([]Reservation, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var reservations []Reservation
+	for _, reservation := range r.store.reservations {
+		var value time.Time
+		switch column {
+		case ReservationCreatedAt:
+			value = reservation.CreatedAt
+		case ReservationCheckIn:
+			value = reservation.CheckIn
+		case ReservationCheckOut:
+			value = reservation.CheckOut
+		default:
+			return nil, fmt.Errorf("unknown reservation date column %q", column)
+		}
+		if reservation.Status == status && value.Before(before) {
+			reservations = append(reservations, reservation)
+		}
+	}
+	return reservations, nil
+}
+
+func (r *memoryReservationRepository) FindByID(ctx context.Context, id int) (Reservation, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, reservation := range r.store.reservations {
+		if reservation.ID == id {
+			return reservation, nil
+		}
+	}
+	return Reservation{}, ErrReservationNotFound
+}
+
+func (r *memoryReservationRepository) UpdateValidation(ctx context.Context, reservation *Reservation) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.reservations {
+		if r.store.reservations[i].ID == reservation.ID {
+			r.store.reservations[i].Status = reservation.Status
+			r.store.reservations[i].AmadeusID = reservation.AmadeusID
+			r.store.reservations[i].ValidationReason = reservation.ValidationReason
+			r.store.reservations[i].AmadeusBookingID = reservation.AmadeusBookingID
+			r.store.reservations[i].ConfirmationNumber = reservation.ConfirmationNumber
+		}
+	}
+	return nil
+}
+
+type memoryHotelMappingRepository struct {
+	store *MemoryStore
+}
+
+func (r *memoryHotelMappingRepository) Get(ctx context.Context, internalID string) (HotelMapping, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	mapping, ok := r.store.mappings[internalID]
+	if !ok {
+		return HotelMapping{}, ErrMappingNotFound
+	}
+	return mapping, nil
+}
+
+func (r *memoryHotelMappingRepository) List(ctx context.Context) ([]HotelMapping, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	mappings := make([]HotelMapping, 0, len(r.store.mappings))
+	for _, mapping := range r.store.mappings {
+		mappings = append(mappings, mapping)
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].InternalID < mappings[j].InternalID })
+	return mappings, nil
+}
+
+func (r *memoryHotelMappingRepository) Set(ctx context.Context, mapping *HotelMapping) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	mapping.UpdatedAt = time.Now()
+	r.store.mappings[mapping.InternalID] = *mapping
+	return nil
+}
+
+func (r *memoryHotelMappingRepository) Delete(ctx context.Context, internalID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.mappings[internalID]; !ok {
+		return ErrMappingNotFound
+	}
+	delete(r.store.mappings, internalID)
+	return nil
+}
+
+type memoryErasureJobRepository struct {
+	store *MemoryStore
+}
+
+func (r *memoryErasureJobRepository) Create(ctx context.Context, job *ErasureJob) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	job.ID = len(r.store.erasureJobs) + 1
+	job.CreatedAt = time.Now()
+	r.store.erasureJobs = append(r.store.erasureJobs, *job)
+	return nil
+}
+
+func (r *memoryErasureJobRepository) LatestForUser(ctx context.Context, userID int) (ErasureJob, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	jobs := append([]ErasureJob(nil), r.store.erasureJobs...)
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID > jobs[j].ID })
+	for _, job := range jobs {
+		if job.UserID == userID {
+			return job, nil
+		}
+	}
+	return ErasureJob{}, ErrErasureJobNotFound
+}
+
+func (r *memoryErasureJobRepository) UpdateStatus(ctx context.Context, id int, status, errorMessage string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.erasureJobs {
+		if r.store.erasureJobs[i].ID != id {
+			continue
+		}
+		r.store.erasureJobs[i].Status = status
+		r.store.erasureJobs[i].Error = errorMessage
+		if status == "completed" || status == "failed" {
+			now := time.Now()
+			r.store.erasureJobs[i].CompletedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memoryErasureJobRepository) ListUnfinished(ctx context.Context, startedBefore time.Time) ([]ErasureJob, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var jobs []ErasureJob
+	for _, job := range r.store.erasureJobs {
+		if erasureJobClaimable(job, startedBefore) {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+func (r *memoryErasureJobRepository) Claim(ctx context.Context, id int, startedBefore time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.erasureJobs {
+		job := &r.store.erasureJobs[i]
+		if job.ID != id || !erasureJobClaimable(*job, startedBefore) {
+			continue
+		}
+		now := time.Now()
+		job.Status = "running"
+		job.StartedAt = &now
+		return true, nil
+	}
+	return false, nil
+}
+
+func erasureJobClaimable(job ErasureJob, startedBefore time.Time) bool {
+	return job.Status == "pending" || (job.Status == "running" && (job.StartedAt == nil || job.StartedAt.Before(startedBefore)))
+}
+
+type memoryPaymentRepository struct {
+	store *MemoryStore
+}
+
+func (r *memoryPaymentRepository) Create(ctx context.Context, payment *Payment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	payment.ID = len(r.store.payments) + 1
+	payment.CreatedAt = time.Now()
+	payment.UpdatedAt = payment.CreatedAt
+	r.store.payments = append(r.store.payments, *payment)
+	return nil
+}
+
+func (r *memoryPaymentRepository) FindByReservation(ctx context.Context, reservationID int) (Payment, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, payment := range r.store.payments {
+		if payment.ReservationID == reservationID {
+			return payment, nil
+		}
+	}
+	return Payment{}, ErrPaymentNotFound
+}
+
+func (r *memoryPaymentRepository) Update(ctx context.Context, payment *Payment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	payment.ClaimedUntil = nil
+	payment.UpdatedAt = time.Now()
+	for i := range r.store.payments {
+		if r.store.payments[i].ID == payment.ID {
+			r.store.payments[i].Status = payment.Status
+			r.store.payments[i].Error = payment.Error
+			r.store.payments[i].ClaimedUntil = nil
+			r.store.payments[i].UpdatedAt = payment.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (r *memoryPaymentRepository) Claim(ctx context.Context, payment *Payment, now, until time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.payments {
+		stored := &r.store.payments[i]
+		if stored.ID != payment.ID {
+			continue
+		}
+		if !paymentClaimable(*stored, now) {
+			return false, nil
+		}
+		stored.Status = PaymentCapturing
+		stored.ClaimedUntil = &until
+		stored.UpdatedAt = time.Now()
+		*payment = *stored
+		return true, nil
+	}
+	return false, nil
+}
+
+func paymentClaimable(payment Payment, now time.Time) bool {
+	switch payment.Status {
+	case PaymentAuthorized, PaymentCaptureFailed:
+		return true
+	case PaymentCapturing:
+		return payment.ClaimedUntil == nil || payment.ClaimedUntil.Before(now)
+	}
+	return false
+}
+
+type memoryBookingSagaRepository struct {
+	store *MemoryStore
+}
+
+func (r *memoryBookingSagaRepository) Create(ctx context.Context, saga *BookingSaga) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	saga.ID = len(r.store.sagas) + 1
+	saga.CreatedAt = time.Now()
+	saga.UpdatedAt = saga.CreatedAt
+	r.store.sagas = append(r.store.sagas, *saga)
+	return nil
+}
+
+func (r *memoryBookingSagaRepository) Update(ctx context.Context, saga *BookingSaga) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	saga.UpdatedAt = time.Now()
+	for i := range r.store.sagas {
+		if r.store.sagas[i].ID == saga.ID {
+			r.store.sagas[i].Step = saga.Step
+			r.store.sagas[i].Status = saga.Status
+			r.store.sagas[i].Error = saga.Error
+			r.store.sagas[i].UpdatedAt = saga.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (r *memoryBookingSagaRepository) Claim(ctx context.Context, saga *BookingSaga, updatedBefore time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.sagas {
+		stored := &r.store.sagas[i]
+		if stored.ID != saga.ID {
+			continue
+		}
+		if stored.Step != saga.Step || stored.Status != saga.Status || !stored.UpdatedAt.Before(updatedBefore) {
+			return false, nil
+		}
+		stored.UpdatedAt = time.Now()
+		saga.UpdatedAt = stored.UpdatedAt
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *memoryBookingSagaRepository) ListUnfinished(ctx context.Context, updatedBefore time.Time) ([]BookingSaga, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var sagas []BookingSaga
+	for _, saga := range r.store.sagas {
+		if (saga.Status == SagaRunning || saga.Status == SagaCompensating) && saga.UpdatedAt.Before(updatedBefore) {
+			sagas = append(sagas, saga)
+		}
+	}
+	return sagas, nil
+}
+
+type memoryInventoryHoldRepository struct {
+	store *MemoryStore
+}
+
+func (r *memoryInventoryHoldRepository) Create(ctx context.Context, hold *InventoryHold, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.datesTaken(hold.HotelID, hold.CheckIn, hold.CheckOut, now) {
+		return ErrDatesUnavailable
+	}
+
+	hold.ID = len(r.store.holds) + 1
+	hold.CreatedAt = time.Now()
+	r.store.holds = append(r.store.holds, *hold)
+	return nil
+}
+
+func (r *memoryInventoryHoldRepository) FindByID(ctx context.Context, id int) (InventoryHold, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, hold := range r.store.holds {
+		if hold.ID == id {
+			return hold, nil
+		}
+	}
+	return InventoryHold{}, ErrHoldNotFound
+}
+
+func (r *memoryInventoryHoldRepository) CountActiveOverlapping(ctx context.Context, hotelID string, checkIn, checkOut, now time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	count := 0
+	for _, hold := range r.store.holds {
+		if hold.HotelID == hotelID && hold.activeAt(now) && overlaps(hold.CheckIn, hold.CheckOut, checkIn, checkOut) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryInventoryHoldRepository) Convert(ctx context.Context, id, reservationID int, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.holds {
+		if r.store.holds[i].ID == id && r.store.holds[i].activeAt(now) {
+			r.store.holds[i].Status = HoldConverted
+			r.store.holds[i].ReservationID = reservationID
+			return nil
+		}
+	}
+	return ErrHoldNotActive
+}
+
+func (r *memoryInventoryHoldRepository) Release(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.holds {
+		if r.store.holds[i].ID == id && r.store.holds[i].Status == HoldActive {
+			r.store.holds[i].Status = HoldReleased
+			return nil
+		}
+	}
+	return ErrHoldNotActive
+}
+
+func (r *memoryInventoryHoldRepository) ExpireBefore(ctx context.Context, now time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	expired := 0
+	for i := range r.store.holds {
+		if r.store.holds[i].Status == HoldActive && !r.store.holds[i].ExpiresAt.After(now) {
+			r.store.holds[i].Status = HoldExpired
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+type jobLock struct {
+	owner       string
+	lockedUntil time.Time
+}
+
+type memoryJobRepository struct {
+	store *MemoryStore
+}
+
+func (r *memoryJobRepository) AcquireLock(ctx context.Context, job, owner string, now, until time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	lock, ok := r.store.jobLocks[job]
+	if ok && lock.lockedUntil.After(now) && lock.owner != owner {
+		return false, nil
+	}
+	r.store.jobLocks[job] = jobLock{owner: owner, lockedUntil: until}
+	return true, nil
+}
+
+func (r *memoryJobRepository) CreateRun(ctx context.Context, run *JobRun) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	run.ID = len(r.store.jobRuns) + 1
+	r.store.jobRuns = append(r.store.jobRuns, *run)
+	return nil
+}
+
+func (r *memoryJobRepository) FinishRun(ctx context.Context, run *JobRun) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	for i := range r.store.jobRuns {
+		if r.store.jobRuns[i].ID == run.ID {
+			r.store.jobRuns[i] = *run
+		}
+	}
+	return nil
+}
+
+func (r *memoryJobRepository) ListRuns(ctx context.Context, job string, limit int) ([]JobRun, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	runs := []JobRun{}
+	for i := len(r.store.jobRuns) - 1; i >= 0 && len(runs) < limit; i-- {
+		if job == "" || r.store.jobRuns[i].Job == job {
+			runs = append(runs, r.store.jobRuns[i])
+		}
+	}
+	return runs, nil
+}
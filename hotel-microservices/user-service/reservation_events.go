@@ -0,0 +1,64 @@
+package userservice
+
+import (
+	"context"
+	"log"
+
+	"events"
+)
+
+// ReservationEventsExchange es un exchange topic; la routing key es el tipo
+// del evento, así los consumidores pueden bindear reservation.* o uno solo
+const ReservationEventsExchange = "reservation_events"
+
+// EventProducer identifica a user-service en los sobres que publica
+const EventProducer = "user-service"
+
+// CorrelationHeader trae el correlation ID del pedido; si falta se genera uno
+const CorrelationHeader = "X-Correlation-ID"
+
+// reservationEventType clasifica un cambio de estado: las reservas que dejan
+// de ocupar el cupo antes de la estadía se informan como canceladas
+func reservationEventType(status string) string {
+	switch status {
+	case "cancelled", "rejected", "expired":
+		return events.ReservationCancelled
+	}
+	return events.ReservationModified
+}
+
+// publishReservationCreated informa una reserva que terminó de reservarse
+func (s *UserService) publishReservationCreated(ctx context.Context, reservation Reservation) {
+	s.publishReservationEvent(ctx, events.ReservationCreated, reservation, "")
+}
+
+// publishStatusChange informa el paso de previous al estado actual de la
+// reserva; no publica nada si el estado no cambió
+func (s *UserService) publishStatusChange(ctx context.Context, reservation Reservation, previous string) {
+	if reservation.Status == previous {
+		return
+	}
+	s.publishReservationEvent(ctx, reservationEventType(reservation.Status), reservation, previous)
+}
+
+func (s *UserService) publishReservationEvent(ctx context.Context, eventType string, reservation Reservation, previous string) {
+	envelope, err := events.New(ctx, eventType, EventProducer, events.ReservationPayload{
+		ReservationID:  reservation.ID,
+		UserID:         reservation.UserID,
+		HotelID:        reservation.HotelID,
+		CheckIn:        reservation.CheckIn,
+		CheckOut:       reservation.CheckOut,
+		Guests:         reservation.Guests,
+		Rooms:          reservation.Rooms,
+		Status:         reservation.Status,
+		PreviousStatus: previous,
+	})
+	if err != nil {
+		log.Printf("Error building %s for reservation %d: %v", eventType, reservation.ID, err)
+		return
+	}
+
+	if err := s.publisher.Publish(ReservationEventsExchange, eventType, envelope); err != nil {
+		log.Printf("Error publishing %s for reservation %d: %v", eventType, reservation.ID, err)
+	}
+}
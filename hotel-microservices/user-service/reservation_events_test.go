@@ -0,0 +1,89 @@
+package userservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"events"
+)
+
+// reservationEvent es un sobre de reservation.* con su payload ya leído
+type reservationEvent struct {
+	events.Envelope
+	events.ReservationPayload
+}
+
+func reservationEvents(t *testing.T, env *testEnv) []reservationEvent {
+	t.Helper()
+
+	var published []reservationEvent
+	for _, msg := range env.publisher.Messages() {
+		if msg.Exchange != ReservationEventsExchange {
+			continue
+		}
+		envelope, err := events.Decode(msg.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.RoutingKey != envelope.Type || envelope.Producer != EventProducer {
+			t.Fatalf("expected routing key %s from %s, got %s from %s", envelope.Type, EventProducer, msg.RoutingKey, envelope.Producer)
+		}
+		event := reservationEvent{Envelope: envelope}
+		if err := envelope.DecodePayload(&event.ReservationPayload); err != nil {
+			t.Fatal(err)
+		}
+		published = append(published, event)
+	}
+	return published
+}
+
+func TestReservationEventsArePublished(t *testing.T) {
+	env, _ := newPaymentTestEnv(t)
+	user := env.createUser(t, "juana")
+
+	// Una reserva que no se pudo cobrar nunca existió para los consumidores
+	paidReservationRequest(env, t, user.ID, "hotel-1", FakeTokenDeclined)
+	if published := reservationEvents(t, env); len(published) != 0 {
+		t.Fatalf("expected no events for a failed booking, got %+v", published)
+	}
+
+	var reservation Reservation
+	booked := paidReservationRequest(env, t, user.ID, "hotel-1", "tok_visa")
+	json.Unmarshal(booked.Body.Bytes(), &reservation)
+	if w := env.do("POST", fmt.Sprintf("/reservations/%d/cancel", reservation.ID), tokenFor(t, user.ID, false), nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	published := reservationEvents(t, env)
+	if len(published) != 2 {
+		t.Fatalf("expected created and cancelled events, got %+v", published)
+	}
+	if created := published[0]; created.Type != events.ReservationCreated || created.ReservationID != reservation.ID || created.Status != "confirmed" || created.Rooms != 2 {
+		t.Fatalf("unexpected created event %+v", created)
+	}
+	// La saga corre fuera del request pero conserva su correlation ID
+	if correlationID := booked.Header().Get(CorrelationHeader); correlationID == "" || published[0].CorrelationID != correlationID {
+		t.Fatalf("expected the created event to carry the request correlation ID %q, got %q", correlationID, published[0].CorrelationID)
+	}
+	if cancelled := published[1]; cancelled.Type != events.ReservationCancelled || cancelled.Status != "cancelled" || cancelled.PreviousStatus != "confirmed" {
+		t.Fatalf("unexpected cancelled event %+v", cancelled)
+	}
+}
+
+func TestReservationEventType(t *testing.T) {
+	cases := map[string]string{
+		"cancelled":  events.ReservationCancelled,
+		"rejected":   events.ReservationCancelled,
+		"expired":    events.ReservationCancelled,
+		"confirmed":  events.ReservationModified,
+		"checked_in": events.ReservationModified,
+		"no_show":    events.ReservationModified,
+	}
+	for status, expected := range cases {
+		if got := reservationEventType(status); got != expected {
+			t.Fatalf("expected %s for %s, got %s", expected, status, got)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package userservice
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"events"
+)
+
+// Pasos de la saga de reserva, en orden. BookingSaga.Step es el último que
+// terminó. La reserva en Amadeus es el punto de no retorno: antes de
+// confirmarla un fallo se compensa hacia atrás, después se completa hacia adelante.
+const (
+	SagaStepInventoryHeld     = "inventory_held"
+	SagaStepPaymentAuthorized = "payment_authorized"
+	SagaStepUpstreamConfirmed = "upstream_confirmed"
+	SagaStepCommitted         = "committed"
+)
+
+// Estados de BookingSaga.Status
+const (
+	SagaRunning      = "running"
+	SagaCompensating = "compensating"
+	SagaCompleted    = "completed"
+	SagaCompensated  = "compensated"
+)
+
+// DefaultSagaStaleAfter es cuánto tiene que estar quieta una saga para que
+// ResumeBookingSagas la dé por interrumpida
+const DefaultSagaStaleAfter = 5 * time.Minute
+
+// BookingSaga es el estado persistido de una reserva en curso
+type BookingSaga struct {
+	ID            int       `json:"id"`
+	ReservationID int       `json:"reservation_id"`
+	Step          string    `json:"step"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// bookingFailure es un fallo de la saga que ya fue compensado; Code es el status HTTP
+type bookingFailure struct {
+	Code    int
+	Message string
+}
+
+// bookReservation corre la saga completa: retiene el cupo insertando la
+// reserva como processing (convirtiendo la retención, si trae una), autoriza
+// el pago, reserva en Amadeus y confirma.
+// No usa el contexto del request: una vez empezada, la saga termina o se
+// compensa aunque el cliente se desconecte. Del request sólo toma el
+// correlation ID para los eventos.
+func (s *UserService) bookReservation(correlationID string, reservation *Reservation) *bookingFailure {
+	ctx := events.WithCorrelationID(context.Background(), correlationID)
+
+	// Con una retención el cupo ya está apartado para el usuario
+	if reservation.HoldID != 0 {
+		if _, failure := s.holdForReservation(ctx, reservation); failure != nil {
+			return failure
+		}
+	}
+
+	// El token no se guarda, solo se usa para autorizar
+	paymentToken := reservation.PaymentToken
+	reservation.PaymentToken = ""
+
+	// Paso 1: retener el cupo. Sin retención, la verificación de fechas y el
+	// alta van juntas bajo el lock del hotel, como al crear una retención.
+	reservation.Status = "processing"
+	var err error
+	if reservation.HoldID != 0 {
+		err = s.reservations.Create(ctx, reservation)
+	} else {
+		err = s.reservations.CreateIfAvailable(ctx, reservation, time.Now())
+	}
+	if err == ErrDatesUnavailable {
+		return &bookingFailure{http.StatusConflict, "Las fechas seleccionadas ya no están disponibles"}
+	}
+	if err != nil {
+		return &bookingFailure{http.StatusInternalServerError, err.Error()}
+	}
+	s.invalidateAvailability(reservation.HotelID)
+	saga := &BookingSaga{ReservationID: reservation.ID, Step: SagaStepInventoryHeld, Status: SagaRunning}
+	if err := s.sagas.Create(ctx, saga); err != nil {
+		reservation.Status = "failed"
+		if err := s.reservations.UpdateValidation(ctx, reservation); err == nil {
+			s.invalidateAvailability(reservation.HotelID)
+		}
+		return &bookingFailure{http.StatusInternalServerError, err.Error()}
+	}
+	if reservation.HoldID != 0 {
+		// Convert es atómico: si otra reserva usó la retención o venció recién,
+		// esta se compensa. Una vez convertida no se devuelve aunque la saga falle.
+		if err := s.holds.Convert(ctx, reservation.HoldID, reservation.ID, time.Now()); err != nil {
+			if err == ErrHoldNotActive {
+				return s.compensateBooking(ctx, saga, reservation, "failed", bookingFailure{http.StatusGone, "La retención ya no está activa"})
+			}
+			return s.compensateBooking(ctx, saga, reservation, "failed", bookingFailure{http.StatusInternalServerError, err.Error()})
+		}
+	}
+
+	// Paso 2: autorizar el pago
+	payment, err := s.authorizePayment(ctx, *reservation, paymentToken)
+	if err == ErrPaymentDeclined {
+		return s.compensateBooking(ctx, saga, reservation, "failed", bookingFailure{http.StatusPaymentRequired, "El pago fue rechazado"})
+	}
+	if err != nil {
+		log.Printf("Error authorizing payment for reservation %d: %v", reservation.ID, err)
+		return s.compensateBooking(ctx, saga, reservation, "failed", bookingFailure{http.StatusServiceUnavailable, "No se pudo procesar el pago, intente más tarde"})
+	}
+	if payment != nil {
+		payment.ReservationID = reservation.ID
+		if err := s.paymentRecords.Create(ctx, payment); err != nil {
+			// Sin registro la compensación no encontraría la autorización
+			s.voidAuthorization(payment)
+			return s.compensateBooking(ctx, saga, reservation, "failed", bookingFailure{http.StatusInternalServerError, err.Error()})
+		}
+		reservation.Payment = payment
+	}
+	s.advanceSaga(ctx, saga, SagaStepPaymentAuthorized)
+
+	// Paso 3: reservar en Amadeus
+	decision := s.decideReservation(ctx, reservation)
+	if decision.Reject {
+		reservation.ValidationReason = decision.Reason
+		if decision.RejectCode == http.StatusConflict {
+			return s.compensateBooking(ctx, saga, reservation, "rejected", bookingFailure{http.StatusConflict, "No hay disponibilidad según Amadeus"})
+		}
+		return s.compensateBooking(ctx, saga, reservation, "failed", bookingFailure{http.StatusServiceUnavailable, "No se pudo validar la disponibilidad con Amadeus, intente más tarde"})
+	}
+	reservation.Status = decision.Status
+	reservation.ValidationReason = decision.Reason
+	if err := s.reservations.UpdateValidation(ctx, reservation); err != nil {
+		return s.compensateBooking(ctx, saga, reservation, "failed", bookingFailure{http.StatusInternalServerError, err.Error()})
+	}
+	s.advanceSaga(ctx, saga, SagaStepUpstreamConfirmed)
+
+	// Paso 4: cobrar y confirmar
+	if failure := s.commitBooking(ctx, saga, reservation, payment); failure != nil {
+		return failure
+	}
+	s.publishReservationCreated(ctx, *reservation)
+	return nil
+}
+
+// commitBooking cobra la autorización y cierra la saga. Las reservas pending
+// de Amadeus se cobran cuando se revalidan.
+func (s *UserService) commitBooking(ctx context.Context, saga *BookingSaga, reservation *Reservation, payment *Payment) *bookingFailure {
+	if payment != nil && payment.Status == PaymentAuthorized && reservation.Status == "confirmed" {
+		if err := s.capturePayment(ctx, reservation, payment); err != nil && reservation.Status == "cancelled" {
+			// capturePayment ya liberó la autorización y canceló en Amadeus
+			saga.Status = SagaCompensated
+			saga.Error = failureReason("capture_failed", err)
+			s.saveSaga(ctx, saga)
+			return &bookingFailure{http.StatusPaymentRequired, "No se pudo cobrar la reserva"}
+		}
+	}
+
+	saga.Step = SagaStepCommitted
+	saga.Status = SagaCompleted
+	s.saveSaga(ctx, saga)
+	return nil
+}
+
+// compensateBooking deshace los pasos hechos: libera el pago, cancela en
+// Amadeus si llegó a reservarse y deja la reserva con status, liberando el cupo
+func (s *UserService) compensateBooking(ctx context.Context, saga *BookingSaga, reservation *Reservation, status string, failure bookingFailure) *bookingFailure {
+	saga.Status = SagaCompensating
+	saga.Error = failure.Message
+	if len(saga.Error) > 255 {
+		saga.Error = saga.Error[:255]
+	}
+	s.saveSaga(ctx, saga)
+
+	if err := s.releasePayment(ctx, reservation.ID); err != nil {
+		log.Printf("Error releasing payment of reservation %d: %v", reservation.ID, err)
+	}
+	if err := s.cancelWithAmadeus(ctx, *reservation); err != nil {
+		log.Printf("Error compensating Amadeus booking: %v", err)
+	}
+
+	reservation.Status = status
+	if err := s.reservations.UpdateValidation(ctx, reservation); err != nil {
+		// Queda en compensating para que ResumeBookingSagas lo reintente
+		log.Printf("Error releasing reservation %d: %v", reservation.ID, err)
+		return &failure
+	}
+	s.invalidateAvailability(reservation.HotelID)
+
+	saga.Status = SagaCompensated
+	s.saveSaga(ctx, saga)
+	return &failure
+}
+
+func (s *UserService) advanceSaga(ctx context.Context, saga *BookingSaga, step string) {
+	saga.Step = step
+	s.saveSaga(ctx, saga)
+}
+
+func (s *UserService) saveSaga(ctx context.Context, saga *BookingSaga) {
+	if err := s.sagas.Update(ctx, saga); err != nil {
+		log.Printf("Error saving booking saga %d: %v", saga.ID, err)
+	}
+}
+
+// ResumeBookingSagas retoma las sagas que quedaron a medias, p.ej. porque el
+// proceso se cayó. Solo toca las que no avanzan hace más de staleAfter, para
+// no pisar las que otra réplica está corriendo, y reclama cada una con Claim
+// antes de tocarla, así dos réplicas nunca retoman la misma. Las que ya
+// reservaron en Amadeus se completan; el resto se compensa. Devuelve cuántas retomó.
+//
+// Si el proceso se cayó durante la llamada a Amadeus, la reserva upstream se
+// busca por referencia para cancelarla. Si se cayó durante la llamada al
+// provider de pagos la autorización puede haber quedado sin registrar; esa no
+// se puede deshacer desde acá y vence del lado del proveedor.
+func (s *UserService) ResumeBookingSagas(ctx context.Context, staleAfter time.Duration) (int, error) {
+	staleBefore := time.Now().Add(-staleAfter)
+	sagas, err := s.sagas.ListUnfinished(ctx, staleBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	for _, saga := range sagas {
+		claimed, err := s.sagas.Claim(ctx, &saga, staleBefore)
+		if err != nil {
+			log.Printf("Error claiming booking saga %d: %v", saga.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		resumed++
+		reservation, err := s.reservations.FindByID(ctx, saga.ReservationID)
+		if err != nil {
+			log.Printf("Error loading reservation of booking saga %d: %v", saga.ID, err)
+			continue
+		}
+
+		if saga.Status == SagaRunning && saga.Step == SagaStepUpstreamConfirmed {
+			var payment *Payment
+			if record, err := s.paymentRecords.FindByReservation(ctx, reservation.ID); err == nil {
+				payment = &record
+			}
+			log.Printf("Resuming booking saga %d for reservation %d", saga.ID, reservation.ID)
+			if s.commitBooking(ctx, &saga, &reservation, payment) == nil {
+				s.publishReservationCreated(ctx, reservation)
+			}
+			continue
+		}
+
+		if saga.Step == SagaStepPaymentAuthorized && reservation.AmadeusBookingID == "" && s.amadeus.Configured() {
+			if err := s.recoverAmadeusBooking(ctx, &reservation); err != nil && err != ErrAmadeusBookingNotFound {
+				// Sin saber si hay reserva upstream no se compensa; se reintenta en la próxima pasada
+				log.Printf("Error looking up Amadeus booking of saga %d: %v", saga.ID, err)
+				continue
+			}
+		}
+		log.Printf("Compensating interrupted booking saga %d for reservation %d (step %s)", saga.ID, reservation.ID, saga.Step)
+		s.compensateBooking(ctx, &saga, &reservation, "failed", bookingFailure{http.StatusInternalServerError, "booking interrupted"})
+	}
+
+	return resumed, nil
+}
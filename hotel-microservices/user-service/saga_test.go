@@ -0,0 +1,146 @@
+package userservice
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func sagaFor(t *testing.T, env *testEnv, reservationID int) BookingSaga {
+	t.Helper()
+
+	env.store.mu.Lock()
+	defer env.store.mu.Unlock()
+	for _, saga := range env.store.sagas {
+		if saga.ReservationID == reservationID {
+			return saga
+		}
+	}
+	t.Fatalf("no booking saga for reservation %d", reservationID)
+	return BookingSaga{}
+}
+
+func TestBookingSagaCompletes(t *testing.T) {
+	env, _ := newPaymentTestEnv(t)
+	user := env.createUser(t, "juana")
+
+	var reservation Reservation
+	json.Unmarshal(paidReservationRequest(env, t, user.ID, "hotel-1", "tok_visa").Body.Bytes(), &reservation)
+
+	if saga := sagaFor(t, env, reservation.ID); saga.Step != SagaStepCommitted || saga.Status != SagaCompleted {
+		t.Fatalf("expected a completed saga, got %+v", saga)
+	}
+}
+
+func TestBookingSagaCompensatesAmadeusRejection(t *testing.T) {
+	env, provider := newPaymentTestEnv(t)
+	fake := NewFakeAmadeus()
+	t.Cleanup(fake.Close)
+	env.service.amadeus = fake.Client()
+	env.mapHotel(t, "hotel-1", "ADFULL01")
+	fake.NoAvailability("ADFULL01")
+	user := env.createUser(t, "juana")
+
+	if w := paidReservationRequest(env, t, user.ID, "hotel-1", "tok_visa"); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reservations, _ := env.store.Reservations.List(context.Background())
+	if len(reservations) != 1 || reservations[0].Status != "rejected" || reservations[0].ValidationReason != ReasonAmadeusNoOffers {
+		t.Fatalf("expected a rejected reservation, got %+v", reservations)
+	}
+	payment, _ := env.store.Payments.FindByReservation(context.Background(), reservations[0].ID)
+	if authorization, _ := provider.Authorization(payment.AuthorizationID); payment.Status != PaymentVoided || authorization.Status != PaymentVoided {
+		t.Fatalf("expected the authorization to be voided, got %+v", payment)
+	}
+	if saga := sagaFor(t, env, reservations[0].ID); saga.Status != SagaCompensated || saga.Step != SagaStepPaymentAuthorized {
+		t.Fatalf("expected a compensated saga stopped after the payment, got %+v", saga)
+	}
+}
+
+// interruptedBooking deja una reserva y su saga como si el proceso se hubiera
+// caído después de step
+func interruptedBooking(t *testing.T, env *testEnv, provider *FakePaymentProvider, status, step string) Reservation {
+	t.Helper()
+	ctx := context.Background()
+
+	reservation := Reservation{UserID: 1, HotelID: "hotel-1", CheckIn: date("2026-07-10"), CheckOut: date("2026-07-12"), Status: status}
+	env.store.Reservations.Create(ctx, &reservation)
+
+	authorizationID, _ := provider.Authorize(ctx, PaymentRequest{Amount: 200, Currency: DefaultPaymentCurrency})
+	env.store.Payments.Create(ctx, &Payment{ReservationID: reservation.ID, Provider: "fake", AuthorizationID: authorizationID, Amount: 200, Status: PaymentAuthorized})
+	env.store.BookingSagas.Create(ctx, &BookingSaga{ReservationID: reservation.ID, Step: step, Status: SagaRunning})
+	return reservation
+}
+
+func TestResumeBookingSagas(t *testing.T) {
+	env, provider := newPaymentTestEnv(t)
+	ctx := context.Background()
+
+	beforePivot := interruptedBooking(t, env, provider, "processing", SagaStepPaymentAuthorized)
+	afterPivot := interruptedBooking(t, env, provider, "confirmed", SagaStepUpstreamConfirmed)
+
+	// Una saga reciente puede estar corriendo en otra réplica
+	if _, err := env.service.ResumeBookingSagas(ctx, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if saga := sagaFor(t, env, beforePivot.ID); saga.Status != SagaRunning {
+		t.Fatalf("expected a recent saga to be left alone, got %+v", saga)
+	}
+
+	if _, err := env.service.ResumeBookingSagas(ctx, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, _ := env.store.Reservations.FindByID(ctx, beforePivot.ID)
+	payment, _ := env.store.Payments.FindByReservation(ctx, beforePivot.ID)
+	if stored.Status != "failed" || payment.Status != PaymentVoided || sagaFor(t, env, beforePivot.ID).Status != SagaCompensated {
+		t.Fatalf("expected the saga before the pivot to be compensated, got %q / %+v", stored.Status, payment)
+	}
+
+	stored, _ = env.store.Reservations.FindByID(ctx, afterPivot.ID)
+	payment, _ = env.store.Payments.FindByReservation(ctx, afterPivot.ID)
+	if saga := sagaFor(t, env, afterPivot.ID); stored.Status != "confirmed" || payment.Status != PaymentCaptured || saga.Status != SagaCompleted {
+		t.Fatalf("expected the saga after the pivot to be completed, got %q / %+v / %+v", stored.Status, payment, saga)
+	}
+}
+
+func TestResumeBookingSagasSkipsClaimedSagas(t *testing.T) {
+	env, provider := newPaymentTestEnv(t)
+	ctx := context.Background()
+	reservation := interruptedBooking(t, env, provider, "processing", SagaStepPaymentAuthorized)
+
+	env.store.mu.Lock()
+	env.store.sagas[0].UpdatedAt = time.Now().Add(-10 * time.Minute)
+	env.store.mu.Unlock()
+
+	// Las dos réplicas listan la misma saga y la reclama la primera
+	staleBefore := time.Now().Add(-DefaultSagaStaleAfter)
+	sagas, _ := env.store.BookingSagas.ListUnfinished(ctx, staleBefore)
+	first, second := sagas[0], sagas[0]
+	if claimed, err := env.store.BookingSagas.Claim(ctx, &first, staleBefore); !claimed || err != nil {
+		t.Fatalf("expected the first claim to win, got %v, %v", claimed, err)
+	}
+	if claimed, _ := env.store.BookingSagas.Claim(ctx, &second, staleBefore); claimed {
+		t.Fatal("expected a claimed saga not to be claimed again")
+	}
+
+	if resumed, err := env.service.ResumeBookingSagas(ctx, DefaultSagaStaleAfter); err != nil || resumed != 0 {
+		t.Fatalf("expected the claimed saga to be skipped, got %d, %v", resumed, err)
+	}
+	payment, _ := env.store.Payments.FindByReservation(ctx, reservation.ID)
+	if saga := sagaFor(t, env, reservation.ID); saga.Status != SagaRunning || payment.Status != PaymentAuthorized {
+		t.Fatalf("expected the claimed saga to be left to its owner, got %+v / %+v", saga, payment)
+	}
+}
+
+func TestCancelReservationWhileProcessing(t *testing.T) {
+	env, provider := newPaymentTestEnv(t)
+	reservation := interruptedBooking(t, env, provider, "processing", SagaStepInventoryHeld)
+
+	if w := env.do("POST", "/reservations/1/cancel", tokenFor(t, reservation.UserID, true), nil); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 while the saga runs, got %d", w.Code)
+	}
+}
@@ -0,0 +1,179 @@
+package userservice
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Estados de JobRun.Status
+const (
+	JobRunRunning   = "running"
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+)
+
+// Job es una tarea periódica. Run devuelve cuántos registros procesó.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) (int, error)
+}
+
+// JobRun es el registro de una corrida de un job
+type JobRun struct {
+	ID         int        `json:"id"`
+	Job        string     `json:"job"`
+	Owner      string     `json:"owner"`
+	Status     string     `json:"status"`
+	Processed  int        `json:"processed"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// DefaultJobLease es cuánto dura el lease de un job que está corriendo. Se
+// renueva cada DefaultJobLease/3, así que sólo vence si la réplica se cae.
+const DefaultJobLease = time.Minute
+
+// Scheduler corre los jobs registrados. Antes de cada corrida toma un lease
+// del job en la base y lo renueva mientras corre; al terminar lo deja hasta el
+// próximo intervalo, así con varias réplicas cada job corre en una sola por
+// intervalo y dos corridas nunca se pisan.
+type Scheduler struct {
+	repo  JobRepository
+	owner string
+	jobs  []Job
+	lease time.Duration
+}
+
+// NewScheduler crea un scheduler; owner identifica a la réplica en los locks y las corridas
+func NewScheduler(repo JobRepository, owner string) *Scheduler {
+	return &Scheduler{repo: repo, owner: owner, lease: DefaultJobLease}
+}
+
+func (s *Scheduler) Register(jobs ...Job) {
+	s.jobs = append(s.jobs, jobs...)
+}
+
+// RunOnce corre job si esta réplica consigue el lease. Devuelve false si lo tiene otra.
+func (s *Scheduler) RunOnce(ctx context.Context, job Job) (bool, error) {
+	now := time.Now()
+	acquired, err := s.repo.AcquireLock(ctx, job.Name, s.owner, now, now.Add(s.lease))
+	if err != nil || !acquired {
+		return false, err
+	}
+
+	run := &JobRun{Job: job.Name, Owner: s.owner, Status: JobRunRunning, StartedAt: now}
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		return true, err
+	}
+
+	runCtx, stop := context.WithCancel(ctx)
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		s.renewLease(runCtx, job.Name, stop)
+	}()
+	processed, runErr := job.Run(runCtx)
+	stop()
+	<-renewed
+
+	// Si el intervalo ya pasó el lease queda vencido y la próxima corrida la toma cualquiera
+	if _, err := s.repo.AcquireLock(ctx, job.Name, s.owner, time.Now(), now.Add(job.Interval)); err != nil {
+		log.Printf("Error keeping lease of job %s: %v", job.Name, err)
+	}
+
+	run.Processed = processed
+	run.Status = JobRunSucceeded
+	if runErr != nil {
+		run.Status = JobRunFailed
+		run.Error = runErr.Error()
+		if len(run.Error) > 255 {
+			run.Error = run.Error[:255]
+		}
+	}
+	if err := s.repo.FinishRun(ctx, run); err != nil {
+		return true, err
+	}
+	return true, runErr
+}
+
+// renewLease extiende el lease de job hasta que se cancele ctx. Si otra réplica
+// lo tomó, p.ej. porque no se pudo renovar a tiempo, cancela la corrida con lost.
+func (s *Scheduler) renewLease(ctx context.Context, job string, lost context.CancelFunc) {
+	ticker := time.NewTicker(s.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		renewed, err := s.repo.AcquireLock(ctx, job, s.owner, now, now.Add(s.lease))
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Error renewing lease of job %s: %v", job, err)
+			continue
+		}
+		if err == nil && !renewed {
+			log.Printf("Lost lease of job %s, cancelling run", job)
+			lost()
+			return
+		}
+	}
+}
+
+// Start corre cada job en su propio ticker hasta que se cancele ctx
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go func(job Job) {
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+
+			for {
+				if _, err := s.RunOnce(ctx, job); err != nil {
+					log.Printf("Error running job %s: %v", job.Name, err)
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(job)
+	}
+}
+
+func (s *UserService) listJobRuns(c *gin.Context) {
+	isAdmin, _ := c.Get("is_admin")
+	if !isAdmin.(bool) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	runs, err := s.jobs.ListRuns(c.Request.Context(), c.Query("job"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
@@ -0,0 +1,590 @@
+package userservice
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"events"
+)
+
+type User struct {
+	ID        int       `json:"id" db:"id"`
+	Username  string    `json:"username" db:"username"`
+	Email     string    `json:"email" db:"email"`
+	Password  string    `json:"password,omitempty" db:"password"`
+	IsAdmin   bool      `json:"is_admin" db:"is_admin"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type Reservation struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	HotelID   string    `json:"hotel_id" db:"hotel_id"`
+	CheckIn   time.Time `json:"check_in" db:"check_in"`
+	CheckOut  time.Time `json:"check_out" db:"check_out"`
+	Guests    int       `json:"guests" db:"guests"`
+	Rooms     int       `json:"rooms" db:"rooms"`
+	RoomType  string    `json:"room_type" db:"room_type"`
+	Status    string    `json:"status" db:"status"`
+	AmadeusID string    `json:"amadeus_id" db:"amadeus_id"`
+	// ValidationReason explica cómo se decidió el estado de la reserva frente a Amadeus
+	ValidationReason string `json:"validation_reason" db:"validation_reason"`
+	// Referencias de la reserva creada en Amadeus, si la hay
+	AmadeusBookingID   string    `json:"amadeus_booking_id" db:"amadeus_booking_id"`
+	ConfirmationNumber string    `json:"confirmation_number" db:"confirmation_number"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	// PaymentToken es el medio de pago tokenizado; no se guarda
+	PaymentToken string   `json:"payment_token,omitempty" db:"-"`
+	Payment      *Payment `json:"payment,omitempty" db:"-"`
+	// HoldID es la retención que se convierte en esta reserva; no se guarda
+	HoldID int `json:"hold_id,omitempty" db:"-"`
+}
+
+type UserService struct {
+	users         UserRepository
+	reservations  ReservationRepository
+	mappings      HotelMappingRepository
+	erasureJobs   ErasureJobRepository
+	cache         Cache
+	publisher     EventPublisher
+	amadeus       *AmadeusClient
+	amadeusPolicy AmadeusFailurePolicy
+	// payments es nil si los pagos están deshabilitados
+	payments       PaymentProvider
+	paymentRecords PaymentRepository
+	paymentPolicy  PaymentFailurePolicy
+	sagas          BookingSagaRepository
+	holds          InventoryHoldRepository
+	holdTTL        time.Duration
+	jobs           JobRepository
+	pendingTTL     time.Duration
+	// hotelServiceURL es la URL de la colección de hoteles, p.ej. http://nginx/api/hotels
+	hotelServiceURL string
+	jwtSecret       string
+}
+
+type Claims struct {
+	UserID  int  `json:"user_id"`
+	IsAdmin bool `json:"is_admin"`
+	jwt.RegisteredClaims
+}
+
+type Repositories struct {
+	Users        UserRepository
+	Reservations ReservationRepository
+	Mappings     HotelMappingRepository
+	ErasureJobs  ErasureJobRepository
+	Payments     PaymentRepository
+	BookingSagas BookingSagaRepository
+	Holds        InventoryHoldRepository
+	Jobs         JobRepository
+}
+
+type Config struct {
+	AmadeusBaseURL  string
+	AmadeusClientID string
+	AmadeusSecret   string
+	AmadeusPayment  AmadeusPaymentCard
+	// AmadeusFailurePolicy vacío equivale a AmadeusFailOpen
+	AmadeusFailurePolicy AmadeusFailurePolicy
+	// PaymentProvider nil deshabilita los pagos
+	PaymentProvider PaymentProvider
+	// PaymentFailurePolicy vacío equivale a PaymentFailPending
+	PaymentFailurePolicy PaymentFailurePolicy
+	// HoldTTL cero equivale a DefaultHoldTTL
+	HoldTTL time.Duration
+	// PendingReservationTTL cero equivale a DefaultPendingReservationTTL
+	PendingReservationTTL time.Duration
+	HotelServiceURL       string
+	JWTSecret             string
+}
+
+func NewUserService(repos Repositories, cache Cache, publisher EventPublisher, config Config) *UserService {
+	amadeus := NewAmadeusClient(config.AmadeusBaseURL, config.AmadeusClientID, config.AmadeusSecret)
+	amadeus.TokenCache = cache
+	amadeus.Payment = config.AmadeusPayment
+
+	holdTTL := config.HoldTTL
+	if holdTTL == 0 {
+		holdTTL = DefaultHoldTTL
+	}
+	pendingTTL := config.PendingReservationTTL
+	if pendingTTL == 0 {
+		pendingTTL = DefaultPendingReservationTTL
+	}
+
+	return &UserService{
+		users:           repos.Users,
+		reservations:    repos.Reservations,
+		mappings:        repos.Mappings,
+		erasureJobs:     repos.ErasureJobs,
+		cache:           cache,
+		publisher:       publisher,
+		amadeus:         amadeus,
+		amadeusPolicy:   config.AmadeusFailurePolicy,
+		payments:        config.PaymentProvider,
+		paymentRecords:  repos.Payments,
+		paymentPolicy:   config.PaymentFailurePolicy,
+		sagas:           repos.BookingSagas,
+		holds:           repos.Holds,
+		holdTTL:         holdTTL,
+		jobs:            repos.Jobs,
+		pendingTTL:      pendingTTL,
+		hotelServiceURL: config.HotelServiceURL,
+		jwtSecret:       config.JWTSecret,
+	}
+}
+
+func NewRouter(service *UserService) *gin.Engine {
+	router := gin.Default()
+
+	// CORS middleware
+	router.Use(func(c *gin.Context) {
+		c.Header("Access-Control-Allow-Origin", "*")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Correlation-ID")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	})
+
+	// Los eventos que produce el pedido llevan su correlation ID
+	router.Use(func(c *gin.Context) {
+		correlationID := c.GetHeader(CorrelationHeader)
+		if correlationID == "" {
+			correlationID = events.NewID()
+		}
+		c.Header(CorrelationHeader, correlationID)
+		c.Request = c.Request.WithContext(events.WithCorrelationID(c.Request.Context(), correlationID))
+		c.Next()
+	})
+
+	// Auth routes
+	router.POST("/auth/register", service.register)
+	router.POST("/auth/login", service.login)
+
+	// User routes
+	router.GET("/users", service.authMiddleware(), service.getUsers)
+	router.GET("/users/:id", service.authMiddleware(), service.getUser)
+	router.GET("/users/:id/reservations", service.authMiddleware(), service.getUserReservations)
+
+	// GDPR routes
+	router.GET("/users/me/export", service.authMiddleware(), service.exportUserData)
+	router.POST("/users/:id/erasure", service.authMiddleware(), service.requestErasure)
+	router.GET("/users/:id/erasure", service.authMiddleware(), service.getErasureJob)
+
+	// Reservation routes
+	router.POST("/reservations", service.authMiddleware(), service.createReservation)
+	router.GET("/reservations", service.authMiddleware(), service.getReservations)
+	router.POST("/reservations/:id/cancel", service.authMiddleware(), service.cancelReservation)
+	router.GET("/reservations/:id/payment", service.authMiddleware(), service.getReservationPayment)
+	router.POST("/reservations/:id/check-in", service.authMiddleware(), service.checkInReservation)
+
+	// Inventory hold routes
+	router.POST("/holds", service.authMiddleware(), service.createHold)
+	router.GET("/holds/:id", service.authMiddleware(), service.getHold)
+	router.DELETE("/holds/:id", service.authMiddleware(), service.releaseHold)
+
+	// Hotel ↔ Amadeus mapping routes (admin)
+	router.GET("/mappings", service.authMiddleware(), service.listMappings)
+	router.GET("/mappings/unmapped", service.authMiddleware(), service.getUnmappedHotels)
+	router.GET("/mappings/lookup", service.authMiddleware(), service.lookupAmadeusHotels)
+	router.GET("/mappings/:hotel_id", service.authMiddleware(), service.getMapping)
+	router.PUT("/mappings/:hotel_id", service.authMiddleware(), service.setMapping)
+	router.DELETE("/mappings/:hotel_id", service.authMiddleware(), service.deleteMapping)
+
+	// Scheduled job routes (admin)
+	router.GET("/jobs/runs", service.authMiddleware(), service.listJobRuns)
+
+	// Availability route
+	router.GET("/availability", service.checkAvailability)
+
+	return router
+}
+
+func (s *UserService) CreateAdminUser() {
+	count, err := s.users.CountAdmins(context.Background())
+	if err != nil {
+		log.Printf("Error checking admin users: %v", err)
+		return
+	}
+
+	if count == 0 {
+		admin := &User{
+			Username: "admin",
+			Email:    "admin@hotel.com",
+			Password: fmt.Sprintf("%x", md5.Sum([]byte("admin123"))),
+			IsAdmin:  true,
+		}
+		if err := s.users.Create(context.Background(), admin); err != nil {
+			log.Printf("Error creating admin user: %v", err)
+		} else {
+			log.Println("Admin user created: username=admin, password=admin123")
+		}
+	}
+}
+
+func (s *UserService) register(c *gin.Context) {
+	var user User
+	if err := c.ShouldBindJSON(&user); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Hash password
+	user.Password = fmt.Sprintf("%x", md5.Sum([]byte(user.Password)))
+	user.IsAdmin = false
+
+	if err := s.users.Create(c.Request.Context(), &user); err != nil {
+		if err == ErrDuplicateUser {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Username or email already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	user.Password = ""
+
+	c.JSON(http.StatusCreated, user)
+}
+
+func (s *UserService) login(c *gin.Context) {
+	var credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	if err := c.ShouldBindJSON(&credentials); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword := fmt.Sprintf("%x", md5.Sum([]byte(credentials.Password)))
+
+	user, err := s.users.FindByCredentials(c.Request.Context(), credentials.Username, hashedPassword)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+
+	// Create JWT token
+	claims := Claims{
+		UserID:  user.ID,
+		IsAdmin: user.IsAdmin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token": tokenString,
+		"user":  user,
+	})
+}
+
+func (s *UserService) authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := c.GetHeader("Authorization")
+		if tokenString == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "No token provided"})
+			c.Abort()
+			return
+		}
+
+		// Remove "Bearer " prefix
+		if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
+			tokenString = tokenString[7:]
+		}
+
+		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+			return []byte(s.jwtSecret), nil
+		})
+
+		if err != nil || !token.Valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+
+		claims, ok := token.Claims.(*Claims)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("is_admin", claims.IsAdmin)
+		c.Next()
+	}
+}
+
+func (s *UserService) getUsers(c *gin.Context) {
+	isAdmin, _ := c.Get("is_admin")
+	if !isAdmin.(bool) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	users, err := s.users.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+func (s *UserService) getUser(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := s.users.FindByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// availabilityCacheTTL es cuántos segundos se cachea una consulta de disponibilidad
+const availabilityCacheTTL = 10
+
+// availabilityCacheKey incluye la generación del hotel: invalidateAvailability
+// la cambia y así descarta de una vez todo lo cacheado para el hotel, sea cual
+// sea el rango de fechas consultado
+func (s *UserService) availabilityCacheKey(hotelID, checkIn, checkOut string) string {
+	generation := ""
+	if item, err := s.cache.Get("availability_generation_" + hotelID); err == nil {
+		generation = string(item.Value)
+	}
+	return fmt.Sprintf("availability_%s_%s_%s_%s", hotelID, checkIn, checkOut, generation)
+}
+
+// invalidateAvailability descarta la disponibilidad cacheada del hotel. La
+// generación dura más que las entradas, así al vencer no revive ninguna vieja.
+func (s *UserService) invalidateAvailability(hotelID string) {
+	err := s.cache.Set(&memcache.Item{
+		Key:        "availability_generation_" + hotelID,
+		Value:      []byte(strconv.FormatInt(time.Now().UnixNano(), 10)),
+		Expiration: 6 * availabilityCacheTTL,
+	})
+	if err != nil {
+		log.Printf("Error invalidating availability of hotel %s: %v", hotelID, err)
+	}
+}
+
+func (s *UserService) checkAvailability(c *gin.Context) {
+	hotelID := c.Query("hotel_id")
+	checkIn := c.Query("check_in")
+	checkOut := c.Query("check_out")
+
+	if hotelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing hotel_id parameter"})
+		return
+	}
+
+	// Si no hay fechas, asumir disponible
+	if checkIn == "" || checkOut == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"available": true,
+			"hotel_id":  hotelID,
+		})
+		return
+	}
+
+	checkInDate, err := time.Parse("2006-01-02", checkIn)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check_in date, expected YYYY-MM-DD"})
+		return
+	}
+	checkOutDate, err := time.Parse("2006-01-02", checkOut)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check_out date, expected YYYY-MM-DD"})
+		return
+	}
+
+	// Create cache key
+	cacheKey := s.availabilityCacheKey(hotelID, checkIn, checkOut)
+
+	// Check cache first
+	item, err := s.cache.Get(cacheKey)
+	if err == nil {
+		var result map[string]interface{}
+		json.Unmarshal(item.Value, &result)
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	// Check database for existing reservations and holds
+	count, err := s.countBooked(c.Request.Context(), hotelID, checkInDate, checkOutDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	available := count == 0
+	result := map[string]interface{}{
+		"available": available,
+		"hotel_id":  hotelID,
+		"check_in":  checkIn,
+		"check_out": checkOut,
+	}
+
+	// Cache result for 10 seconds
+	resultJSON, _ := json.Marshal(result)
+	s.cache.Set(&memcache.Item{
+		Key:        cacheKey,
+		Value:      resultJSON,
+		Expiration: availabilityCacheTTL,
+	})
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *UserService) createReservation(c *gin.Context) {
+	var reservation Reservation
+	if err := c.ShouldBindJSON(&reservation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	reservation.UserID = userID.(int)
+	reservation.Payment = nil
+
+	failure := s.bookReservation(events.CorrelationID(c.Request.Context()), &reservation)
+	if failure != nil {
+		c.JSON(failure.Code, gin.H{"error": failure.Message})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+func (s *UserService) getReservations(c *gin.Context) {
+	isAdmin, _ := c.Get("is_admin")
+	if !isAdmin.(bool) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	reservations, err := s.reservations.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reservations)
+}
+
+func (s *UserService) cancelReservation(c *gin.Context) {
+	reservationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		return
+	}
+
+	reservation, err := s.reservations.FindByID(c.Request.Context(), reservationID)
+	if err == ErrReservationNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reservation not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentUserID, _ := c.Get("user_id")
+	isAdmin, _ := c.Get("is_admin")
+	if currentUserID.(int) != reservation.UserID && !isAdmin.(bool) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	switch reservation.Status {
+	case "cancelled", "rejected", "failed", "expired", "checked_in", "no_show", "completed":
+		c.JSON(http.StatusConflict, gin.H{"error": "Reservation is already " + reservation.Status})
+		return
+	case "processing":
+		c.JSON(http.StatusConflict, gin.H{"error": "Reservation is still being booked"})
+		return
+	}
+
+	// Primero devolver el pago y cancelar en Amadeus, así un fallo upstream se
+	// puede reintentar; releasePayment no hace nada si el pago ya se devolvió
+	if err := s.releasePayment(c.Request.Context(), reservation.ID); err != nil {
+		log.Printf("Error releasing payment of reservation %d: %v", reservation.ID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "No se pudo devolver el pago, intente más tarde"})
+		return
+	}
+
+	if err := s.cancelWithAmadeus(c.Request.Context(), reservation); err != nil {
+		log.Printf("Error cancelling reservation %d upstream: %v", reservation.ID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "No se pudo cancelar la reserva en Amadeus, intente más tarde"})
+		return
+	}
+
+	previous := reservation.Status
+	reservation.Status = "cancelled"
+	if err := s.reservations.UpdateValidation(c.Request.Context(), &reservation); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.invalidateAvailability(reservation.HotelID)
+	s.publishStatusChange(c.Request.Context(), reservation, previous)
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+func (s *UserService) getUserReservations(c *gin.Context) {
+	userIDStr := c.Param("id")
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Check if user is accessing their own reservations or is admin
+	currentUserID, _ := c.Get("user_id")
+	isAdmin, _ := c.Get("is_admin")
+
+	if currentUserID.(int) != userID && !isAdmin.(bool) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	reservations, err := s.reservations.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reservations)
+}
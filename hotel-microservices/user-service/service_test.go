@@ -0,0 +1,510 @@
+package userservice
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"events"
+)
+
+const testJWTSecret = "test-secret"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type testEnv struct {
+	service   *UserService
+	store     *MemoryStore
+	publisher *MemoryPublisher
+	router    *gin.Engine
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	store := NewMemoryStore()
+	publisher := NewMemoryPublisher()
+	service := &UserService{
+		users:          store.Users,
+		reservations:   store.Reservations,
+		mappings:       store.Mappings,
+		erasureJobs:    store.ErasureJobs,
+		paymentRecords: store.Payments,
+		sagas:          store.BookingSagas,
+		holds:          store.Holds,
+		holdTTL:        DefaultHoldTTL,
+		jobs:           store.Jobs,
+		pendingTTL:     DefaultPendingReservationTTL,
+		cache:          NewMemoryCache(),
+		publisher:      publisher,
+		jwtSecret:      testJWTSecret,
+	}
+	service.CreateAdminUser()
+
+	return &testEnv{
+		service:   service,
+		store:     store,
+		publisher: publisher,
+		router:    NewRouter(service),
+	}
+}
+
+func (e *testEnv) createUser(t *testing.T, username string) User {
+	t.Helper()
+
+	user := &User{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: fmt.Sprintf("%x", md5.Sum([]byte("secret"))),
+	}
+	if err := e.store.Users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return *user
+}
+
+func (e *testEnv) mapHotel(t *testing.T, hotelID, amadeusID string) {
+	t.Helper()
+
+	mapping := &HotelMapping{InternalID: hotelID, AmadeusID: amadeusID, Source: MappingSourceManual}
+	if err := e.store.Mappings.Set(context.Background(), mapping); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func tokenFor(t *testing.T, userID int, isAdmin bool) string {
+	t.Helper()
+
+	claims := Claims{
+		UserID:  userID,
+		IsAdmin: isAdmin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func (e *testEnv) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	return w
+}
+
+func date(value string) time.Time {
+	parsed, _ := time.Parse("2006-01-02", value)
+	return parsed
+}
+
+func TestRegister(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do("POST", "/auth/register", "", map[string]string{
+		"username": "juana",
+		"email":    "juana@example.com",
+		"password": "secret",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var user User
+	json.Unmarshal(w.Body.Bytes(), &user)
+	if user.ID == 0 || user.Password != "" || user.IsAdmin {
+		t.Errorf("unexpected registered user %+v", user)
+	}
+
+	w = env.do("POST", "/auth/register", "", map[string]string{
+		"username": "juana",
+		"email":    "otra@example.com",
+		"password": "secret",
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for duplicate username, got %d", w.Code)
+	}
+}
+
+func TestRegisterCannotCreateAdmins(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do("POST", "/auth/register", "", map[string]interface{}{
+		"username": "mallory",
+		"email":    "mallory@example.com",
+		"password": "secret",
+		"is_admin": true,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+
+	var user User
+	json.Unmarshal(w.Body.Bytes(), &user)
+	if user.IsAdmin {
+		t.Error("registration must not grant admin")
+	}
+}
+
+func TestLogin(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "juana")
+
+	w := env.do("POST", "/auth/login", "", map[string]string{"username": "juana", "password": "secret"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Token string `json:"token"`
+		User  User   `json:"user"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Token == "" || response.User.Username != "juana" {
+		t.Fatalf("unexpected login response %s", w.Body.String())
+	}
+
+	// The issued token must be accepted by authenticated routes
+	if w := env.do("GET", fmt.Sprintf("/users/%d/reservations", response.User.ID), response.Token, nil); w.Code != http.StatusOK {
+		t.Errorf("expected issued token to work, got %d", w.Code)
+	}
+
+	w = env.do("POST", "/auth/login", "", map[string]string{"username": "juana", "password": "wrong"})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for bad password, got %d", w.Code)
+	}
+}
+
+func TestAuthMiddlewareRejectsMissingAndInvalidTokens(t *testing.T) {
+	env := newTestEnv(t)
+
+	if w := env.do("GET", "/users", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", w.Code)
+	}
+	if w := env.do("GET", "/users", "garbage", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with invalid token, got %d", w.Code)
+	}
+}
+
+func TestGetUsersRequiresAdmin(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "juana")
+
+	if w := env.do("GET", "/users", tokenFor(t, user.ID, false), nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for non-admin, got %d", w.Code)
+	}
+
+	w := env.do("GET", "/users", tokenFor(t, 1, true), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for admin, got %d", w.Code)
+	}
+
+	var users []User
+	json.Unmarshal(w.Body.Bytes(), &users)
+	if len(users) != 2 {
+		t.Errorf("expected admin and juana, got %d users", len(users))
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "juana")
+	token := tokenFor(t, user.ID, false)
+
+	if w := env.do("GET", fmt.Sprintf("/users/%d", user.ID), token, nil); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if w := env.do("GET", "/users/999", token, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+	if w := env.do("GET", "/users/abc", token, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestCreateReservation(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "juana")
+	token := tokenFor(t, user.ID, false)
+
+	body := map[string]interface{}{
+		"hotel_id":  "hotel-1",
+		"check_in":  "2026-03-10T00:00:00Z",
+		"check_out": "2026-03-12T00:00:00Z",
+		"guests":    2,
+		"rooms":     1,
+	}
+
+	w := env.do("POST", "/reservations", token, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var reservation Reservation
+	json.Unmarshal(w.Body.Bytes(), &reservation)
+	if reservation.ID == 0 || reservation.UserID != user.ID || reservation.Status != "confirmed" {
+		t.Errorf("unexpected reservation %+v", reservation)
+	}
+
+	// Same dates are no longer available
+	if w := env.do("POST", "/reservations", token, body); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for overlapping reservation, got %d", w.Code)
+	}
+
+	if w := env.do("POST", "/reservations", "", body); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", w.Code)
+	}
+}
+
+func TestGetReservationsRequiresAdmin(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "juana")
+	env.store.Reservations.Create(context.Background(), &Reservation{UserID: user.ID, HotelID: "h", Status: "confirmed"})
+
+	if w := env.do("GET", "/reservations", tokenFor(t, user.ID, false), nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for non-admin, got %d", w.Code)
+	}
+
+	w := env.do("GET", "/reservations", tokenFor(t, 1, true), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var reservations []Reservation
+	json.Unmarshal(w.Body.Bytes(), &reservations)
+	if len(reservations) != 1 {
+		t.Errorf("expected 1 reservation, got %d", len(reservations))
+	}
+}
+
+func TestGetUserReservations(t *testing.T) {
+	env := newTestEnv(t)
+	juana := env.createUser(t, "juana")
+	pedro := env.createUser(t, "pedro")
+	env.store.Reservations.Create(context.Background(), &Reservation{UserID: juana.ID, HotelID: "h", Status: "confirmed"})
+	path := fmt.Sprintf("/users/%d/reservations", juana.ID)
+
+	w := env.do("GET", path, tokenFor(t, juana.ID, false), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for owner, got %d", w.Code)
+	}
+	var reservations []Reservation
+	json.Unmarshal(w.Body.Bytes(), &reservations)
+	if len(reservations) != 1 {
+		t.Errorf("expected 1 reservation, got %d", len(reservations))
+	}
+
+	if w := env.do("GET", path, tokenFor(t, pedro.ID, false), nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for another user, got %d", w.Code)
+	}
+	if w := env.do("GET", path, tokenFor(t, 1, true), nil); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for admin, got %d", w.Code)
+	}
+	if w := env.do("GET", "/users/abc/reservations", tokenFor(t, 1, true), nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid ID, got %d", w.Code)
+	}
+}
+
+func TestCheckAvailability(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "juana")
+	env.store.Reservations.Create(context.Background(), &Reservation{
+		UserID:   user.ID,
+		HotelID:  "hotel-1",
+		CheckIn:  date("2026-03-10"),
+		CheckOut: date("2026-03-12"),
+		Status:   "confirmed",
+	})
+
+	cases := []struct {
+		query     string
+		code      int
+		available bool
+	}{
+		{"", http.StatusBadRequest, false},
+		{"hotel_id=hotel-1", http.StatusOK, true},
+		{"hotel_id=hotel-1&check_in=2026-03-11&check_out=2026-03-13", http.StatusOK, false},
+		{"hotel_id=hotel-1&check_in=2026-03-12&check_out=2026-03-14", http.StatusOK, true},
+		{"hotel_id=hotel-2&check_in=2026-03-10&check_out=2026-03-12", http.StatusOK, true},
+		{"hotel_id=hotel-1&check_in=tomorrow&check_out=2026-03-12", http.StatusBadRequest, false},
+	}
+
+	for _, tc := range cases {
+		w := env.do("GET", "/availability?"+tc.query, "", nil)
+		if w.Code != tc.code {
+			t.Errorf("%q: expected %d, got %d", tc.query, tc.code, w.Code)
+			continue
+		}
+		if tc.code != http.StatusOK {
+			continue
+		}
+
+		var result map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &result)
+		if result["available"] != tc.available {
+			t.Errorf("%q: expected available=%v, got %v", tc.query, tc.available, result["available"])
+		}
+	}
+}
+
+func TestCheckAvailabilityUsesCache(t *testing.T) {
+	env := newTestEnv(t)
+	query := "/availability?hotel_id=hotel-1&check_in=2026-03-10&check_out=2026-03-12"
+
+	if w := env.do("GET", query, "", nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	// A reservation made after the first lookup is hidden by the cached answer
+	env.store.Reservations.Create(context.Background(), &Reservation{
+		HotelID:  "hotel-1",
+		CheckIn:  date("2026-03-10"),
+		CheckOut: date("2026-03-12"),
+		Status:   "confirmed",
+	})
+
+	w := env.do("GET", query, "", nil)
+	var result map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &result)
+	if result["available"] != true {
+		t.Errorf("expected cached availability, got %v", result["available"])
+	}
+}
+
+func TestExportUserData(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "juana")
+	env.store.Reservations.Create(context.Background(), &Reservation{UserID: user.ID, HotelID: "h", Status: "confirmed"})
+
+	w := env.do("GET", "/users/me/export", tokenFor(t, user.ID, false), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Disposition") == "" {
+		t.Error("expected export to be served as an attachment")
+	}
+
+	var export UserExport
+	json.Unmarshal(w.Body.Bytes(), &export)
+	if export.User.Username != "juana" || len(export.Reservations) != 1 || export.FormatVersion != 1 {
+		t.Errorf("unexpected export %+v", export)
+	}
+}
+
+func TestErasure(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "juana")
+	env.store.Reservations.Create(context.Background(), &Reservation{UserID: user.ID, HotelID: "h", AmadeusID: "ABC123", Status: "confirmed"})
+	path := fmt.Sprintf("/users/%d/erasure", user.ID)
+	adminToken := tokenFor(t, 1, true)
+
+	if w := env.do("POST", path, tokenFor(t, user.ID, false), nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for non-admin, got %d", w.Code)
+	}
+	if w := env.do("POST", "/users/1/erasure", adminToken, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 when admin erases self, got %d", w.Code)
+	}
+	if w := env.do("POST", "/users/999/erasure", adminToken, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown user, got %d", w.Code)
+	}
+	if w := env.do("GET", path, adminToken, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 before any erasure, got %d", w.Code)
+	}
+
+	if w := env.do("POST", path, adminToken, nil); w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", w.Code)
+	}
+
+	var job ErasureJob
+	for i := 0; i < 100; i++ {
+		w := env.do("GET", path, adminToken, nil)
+		json.Unmarshal(w.Body.Bytes(), &job)
+		if job.Status == "completed" {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if job.Status != "completed" {
+		t.Fatalf("expected erasure job to complete, got %+v", job)
+	}
+
+	erased, _ := env.store.Users.FindByID(context.Background(), user.ID)
+	if erased.Username == "juana" || erased.Email == user.Email {
+		t.Errorf("expected user PII to be anonymized, got %+v", erased)
+	}
+	reservations, _ := env.store.Reservations.ListByUser(context.Background(), user.ID)
+	if reservations[0].AmadeusID != "" {
+		t.Errorf("expected reservation references to be cleared, got %+v", reservations[0])
+	}
+
+	messages := env.publisher.Messages()
+	if len(messages) != 1 || messages[0].Exchange != UserErasuresExchange || messages[0].RoutingKey != events.UserErased {
+		t.Fatalf("expected one user.erased event, got %+v", messages)
+	}
+	envelope, err := events.Decode(messages[0].Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload events.UserErasedPayload
+	if err := envelope.DecodePayload(&payload); err != nil || payload.UserID != user.ID || payload.JobID != job.ID {
+		t.Fatalf("unexpected user.erased payload %+v, %v", payload, err)
+	}
+}
+
+func TestResumeErasureJobs(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	pending := env.createUser(t, "juana")
+	interrupted := env.createUser(t, "pedro")
+	running := env.createUser(t, "maria")
+
+	// Jobs que quedaron así porque el proceso se cayó, y uno que otra réplica está corriendo
+	for _, userID := range []int{pending.ID, interrupted.ID, running.ID} {
+		env.store.ErasureJobs.Create(ctx, &ErasureJob{UserID: userID, RequestedBy: 1, Status: "pending"})
+	}
+	env.store.ErasureJobs.Claim(ctx, 2, time.Now())
+	env.store.ErasureJobs.Claim(ctx, 3, time.Now())
+	env.store.mu.Lock()
+	startedAt := time.Now().Add(-2 * DefaultErasureStaleAfter)
+	env.store.erasureJobs[1].StartedAt = &startedAt
+	env.store.mu.Unlock()
+
+	if resumed, err := env.service.ResumeErasureJobs(ctx); err != nil || resumed != 2 {
+		t.Fatalf("expected two jobs resumed, got %d, %v", resumed, err)
+	}
+	for _, user := range []User{pending, interrupted} {
+		if job, _ := env.store.ErasureJobs.LatestForUser(ctx, user.ID); job.Status != "completed" {
+			t.Errorf("expected the job of %s to complete, got %+v", user.Username, job)
+		}
+	}
+	if job, _ := env.store.ErasureJobs.LatestForUser(ctx, running.ID); job.Status != "running" {
+		t.Errorf("expected a recently started job to be left alone, got %+v", job)
+	}
+	if resumed, _ := env.service.ResumeErasureJobs(ctx); resumed != 0 {
+		t.Fatalf("expected nothing left to resume, got %d", resumed)
+	}
+}